@@ -6,7 +6,10 @@
 
 package arm
 
-import "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
 
 // Endpoint is the base URL for Azure Resource Manager.
 type Endpoint string
@@ -26,12 +29,18 @@ const (
 type ClientOptions struct {
 	policy.ClientOptions
 
+	// Cloud specifies the cloud targeted by the client.  Its Resource Manager endpoint is used
+	// when Host is empty, and its Resource Manager audience determines the scope of access tokens.
+	// Defaults to cloud.AzurePublic.
+	Cloud cloud.Configuration
+
 	// AuxiliaryTenants contains a list of additional tenants for cross-tenant requests.
 	AuxiliaryTenants []string
 
 	// DisableRPRegistration disables the auto-RP registration policy. Defaults to false.
 	DisableRPRegistration bool
 
-	// Host is the base URL for Azure Resource Manager. Defaults to AzurePublicCloud.
+	// Host is the base URL for Azure Resource Manager. When set, it takes precedence over the
+	// Cloud's Resource Manager endpoint. Defaults to AzurePublicCloud.
 	Host Endpoint
 }
@@ -0,0 +1,266 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package arm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	subscriptionsKey  = "subscriptions"
+	resourceGroupsKey = "resourceGroups"
+	providersKey      = "providers"
+
+	// resourcesNamespace is the namespace of tenants, subscriptions, resource groups and providers.
+	resourcesNamespace = "Microsoft.Resources"
+)
+
+// ResourceType is the type of an Azure resource, e.g. "Microsoft.Network/virtualNetworks/subnets".
+type ResourceType struct {
+	// Namespace is the resource provider namespace, e.g. "Microsoft.Network".
+	Namespace string
+
+	// Types contains the type of each resource in the hierarchy, e.g. ["virtualNetworks", "subnets"].
+	Types []string
+}
+
+// String returns the type in the format "Namespace/Type1/Type2".
+func (rt ResourceType) String() string {
+	return strings.Join(append([]string{rt.Namespace}, rt.Types...), "/")
+}
+
+// LastType returns the type of the innermost resource, e.g. "subnets".
+func (rt ResourceType) LastType() string {
+	if len(rt.Types) == 0 {
+		return ""
+	}
+	return rt.Types[len(rt.Types)-1]
+}
+
+// equalFold returns true when the types differ at most in case.
+func (rt ResourceType) equalFold(other ResourceType) bool {
+	return strings.EqualFold(rt.String(), other.String())
+}
+
+var (
+	tenantResourceType        = ResourceType{Namespace: resourcesNamespace, Types: []string{"tenants"}}
+	subscriptionResourceType  = ResourceType{Namespace: resourcesNamespace, Types: []string{subscriptionsKey}}
+	resourceGroupResourceType = ResourceType{Namespace: resourcesNamespace, Types: []string{resourceGroupsKey}}
+	providerResourceType      = ResourceType{Namespace: resourcesNamespace, Types: []string{providersKey}}
+)
+
+// newRootResourceID returns the ID of the tenant, "/".  It's the ancestor of all other resource IDs.
+func newRootResourceID() *ResourceID {
+	return &ResourceID{ResourceType: tenantResourceType}
+}
+
+// ResourceID is a parsed Azure Resource Manager resource ID, e.g.
+// "/subscriptions/{id}/resourceGroups/{name}/providers/Microsoft.Compute/virtualMachines/{name}".
+// Use ParseResourceID to parse an ID, or the constructors to build one.
+type ResourceID struct {
+	// Parent is the ID of the resource containing this one.  It's nil only for the tenant's ID, "/".
+	Parent *ResourceID
+
+	// SubscriptionID is the ID of the subscription containing the resource.  It's empty
+	// for tenant-level resources.
+	SubscriptionID string
+
+	// ResourceGroupName is the name of the resource group containing the resource.  It's empty
+	// for tenant-level and subscription-level resources.
+	ResourceGroupName string
+
+	// ResourceType is the type of the resource.
+	ResourceType ResourceType
+
+	// Name is the name of the resource.
+	Name string
+
+	// isChild is true when the resource is nested within its parent's namespace, i.e. its ID
+	// is "{parent}/{type}/{name}" rather than "{parent}/providers/{namespace}/{type}/{name}"
+	isChild bool
+}
+
+// NewSubscriptionResourceID creates the ID of the specified subscription.
+func NewSubscriptionResourceID(subscriptionID string) *ResourceID {
+	return &ResourceID{
+		Parent:         newRootResourceID(),
+		SubscriptionID: subscriptionID,
+		ResourceType:   subscriptionResourceType,
+		Name:           subscriptionID,
+	}
+}
+
+// NewResourceGroupResourceID creates the ID of the specified resource group.
+func NewResourceGroupResourceID(subscriptionID, resourceGroupName string) *ResourceID {
+	return &ResourceID{
+		Parent:            NewSubscriptionResourceID(subscriptionID),
+		SubscriptionID:    subscriptionID,
+		ResourceGroupName: resourceGroupName,
+		ResourceType:      resourceGroupResourceType,
+		Name:              resourceGroupName,
+	}
+}
+
+// NewResourceID creates the ID of a resource of type namespace/resourceType within parent, e.g. a virtual
+// machine within a resource group.  When parent is itself a provider resource, the new ID is that of an
+// extension resource, e.g. "{parent}/providers/Microsoft.Authorization/locks/{name}".
+func NewResourceID(parent *ResourceID, namespace, resourceType, name string) *ResourceID {
+	return &ResourceID{
+		Parent:            parent,
+		SubscriptionID:    parent.SubscriptionID,
+		ResourceGroupName: parent.ResourceGroupName,
+		ResourceType:      ResourceType{Namespace: namespace, Types: []string{resourceType}},
+		Name:              name,
+	}
+}
+
+// NewChildResourceID creates the ID of a child resource of parent, e.g. a subnet within a virtual network.
+func NewChildResourceID(parent *ResourceID, childType, name string) *ResourceID {
+	types := make([]string, len(parent.ResourceType.Types), len(parent.ResourceType.Types)+1)
+	copy(types, parent.ResourceType.Types)
+	return &ResourceID{
+		Parent:            parent,
+		SubscriptionID:    parent.SubscriptionID,
+		ResourceGroupName: parent.ResourceGroupName,
+		ResourceType:      ResourceType{Namespace: parent.ResourceType.Namespace, Types: append(types, childType)},
+		Name:              name,
+		isChild:           true,
+	}
+}
+
+// ParseResourceID parses an Azure Resource Manager resource ID.  The segment names "subscriptions",
+// "resourceGroups" and "providers" are matched case-insensitively.  Use String to get the ID's normalized form.
+func ParseResourceID(id string) (*ResourceID, error) {
+	if !strings.HasPrefix(id, "/") {
+		return nil, fmt.Errorf("invalid resource ID %q: must start with '/'", id)
+	}
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(id, "/"), "/")
+	if trimmed == "" {
+		return newRootResourceID(), nil
+	}
+	parts := strings.Split(trimmed, "/")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid resource ID %q: contains an empty segment", id)
+		}
+	}
+	parsed, err := parseSegments(newRootResourceID(), parts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource ID %q: %s", id, err)
+	}
+	return parsed, nil
+}
+
+// ParseResourceIDPtr parses the resource ID pointed to by id, such as the ID field of a model returned by a client.
+func ParseResourceIDPtr(id *string) (*ResourceID, error) {
+	if id == nil {
+		return nil, errors.New("resource ID is nil")
+	}
+	return ParseResourceID(*id)
+}
+
+// parseSegments parses the segments following parent, one resource at a time.
+func parseSegments(parent *ResourceID, parts []string) (*ResourceID, error) {
+	if len(parts) == 0 {
+		return parent, nil
+	}
+	switch {
+	case strings.EqualFold(parts[0], subscriptionsKey) && parent.isRoot():
+		if len(parts) < 2 {
+			return nil, errors.New("missing subscription ID")
+		}
+		return parseSegments(NewSubscriptionResourceID(parts[1]), parts[2:])
+	case strings.EqualFold(parts[0], resourceGroupsKey) && parent.ResourceType.equalFold(subscriptionResourceType):
+		if len(parts) < 2 {
+			return nil, errors.New("missing resource group name")
+		}
+		return parseSegments(NewResourceGroupResourceID(parent.SubscriptionID, parts[1]), parts[2:])
+	case strings.EqualFold(parts[0], providersKey):
+		switch len(parts) {
+		case 1:
+			return nil, errors.New("missing provider namespace")
+		case 2:
+			// a provider, e.g. /subscriptions/{id}/providers/Microsoft.Compute
+			return &ResourceID{
+				Parent:            parent,
+				SubscriptionID:    parent.SubscriptionID,
+				ResourceGroupName: parent.ResourceGroupName,
+				ResourceType:      providerResourceType,
+				Name:              parts[1],
+			}, nil
+		case 3:
+			return nil, fmt.Errorf("missing name of %s/%s resource", parts[1], parts[2])
+		}
+		return parseSegments(NewResourceID(parent, parts[1], parts[2], parts[3]), parts[4:])
+	}
+	if parent.isRoot() || parent.ResourceType.equalFold(subscriptionResourceType) || parent.ResourceType.equalFold(resourceGroupResourceType) {
+		return nil, fmt.Errorf("unexpected segment %q", parts[0])
+	}
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("missing name of %s resource", parts[0])
+	}
+	return parseSegments(NewChildResourceID(parent, parts[0], parts[1]), parts[2:])
+}
+
+// isRoot returns true when id is the tenant's ID, "/"
+func (id *ResourceID) isRoot() bool {
+	return id.Parent == nil && id.ResourceType.equalFold(tenantResourceType)
+}
+
+// String returns the normalized ID, e.g. "/subscriptions/{id}/resourceGroups/{name}".
+// It has a leading slash, no trailing slash and the canonical case of segment names.
+func (id *ResourceID) String() string {
+	if id.Parent == nil {
+		return "/"
+	}
+	// the root's ID is "/" but it's omitted as a prefix
+	prefix := ""
+	if id.Parent.Parent != nil {
+		prefix = id.Parent.String()
+	}
+	switch {
+	case id.ResourceType.equalFold(subscriptionResourceType):
+		return prefix + "/" + subscriptionsKey + "/" + id.Name
+	case id.ResourceType.equalFold(resourceGroupResourceType):
+		return prefix + "/" + resourceGroupsKey + "/" + id.Name
+	case id.ResourceType.equalFold(providerResourceType):
+		return prefix + "/" + providersKey + "/" + id.Name
+	case id.isChild:
+		return prefix + "/" + id.ResourceType.LastType() + "/" + id.Name
+	}
+	return prefix + "/" + providersKey + "/" + id.ResourceType.Namespace + "/" + id.ResourceType.LastType() + "/" + id.Name
+}
+
+// StringPtr returns a pointer to the normalized ID, suitable for the ID fields of models.
+func (id *ResourceID) StringPtr() *string {
+	s := id.String()
+	return &s
+}
+
+// Equal returns true when id and other identify the same resource.  Resource IDs are case-insensitive.
+func (id *ResourceID) Equal(other *ResourceID) bool {
+	if id == nil || other == nil {
+		return id == other
+	}
+	return strings.EqualFold(id.String(), other.String())
+}
+
+// Validate returns an error when the ID isn't a valid resource ID, e.g. because it was built
+// with an empty name.
+func (id *ResourceID) Validate() error {
+	s := id.String()
+	parsed, err := ParseResourceID(s)
+	if err != nil {
+		return err
+	}
+	if parsed.String() != s {
+		return fmt.Errorf("invalid resource ID %q: segment names are ambiguous", s)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package arm
+
+import (
+	"testing"
+)
+
+const (
+	subID  = "00000000-0000-0000-0000-000000000000"
+	rgID   = "/subscriptions/" + subID + "/resourceGroups/myRg"
+	vnetID = rgID + "/providers/Microsoft.Network/virtualNetworks/myNet"
+)
+
+func TestParseResourceID(t *testing.T) {
+	for _, test := range []struct {
+		id           string
+		subID        string
+		rgName       string
+		resourceType string
+		name         string
+		parent       string
+	}{
+		{"/", "", "", "Microsoft.Resources/tenants", "", ""},
+		{"/subscriptions/" + subID, subID, "", "Microsoft.Resources/subscriptions", subID, "/"},
+		{rgID, subID, "myRg", "Microsoft.Resources/resourceGroups", "myRg", "/subscriptions/" + subID},
+		{vnetID, subID, "myRg", "Microsoft.Network/virtualNetworks", "myNet", rgID},
+		{vnetID + "/subnets/mySubnet", subID, "myRg", "Microsoft.Network/virtualNetworks/subnets", "mySubnet", vnetID},
+		{vnetID + "/providers/Microsoft.Authorization/locks/myLock", subID, "myRg", "Microsoft.Authorization/locks", "myLock", vnetID},
+		{"/subscriptions/" + subID + "/providers/Microsoft.Compute", subID, "", "Microsoft.Resources/providers", "Microsoft.Compute", "/subscriptions/" + subID},
+		{"/subscriptions/" + subID + "/providers/Microsoft.Compute/locations/westus", subID, "", "Microsoft.Compute/locations", "westus", "/subscriptions/" + subID},
+		{"/providers/Microsoft.Management/managementGroups/myGroup", "", "", "Microsoft.Management/managementGroups", "myGroup", "/"},
+	} {
+		id, err := ParseResourceID(test.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id.SubscriptionID != test.subID || id.ResourceGroupName != test.rgName || id.Name != test.name {
+			t.Fatalf("unexpected ID %+v for %s", id, test.id)
+		}
+		if rt := id.ResourceType.String(); rt != test.resourceType {
+			t.Fatalf("unexpected resource type %s for %s", rt, test.id)
+		}
+		if s := id.String(); s != test.id {
+			t.Fatalf("unexpected string %s for %s", s, test.id)
+		}
+		if test.parent == "" {
+			if id.Parent != nil {
+				t.Fatalf("unexpected parent %s for %s", id.Parent, test.id)
+			}
+		} else if p := id.Parent.String(); p != test.parent {
+			t.Fatalf("unexpected parent %s for %s", p, test.id)
+		}
+		if err := id.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseResourceIDNormalizes(t *testing.T) {
+	id, err := ParseResourceID("/SUBSCRIPTIONS/" + subID + "/resourcegroups/myRg/PROVIDERS/Microsoft.Network/virtualNetworks/myNet/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := id.String(); s != vnetID {
+		t.Fatalf("unexpected string %s", s)
+	}
+	other, err := ParseResourceID(vnetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.Equal(other) {
+		t.Fatal("expected IDs to be equal")
+	}
+	if id.Equal(other.Parent) {
+		t.Fatal("expected IDs to differ")
+	}
+}
+
+func TestParseResourceIDInvalid(t *testing.T) {
+	for _, id := range []string{
+		"",
+		"subscriptions/" + subID,
+		"/subscriptions",
+		"/subscriptions//resourceGroups/myRg",
+		rgID + "/providers",
+		rgID + "/providers/Microsoft.Network/virtualNetworks",
+		vnetID + "/subnets",
+		"/resourceGroups/myRg",
+		"/subscriptions/" + subID + "/virtualNetworks/myNet",
+	} {
+		if _, err := ParseResourceID(id); err == nil {
+			t.Fatalf("expected an error for %q", id)
+		}
+	}
+}
+
+func TestParseResourceIDPtr(t *testing.T) {
+	if _, err := ParseResourceIDPtr(nil); err == nil {
+		t.Fatal("expected an error for nil")
+	}
+	s := vnetID
+	id, err := ParseResourceIDPtr(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := id.StringPtr(); p == nil || *p != vnetID {
+		t.Fatalf("unexpected pointer value %v", p)
+	}
+}
+
+func TestNewResourceID(t *testing.T) {
+	vnet := NewResourceID(NewResourceGroupResourceID(subID, "myRg"), "Microsoft.Network", "virtualNetworks", "myNet")
+	subnet := NewChildResourceID(vnet, "subnets", "mySubnet")
+	if s := subnet.String(); s != vnetID+"/subnets/mySubnet" {
+		t.Fatalf("unexpected string %s", s)
+	}
+	if subnet.SubscriptionID != subID || subnet.ResourceGroupName != "myRg" {
+		t.Fatalf("unexpected ID %+v", subnet)
+	}
+	if rt := subnet.ResourceType.String(); rt != "Microsoft.Network/virtualNetworks/subnets" {
+		t.Fatalf("unexpected resource type %s", rt)
+	}
+	// the child's types must not alias the parent's
+	NewChildResourceID(vnet, "virtualNetworkPeerings", "myPeering")
+	if rt := subnet.ResourceType.String(); rt != "Microsoft.Network/virtualNetworks/subnets" {
+		t.Fatalf("unexpected resource type %s", rt)
+	}
+	if err := subnet.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	lock := NewResourceID(vnet, "Microsoft.Authorization", "locks", "myLock")
+	if s := lock.String(); s != vnetID+"/providers/Microsoft.Authorization/locks/myLock" {
+		t.Fatalf("unexpected string %s", s)
+	}
+	if err := NewChildResourceID(vnet, "subnets", "").Validate(); err == nil {
+		t.Fatal("expected an error for an empty name")
+	}
+	if err := NewChildResourceID(vnet, "subnets", "a/b").Validate(); err == nil {
+		t.Fatal("expected an error for a name containing '/'")
+	}
+}
+
+func TestParseResourceIDRootIsNotShared(t *testing.T) {
+	root, err := ParseResourceID("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// modifying a parsed ID doesn't affect later parsing
+	root.ResourceType = subscriptionResourceType
+	id, err := ParseResourceID("/subscriptions/" + subID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.SubscriptionID != subID || id.Parent.String() != "/" {
+		t.Fatalf("unexpected ID %+v", id)
+	}
+}
@@ -22,33 +22,33 @@ func NewPipeline(module, version string, cred azcore.TokenCredential, options *a
 		options = &arm.ClientOptions{}
 	}
 	ep := options.Host
+	if len(ep) == 0 {
+		ep = arm.Endpoint(options.Cloud.ResourceManagerEndpoint)
+	}
 	if len(ep) == 0 {
 		ep = arm.AzurePublicCloud
 	}
-	policies := []policy.Policy{}
-	if !options.Telemetry.Disabled {
-		policies = append(policies, azruntime.NewTelemetryPolicy(module, version, &options.Telemetry))
+	scope := shared.EndpointToScope(string(ep))
+	aud := options.Cloud.ResourceManagerAudience
+	if aud != "" {
+		scope = shared.EndpointToScope(aud)
 	}
+	authPolicy := azruntime.NewBearerTokenPolicy(cred, azruntime.AuthenticationOptions{
+		TokenRequest: policy.TokenRequestOptions{
+			Scopes: []string{scope},
+		},
+		AuxiliaryTenants: options.AuxiliaryTenants,
+	})
+	plOpts := azruntime.PipelineOptions{PerRetry: []policy.Policy{authPolicy}}
 	if !options.DisableRPRegistration {
 		regRPOpts := RegistrationOptions{
+			Audience:   aud,
 			HTTPClient: options.Transport,
 			Logging:    options.Logging,
 			Retry:      options.Retry,
 			Telemetry:  options.Telemetry,
 		}
-		policies = append(policies, NewRPRegistrationPolicy(string(ep), cred, &regRPOpts))
+		plOpts.PerCall = append(plOpts.PerCall, NewRPRegistrationPolicy(string(ep), cred, &regRPOpts))
 	}
-	policies = append(policies, options.PerCallPolicies...)
-	policies = append(policies, azruntime.NewRetryPolicy(&options.Retry))
-	policies = append(policies, options.PerRetryPolicies...)
-	policies = append(policies,
-		azruntime.NewBearerTokenPolicy(cred, azruntime.AuthenticationOptions{
-			TokenRequest: policy.TokenRequestOptions{
-				Scopes: []string{shared.EndpointToScope(string(ep))},
-			},
-			AuxiliaryTenants: options.AuxiliaryTenants,
-		},
-		),
-		azruntime.NewLogPolicy(&options.Logging))
-	return azruntime.NewPipeline(options.Transport, policies...)
+	return azruntime.NewClientPipeline(module, version, plOpts, &options.ClientOptions)
 }
@@ -15,6 +15,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	azruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
@@ -147,3 +148,76 @@ func TestPipelineWithCustomPolicies(t *testing.T) {
 		t.Fatalf("unexpected per retry policy count %d", perRetryPolicy.count)
 	}
 }
+
+// credential that records the scopes of token requests
+type scopeRecordingCred struct {
+	scopes []string
+}
+
+func (c *scopeRecordingCred) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	c.scopes = append(c.scopes, opts.Scopes...)
+	return &azcore.AccessToken{Token: "abc123", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestNewPipelineWithCloud(t *testing.T) {
+	for _, test := range []struct {
+		opts  arm.ClientOptions
+		scope string
+	}{
+		{arm.ClientOptions{}, "https://management.azure.com//.default"},
+		{arm.ClientOptions{Host: arm.AzureChina}, "https://management.chinacloudapi.cn//.default"},
+		{arm.ClientOptions{Cloud: cloud.AzureGovernment}, "https://management.core.usgovcloudapi.net//.default"},
+		{arm.ClientOptions{Cloud: cloud.Configuration{ResourceManagerEndpoint: "https://management.local.azurestack.external"}}, "https://management.local.azurestack.external//.default"},
+	} {
+		srv, close := mock.NewServer()
+		srv.AppendResponse()
+		test.opts.Transport = srv
+		test.opts.DisableRPRegistration = true
+		cred := &scopeRecordingCred{}
+		req, err := azruntime.NewRequest(context.Background(), http.MethodGet, srv.URL())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = NewPipeline("armtest", "v1.2.3", cred, &test.opts).Do(req); err != nil {
+			t.Fatal(err)
+		}
+		close()
+		if len(cred.scopes) != 1 || cred.scopes[0] != test.scope {
+			t.Fatalf("unexpected scopes %v", cred.scopes)
+		}
+	}
+}
+
+func TestNewPipelineRPRegistrationWithCloud(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	// initial response that RP is unregistered
+	srv.AppendResponse(mock.WithStatusCode(http.StatusConflict), mock.WithBody([]byte(rpUnregisteredResp)))
+	// responses to Register() and Get(), successful registration
+	srv.RepeatResponse(2, mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte(rpRegisteredResp)))
+	// response for original request
+	srv.AppendResponse(mock.WithStatusCode(http.StatusAccepted))
+	opts := arm.ClientOptions{Cloud: cloud.AzureGovernment, Host: arm.Endpoint(srv.URL())}
+	opts.Transport = srv
+	cred := &scopeRecordingCred{}
+	req, err := azruntime.NewRequest(context.Background(), http.MethodGet, azruntime.JoinPaths(srv.URL(), requestEndpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewPipeline("armtest", "v1.2.3", cred, &opts).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	// the client's pipeline and the registration policy's pipeline each request a token
+	if len(cred.scopes) != 2 {
+		t.Fatalf("expected 2 token requests, got %v", cred.scopes)
+	}
+	for _, s := range cred.scopes {
+		if s != "https://management.core.usgovcloudapi.net//.default" {
+			t.Fatalf("unexpected scope %s", s)
+		}
+	}
+}
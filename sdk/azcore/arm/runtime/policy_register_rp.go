@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pipeline"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -49,6 +50,11 @@ type RegistrationOptions struct {
 	// NOTE: Setting this to a small value might cause the policy to prematurely fail.
 	PollingDuration time.Duration
 
+	// Audience is the audience of the access tokens the policy requests to register resource providers.
+	// The default value is derived from the endpoint, which is correct only in clouds whose Resource
+	// Manager endpoint is also its token audience.
+	Audience string
+
 	// HTTPClient sets the transport for making HTTP requests.
 	HTTPClient policy.Transporter
 
@@ -87,12 +93,16 @@ func NewRPRegistrationPolicy(endpoint string, cred azcore.TokenCredential, o *Re
 	if o == nil {
 		o = &RegistrationOptions{}
 	}
+	scope := shared.EndpointToScope(endpoint)
+	if o.Audience != "" {
+		scope = shared.EndpointToScope(o.Audience)
+	}
 	p := &rpRegistrationPolicy{
 		endpoint: endpoint,
 		pipeline: runtime.NewPipeline(o.HTTPClient,
 			runtime.NewTelemetryPolicy(shared.Module, shared.Version, &o.Telemetry),
 			runtime.NewRetryPolicy(&o.Retry),
-			runtime.NewBearerTokenPolicy(cred, runtime.AuthenticationOptions{TokenRequest: policy.TokenRequestOptions{Scopes: []string{shared.EndpointToScope(endpoint)}}}),
+			runtime.NewBearerTokenPolicy(cred, runtime.AuthenticationOptions{TokenRequest: policy.TokenRequestOptions{Scopes: []string{scope}}}),
 			runtime.NewLogPolicy(&o.Logging)),
 		options: *o,
 	}
@@ -202,11 +212,19 @@ func (r *rpRegistrationPolicy) Do(req *policy.Request) (*http.Response, error) {
 	return resp, fmt.Errorf("exceeded attempts to register %s", rp)
 }
 
+// getSubscription returns the subscription ID from the request's URL path.  The path isn't
+// necessarily a resource ID, e.g. it may be a collection or an action, so only the prefix up
+// to and including the subscription ID is parsed.
 func getSubscription(path string) (string, error) {
 	parts := strings.Split(path, "/")
 	for i, v := range parts {
-		if v == "subscriptions" && (i+1) < len(parts) {
-			return parts[i+1], nil
+		if strings.EqualFold(v, "subscriptions") && (i+1) < len(parts) {
+			// the path may have a prefix, e.g. a proxy's, before the resource ID
+			id, err := arm.ParseResourceID("/" + strings.Join(parts[i:i+2], "/"))
+			if err != nil {
+				return "", err
+			}
+			return id.SubscriptionID, nil
 		}
 	}
 	return "", fmt.Errorf("failed to obtain subscription ID from %s", path)
@@ -370,3 +370,25 @@ func TestRPRegistrationPolicyDisabled(t *testing.T) {
 		t.Fatalf("expected 0 log entries, got %d", logEntries)
 	}
 }
+
+func TestGetSubscription(t *testing.T) {
+	for _, path := range []string{
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+		"/Subscriptions/sub/providers/Microsoft.Storage/storageAccounts",
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm/start",
+		"/proxy/subscriptions/sub/resourceGroups/rg",
+	} {
+		subID, err := getSubscription(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subID != "sub" {
+			t.Fatalf("unexpected subscription ID %s from %s", subID, path)
+		}
+	}
+	for _, path := range []string{"/providers/Microsoft.Storage/operations", "/subscriptions//resourceGroups/rg"} {
+		if _, err := getSubscription(path); err == nil {
+			t.Fatalf("expected an error for %s", path)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// Configuration describes the endpoints of an Azure cloud.
+type Configuration struct {
+	// Name is the name of the cloud, e.g. "AzureCloud".
+	Name string
+
+	// ActiveDirectoryAuthorityHost is the base URL of the Azure Active Directory authority.
+	ActiveDirectoryAuthorityHost string
+
+	// ResourceManagerEndpoint is the base URL for Azure Resource Manager.
+	ResourceManagerEndpoint string
+
+	// ResourceManagerAudience is the audience of access tokens for Azure Resource Manager.
+	ResourceManagerAudience string
+
+	// StorageEndpointSuffix is the DNS suffix of storage accounts, e.g. "core.windows.net".
+	StorageEndpointSuffix string
+
+	// KeyVaultDNSSuffix is the DNS suffix of Key Vault vaults, e.g. "vault.azure.net".
+	KeyVaultDNSSuffix string
+}
+
+var (
+	// AzurePublic is the configuration of the Azure public cloud.
+	AzurePublic = Configuration{
+		Name:                         "AzureCloud",
+		ActiveDirectoryAuthorityHost: "https://login.microsoftonline.com/",
+		ResourceManagerEndpoint:      "https://management.azure.com/",
+		ResourceManagerAudience:      "https://management.core.windows.net/",
+		StorageEndpointSuffix:        "core.windows.net",
+		KeyVaultDNSSuffix:            "vault.azure.net",
+	}
+
+	// AzureChina is the configuration of the Azure China cloud.
+	AzureChina = Configuration{
+		Name:                         "AzureChinaCloud",
+		ActiveDirectoryAuthorityHost: "https://login.chinacloudapi.cn/",
+		ResourceManagerEndpoint:      "https://management.chinacloudapi.cn/",
+		ResourceManagerAudience:      "https://management.core.chinacloudapi.cn/",
+		StorageEndpointSuffix:        "core.chinacloudapi.cn",
+		KeyVaultDNSSuffix:            "vault.azure.cn",
+	}
+
+	// AzureGovernment is the configuration of the Azure US government cloud.
+	AzureGovernment = Configuration{
+		Name:                         "AzureUSGovernment",
+		ActiveDirectoryAuthorityHost: "https://login.microsoftonline.us/",
+		ResourceManagerEndpoint:      "https://management.usgovcloudapi.net/",
+		ResourceManagerAudience:      "https://management.core.usgovcloudapi.net/",
+		StorageEndpointSuffix:        "core.usgovcloudapi.net",
+		KeyVaultDNSSuffix:            "vault.usgovcloudapi.net",
+	}
+
+	// AzureGermany is the configuration of the Azure Germany cloud.
+	AzureGermany = Configuration{
+		Name:                         "AzureGermanCloud",
+		ActiveDirectoryAuthorityHost: "https://login.microsoftonline.de/",
+		ResourceManagerEndpoint:      "https://management.microsoftazure.de/",
+		ResourceManagerAudience:      "https://management.core.cloudapi.de/",
+		StorageEndpointSuffix:        "core.cloudapi.de",
+		KeyVaultDNSSuffix:            "vault.microsoftazure.de",
+	}
+)
+
+// metadataAPIVersion is the version of the ARM metadata endpoint's API.
+const metadataAPIVersion = "2019-05-01"
+
+// metadata is an entry in the document returned by the ARM metadata endpoint.
+// Azure returns an array of entries, one per cloud, while Azure Stack returns a single
+// entry describing only its authentication endpoints.
+type metadata struct {
+	Name            string `json:"name"`
+	ResourceManager string `json:"resourceManager"`
+	Authentication  struct {
+		LoginEndpoint string   `json:"loginEndpoint"`
+		Audiences     []string `json:"audiences"`
+	} `json:"authentication"`
+	Suffixes struct {
+		Storage     string `json:"storage"`
+		KeyVaultDNS string `json:"keyVaultDns"`
+	} `json:"suffixes"`
+}
+
+// FromMetadata creates a Configuration from the metadata document of the Azure Resource Manager
+// instance at endpoint.  This supports both Azure and Azure Stack.  When the document describes
+// several clouds, the one whose Resource Manager endpoint matches endpoint is returned, else the first.
+// Pass a nil transport to use the default HTTP client.
+func FromMetadata(ctx context.Context, endpoint string, transport policy.Transporter) (Configuration, error) {
+	if endpoint == "" {
+		return Configuration{}, errors.New("endpoint must not be empty")
+	}
+	req, err := runtime.NewRequest(ctx, http.MethodGet, runtime.JoinPaths(endpoint, "metadata", "endpoints"))
+	if err != nil {
+		return Configuration{}, err
+	}
+	qp := req.Raw().URL.Query()
+	qp.Set("api-version", metadataAPIVersion)
+	req.Raw().URL.RawQuery = qp.Encode()
+	resp, err := runtime.NewPipeline(transport).Do(req)
+	if err != nil {
+		return Configuration{}, err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return Configuration{}, runtime.NewResponseError(nil, resp)
+	}
+	body, err := runtime.Payload(resp)
+	if err != nil {
+		return Configuration{}, err
+	}
+	return parseMetadata(body, endpoint)
+}
+
+func parseMetadata(body []byte, endpoint string) (Configuration, error) {
+	var entries []metadata
+	if err := json.Unmarshal(body, &entries); err != nil {
+		var entry metadata
+		if err := json.Unmarshal(body, &entry); err != nil {
+			return Configuration{}, fmt.Errorf("unmarshalling cloud metadata: %s", err)
+		}
+		entries = []metadata{entry}
+	}
+	if len(entries) == 0 {
+		return Configuration{}, errors.New("cloud metadata contains no clouds")
+	}
+	selected := entries[0]
+	for _, entry := range entries {
+		if sameEndpoint(entry.ResourceManager, endpoint) {
+			selected = entry
+			break
+		}
+	}
+	if selected.Authentication.LoginEndpoint == "" {
+		return Configuration{}, errors.New("cloud metadata doesn't specify an authority host")
+	}
+	cfg := Configuration{
+		Name:                         selected.Name,
+		ActiveDirectoryAuthorityHost: selected.Authentication.LoginEndpoint,
+		ResourceManagerEndpoint:      selected.ResourceManager,
+		StorageEndpointSuffix:        selected.Suffixes.Storage,
+		KeyVaultDNSSuffix:            selected.Suffixes.KeyVaultDNS,
+	}
+	if cfg.ResourceManagerEndpoint == "" {
+		// Azure Stack's document doesn't include its own endpoint
+		cfg.ResourceManagerEndpoint = endpoint
+	}
+	if len(selected.Authentication.Audiences) > 0 {
+		cfg.ResourceManagerAudience = selected.Authentication.Audiences[0]
+	}
+	return cfg, nil
+}
+
+// sameEndpoint returns true when a and b differ at most in case and a trailing slash.
+func sameEndpoint(a, b string) bool {
+	return a != "" && strings.EqualFold(strings.TrimSuffix(a, "/"), strings.TrimSuffix(b, "/"))
+}
@@ -0,0 +1,121 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+const azureMetadata = `[
+	{
+		"name": "AzureCloud",
+		"resourceManager": "https://management.azure.com/",
+		"authentication": {
+			"loginEndpoint": "https://login.microsoftonline.com",
+			"audiences": ["https://management.core.windows.net/", "https://management.azure.com/"]
+		},
+		"suffixes": {"storage": "core.windows.net", "keyVaultDns": "vault.azure.net"}
+	},
+	{
+		"name": "AzureUSGovernment",
+		"resourceManager": "https://management.usgovcloudapi.net",
+		"authentication": {
+			"loginEndpoint": "https://login.microsoftonline.us",
+			"audiences": ["https://management.core.usgovcloudapi.net/"]
+		},
+		"suffixes": {"storage": "core.usgovcloudapi.net", "keyVaultDns": "vault.usgovcloudapi.net"}
+	}
+]`
+
+const azureStackMetadata = `{
+	"galleryEndpoint": "https://providers.local.azurestack.external:30016/",
+	"graphEndpoint": "https://graph.windows.net/",
+	"portalEndpoint": "https://portal.local.azurestack.external/",
+	"authentication": {
+		"loginEndpoint": "https://login.microsoftonline.com/",
+		"audiences": ["https://management.contoso.onmicrosoft.com/0000"]
+	}
+}`
+
+func TestFromMetadata(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte(azureMetadata)))
+	cfg, err := FromMetadata(context.Background(), srv.URL(), srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the server's URL doesn't match any entry, so the first is selected
+	if cfg.Name != "AzureCloud" || cfg.ActiveDirectoryAuthorityHost != "https://login.microsoftonline.com" {
+		t.Fatalf("unexpected configuration %+v", cfg)
+	}
+	if cfg.ResourceManagerAudience != AzurePublic.ResourceManagerAudience {
+		t.Fatalf("unexpected audience %s", cfg.ResourceManagerAudience)
+	}
+	if cfg.StorageEndpointSuffix != "core.windows.net" || cfg.KeyVaultDNSSuffix != "vault.azure.net" {
+		t.Fatalf("unexpected suffixes %+v", cfg)
+	}
+}
+
+func TestParseMetadataSelectsEndpoint(t *testing.T) {
+	cfg, err := parseMetadata([]byte(azureMetadata), "https://MANAGEMENT.usgovcloudapi.net/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "AzureUSGovernment" || cfg.ResourceManagerEndpoint != "https://management.usgovcloudapi.net" {
+		t.Fatalf("unexpected configuration %+v", cfg)
+	}
+	if cfg.StorageEndpointSuffix != AzureGovernment.StorageEndpointSuffix {
+		t.Fatalf("unexpected storage suffix %s", cfg.StorageEndpointSuffix)
+	}
+}
+
+func TestParseMetadataAzureStack(t *testing.T) {
+	const endpoint = "https://management.local.azurestack.external/"
+	cfg, err := parseMetadata([]byte(azureStackMetadata), endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ResourceManagerEndpoint != endpoint {
+		t.Fatalf("unexpected endpoint %s", cfg.ResourceManagerEndpoint)
+	}
+	if cfg.ResourceManagerAudience != "https://management.contoso.onmicrosoft.com/0000" {
+		t.Fatalf("unexpected audience %s", cfg.ResourceManagerAudience)
+	}
+	if cfg.ActiveDirectoryAuthorityHost != "https://login.microsoftonline.com/" {
+		t.Fatalf("unexpected authority host %s", cfg.ActiveDirectoryAuthorityHost)
+	}
+}
+
+func TestParseMetadataInvalid(t *testing.T) {
+	for _, body := range []string{"", "[]", "not json", `{"authentication": {}}`} {
+		if _, err := parseMetadata([]byte(body), "https://management.azure.com"); err == nil {
+			t.Fatalf("expected an error for %q", body)
+		}
+	}
+}
+
+func TestFromMetadataErrorResponse(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithStatusCode(http.StatusNotFound))
+	_, err := FromMetadata(context.Background(), srv.URL(), srv)
+	var respErr *shared.ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !strings.Contains(respErr.RawResponse().Request.URL.RawQuery, "api-version="+metadataAPIVersion) {
+		t.Fatalf("unexpected query %s", respErr.RawResponse().Request.URL.RawQuery)
+	}
+}
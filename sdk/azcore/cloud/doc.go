@@ -0,0 +1,25 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright 2017 Microsoft Corporation. All rights reserved.
+// Use of this source code is governed by an MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package cloud describes the endpoints of an Azure cloud.
+
+A Configuration carries the Azure Active Directory authority host, the Azure Resource Manager
+endpoint and token audience, and the DNS suffixes of data plane services.  ARM clients and
+azidentity credentials accept a Configuration, so a single value targets all of them at the same
+cloud.
+
+Well-known clouds are available as AzurePublic, AzureChina, AzureGovernment and AzureGermany.
+For Azure Stack and other private clouds, load a Configuration from the ARM metadata endpoint.
+
+	cfg, err := cloud.FromMetadata(context.Background(), "https://management.local.azurestack.external/", nil)
+	if err != nil {
+		// handle error
+	}
+	options := arm.ClientOptions{Cloud: cfg}
+*/
+package cloud
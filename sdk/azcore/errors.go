@@ -7,7 +7,9 @@
 package azcore
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/errorinfo"
@@ -22,5 +24,34 @@ type HTTPResponse interface {
 	RawResponse() *http.Response
 }
 
-var _ HTTPResponse = (*shared.ResponseError)(nil)
-var _ errorinfo.NonRetriable = (*shared.ResponseError)(nil)
+// ResponseError is returned when a request is made to a service and
+// the service returns a non-success HTTP status code.
+// It exposes the status code, the service's error code and message, and the request ID.
+// Use errors.As() to access this type in the error chain.
+type ResponseError = shared.ResponseError
+
+var _ HTTPResponse = (*ResponseError)(nil)
+var _ errorinfo.NonRetriable = (*ResponseError)(nil)
+
+// CircuitOpenError is returned when a request isn't sent because the circuit breaker
+// for the request's host is open.  Use errors.As() to detect this error.
+type CircuitOpenError struct {
+	// Host is the host whose circuit is open.
+	Host string
+
+	// RetryAfter is the time remaining until the circuit allows a probe request.
+	// It's zero when another probe request is in progress.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface for type CircuitOpenError.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for host %s", e.Host)
+}
+
+// NonRetriable indicates this error is non-transient.
+func (e *CircuitOpenError) NonRetriable() {
+	// marker method
+}
+
+var _ errorinfo.NonRetriable = (*CircuitOpenError)(nil)
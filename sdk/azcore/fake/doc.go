@@ -0,0 +1,13 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright 2017 Microsoft Corporation. All rights reserved.
+// Use of this source code is governed by an MIT
+// license that can be found in the LICENSE file.
+
+// Package fake provides an in-memory, scriptable policy.Transporter for unit testing code that
+// uses Azure SDK clients without sending requests over the network.  Set a client's transport
+// option to a *Transport, script the responses it should return, then inspect the requests it
+// received.  The LRO responders script the common ARM long-running operation patterns so pollers
+// can be tested offline.
+package fake
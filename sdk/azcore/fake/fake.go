@@ -0,0 +1,302 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package fake
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrBodyRead is returned when reading the body of a response created with WithBodyReadError.
+var ErrBodyRead = errors.New("fake: failed to read response body")
+
+// Transport is a policy.Transporter that returns scripted responses.
+// Responses are returned in the order they were appended.  When the queue is empty,
+// the response set with SetResponse or SetError is returned.
+// It's safe for concurrent use.
+type Transport struct {
+	mu       sync.Mutex
+	static   *response
+	queue    []response
+	requests []Request
+}
+
+// NewTransport creates a Transport with no scripted responses.
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// Request is a request received by a Transport.
+type Request struct {
+	// Method is the request's HTTP method.
+	Method string
+
+	// URL is the request's URL.
+	URL *url.URL
+
+	// Header contains the request's headers.
+	Header http.Header
+
+	// Body contains the request's body.
+	Body []byte
+}
+
+// ResponseOption configures a scripted response.
+type ResponseOption interface {
+	apply(*response)
+}
+
+type responseOptionFunc func(*response)
+
+func (fn responseOptionFunc) apply(r *response) {
+	fn(r)
+}
+
+// WithStatusCode sets the response's HTTP status code.  The default value is 200.
+func WithStatusCode(code int) ResponseOption {
+	return responseOptionFunc(func(r *response) {
+		r.code = code
+	})
+}
+
+// WithHeader adds the specified header and value to the response.
+func WithHeader(key, value string) ResponseOption {
+	return responseOptionFunc(func(r *response) {
+		r.header.Add(key, value)
+	})
+}
+
+// WithBody sets the response's body.
+func WithBody(body []byte) ResponseOption {
+	return responseOptionFunc(func(r *response) {
+		r.body = body
+	})
+}
+
+// WithDelay delays the response by the specified duration.
+// If the request's context is done first, its error is returned instead.
+func WithDelay(d time.Duration) ResponseOption {
+	return responseOptionFunc(func(r *response) {
+		r.delay = d
+	})
+}
+
+// WithBodyReadError causes reading the response's body to fail with ErrBodyRead.
+func WithBodyReadError() ResponseOption {
+	return responseOptionFunc(func(r *response) {
+		r.readErr = true
+	})
+}
+
+// WithPredicate restricts the response to requests for which the predicate returns true.
+// Requests that don't match skip over the response, leaving it in the queue.
+func WithPredicate(pred func(*http.Request) bool) ResponseOption {
+	return responseOptionFunc(func(r *response) {
+		r.pred = pred
+	})
+}
+
+type response struct {
+	code    int
+	header  http.Header
+	body    []byte
+	delay   time.Duration
+	readErr bool
+	pred    func(*http.Request) bool
+	err     error
+}
+
+func newResponse(opts []ResponseOption) response {
+	r := response{code: http.StatusOK, header: http.Header{}}
+	for _, o := range opts {
+		o.apply(&r)
+	}
+	return r
+}
+
+// AppendResponse appends a response to the queue.
+func (t *Transport) AppendResponse(opts ...ResponseOption) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queue = append(t.queue, newResponse(opts))
+}
+
+// RepeatResponse appends the response to the queue n times.
+func (t *Transport) RepeatResponse(n int, opts ...ResponseOption) {
+	for i := 0; i < n; i++ {
+		t.AppendResponse(opts...)
+	}
+}
+
+// AppendError appends a response to the queue that fails with the specified error instead of returning a response.
+// The WithDelay and WithPredicate options apply to the error.
+func (t *Transport) AppendError(err error, opts ...ResponseOption) {
+	r := newResponse(opts)
+	r.err = err
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queue = append(t.queue, r)
+}
+
+// SetResponse sets the response returned when the queue contains no matching response.
+func (t *Transport) SetResponse(opts ...ResponseOption) {
+	r := newResponse(opts)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.static = &r
+}
+
+// SetError sets the error returned when the queue contains no matching response.
+func (t *Transport) SetError(err error) {
+	r := newResponse(nil)
+	r.err = err
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.static = &r
+}
+
+// Do implements the policy.Transporter interface for type Transport.
+// It returns an error when there's no response for the request.
+func (t *Transport) Do(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	captured := Request{Method: req.Method, URL: &u, Header: req.Header.Clone()}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		captured.Body = body
+	}
+	r, ok := t.next(req, captured)
+	if !ok {
+		return nil, fmt.Errorf("fake: no response for %s %s", req.Method, req.URL)
+	}
+	if r.delay > 0 {
+		select {
+		case <-time.After(r.delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.code, http.StatusText(r.code)),
+		StatusCode:    r.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+	var body io.Reader = bytes.NewReader(r.body)
+	if r.readErr {
+		body = &readFailer{}
+		resp.ContentLength = -1
+	}
+	resp.Body = ioutil.NopCloser(body)
+	return resp, nil
+}
+
+// next records the request and dequeues its response.
+func (t *Transport) next(req *http.Request, captured Request) (response, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests = append(t.requests, captured)
+	for i, r := range t.queue {
+		if r.pred == nil || r.pred(req) {
+			t.queue = append(t.queue[:i], t.queue[i+1:]...)
+			return r, true
+		}
+	}
+	if t.static != nil {
+		return *t.static, true
+	}
+	return response{}, false
+}
+
+// Requests returns the requests received, in the order they were received.
+func (t *Transport) Requests() []Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Request(nil), t.requests...)
+}
+
+// Pending returns the number of queued responses that haven't been returned.
+func (t *Transport) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.queue)
+}
+
+// TestingT is the subset of testing.TB used by the assertion helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertRequestCount reports an error if the number of requests received isn't n.
+func (t *Transport) AssertRequestCount(tt TestingT, n int) bool {
+	tt.Helper()
+	if c := len(t.Requests()); c != n {
+		tt.Errorf("fake: received %d requests, expected %d", c, n)
+		return false
+	}
+	return true
+}
+
+// AssertRequest reports an error if the ith request received, starting at zero, doesn't have the
+// specified method and URL.  The URL's query string is compared when rawURL contains one.
+func (t *Transport) AssertRequest(tt TestingT, i int, method, rawURL string) bool {
+	tt.Helper()
+	reqs := t.Requests()
+	if i < 0 || i >= len(reqs) {
+		tt.Errorf("fake: request %d wasn't received, received %d requests", i, len(reqs))
+		return false
+	}
+	got := *reqs[i].URL
+	want, err := url.Parse(rawURL)
+	if err != nil {
+		tt.Errorf("fake: invalid URL %s: %v", rawURL, err)
+		return false
+	}
+	if want.RawQuery == "" {
+		got.RawQuery = ""
+	}
+	if reqs[i].Method != method || got.String() != want.String() {
+		tt.Errorf("fake: request %d is %s %s, expected %s %s", i, reqs[i].Method, got.String(), method, want.String())
+		return false
+	}
+	return true
+}
+
+// AssertDone reports an error if any queued responses haven't been returned.
+func (t *Transport) AssertDone(tt TestingT) bool {
+	tt.Helper()
+	if n := t.Pending(); n > 0 {
+		tt.Errorf("fake: %d scripted responses weren't returned", n)
+		return false
+	}
+	return true
+}
+
+// readFailer is a response body that fails all reads.
+type readFailer struct{}
+
+func (*readFailer) Read([]byte) (int, error) {
+	return 0, ErrBodyRead
+}
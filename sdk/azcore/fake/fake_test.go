@@ -0,0 +1,158 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingT records the errors reported by the assertion helpers.
+type recordingT struct {
+	errs []string
+}
+
+func (*recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func newRequest(t *testing.T, ctx context.Context, method, url string, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestTransportSequence(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendResponse(WithStatusCode(http.StatusCreated), WithHeader("x-ms-request-id", "one"), WithBody([]byte("first")))
+	tr.RepeatResponse(2, WithStatusCode(http.StatusAccepted))
+	tr.SetResponse(WithStatusCode(http.StatusNoContent))
+	expected := []int{http.StatusCreated, http.StatusAccepted, http.StatusAccepted, http.StatusNoContent, http.StatusNoContent}
+	for i, code := range expected {
+		resp, err := tr.Do(newRequest(t, context.Background(), http.MethodPut, "https://contoso.com/widgets?api-version=1", fmt.Sprintf("body%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != code {
+			t.Fatalf("unexpected status code %d for request %d", resp.StatusCode, i)
+		}
+		if i == 0 {
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "first" || resp.Header.Get("x-ms-request-id") != "one" || resp.Status != "201 Created" {
+				t.Fatalf("unexpected response %s %v %s", resp.Status, resp.Header, string(body))
+			}
+		}
+	}
+	if !tr.AssertRequestCount(t, 5) || !tr.AssertRequest(t, 2, http.MethodPut, "https://contoso.com/widgets") || !tr.AssertDone(t) {
+		t.FailNow()
+	}
+	if b := string(tr.Requests()[3].Body); b != "body3" {
+		t.Fatalf("unexpected request body %s", b)
+	}
+}
+
+func TestTransportNoResponse(t *testing.T) {
+	tr := NewTransport()
+	if _, err := tr.Do(newRequest(t, context.Background(), http.MethodGet, "https://contoso.com", "")); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+}
+
+func TestTransportPredicate(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendResponse(WithPredicate(func(req *http.Request) bool { return req.Method == http.MethodDelete }), WithStatusCode(http.StatusNoContent))
+	tr.AppendResponse(WithStatusCode(http.StatusOK))
+	resp, err := tr.Do(newRequest(t, context.Background(), http.MethodGet, "https://contoso.com", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	resp, err = tr.Do(newRequest(t, context.Background(), http.MethodDelete, "https://contoso.com", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	tr := NewTransport()
+	boom := errors.New("boom")
+	tr.AppendError(boom)
+	tr.AppendResponse(WithBodyReadError())
+	tr.SetError(context.DeadlineExceeded)
+	if _, err := tr.Do(newRequest(t, context.Background(), http.MethodGet, "https://contoso.com", "")); !errors.Is(err, boom) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	resp, err := tr.Do(newRequest(t, context.Background(), http.MethodGet, "https://contoso.com", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(resp.Body); !errors.Is(err, ErrBodyRead) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := tr.Do(newRequest(t, context.Background(), http.MethodGet, "https://contoso.com", "")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestTransportDelay(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendResponse(WithDelay(20 * time.Millisecond))
+	tr.AppendResponse(WithDelay(time.Hour))
+	start := time.Now()
+	if _, err := tr.Do(newRequest(t, context.Background(), http.MethodGet, "https://contoso.com", "")); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("response wasn't delayed: %v", d)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tr.Do(newRequest(t, ctx, http.MethodGet, "https://contoso.com", "")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestTransportAssertions(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendResponse()
+	tr.AppendResponse()
+	if _, err := tr.Do(newRequest(t, context.Background(), http.MethodGet, "https://contoso.com/a?b=c", "")); err != nil {
+		t.Fatal(err)
+	}
+	rt := &recordingT{}
+	if tr.AssertRequestCount(rt, 2) || tr.AssertDone(rt) || tr.AssertRequest(rt, 1, http.MethodGet, "https://contoso.com/a") {
+		t.Fatal("unexpected assertion success")
+	}
+	if tr.AssertRequest(rt, 0, http.MethodGet, "https://contoso.com/a?b=d") {
+		t.Fatal("unexpected assertion success")
+	}
+	if len(rt.errs) != 4 {
+		t.Fatalf("unexpected errors %v", rt.errs)
+	}
+	if !tr.AssertRequest(t, 0, http.MethodGet, "https://contoso.com/a?b=c") {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,145 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// DefaultPollingURL is the status monitor URL used when LROOptions.PollingURL isn't set.
+const DefaultPollingURL = "https://management.azure.com/providers/Microsoft.Fake/operations/lro"
+
+// LRO terminal states.
+const (
+	LROSucceeded = "Succeeded"
+	LROFailed    = "Failed"
+	LROCanceled  = "Canceled"
+)
+
+// LROOptions configures a scripted ARM long-running operation.
+type LROOptions struct {
+	// PollingURL is the URL of the operation's status monitor.
+	// The default value is DefaultPollingURL.
+	PollingURL string
+
+	// InProgressPolls is the number of polls that report the operation as in progress before it completes.
+	InProgressPolls int
+
+	// FinalState is the operation's terminal state, one of LROSucceeded, LROFailed or LROCanceled.
+	// The default value is LROSucceeded.
+	FinalState string
+
+	// Result is the body of the response containing the resource once the operation has succeeded.
+	Result []byte
+}
+
+func (o *LROOptions) defaults() LROOptions {
+	lro := LROOptions{}
+	if o != nil {
+		lro = *o
+	}
+	if lro.PollingURL == "" {
+		lro.PollingURL = DefaultPollingURL
+	}
+	if lro.FinalState == "" {
+		lro.FinalState = LROSucceeded
+	}
+	return lro
+}
+
+// AppendAzureAsyncOperationLRO scripts an operation using the Azure-AsyncOperation pattern.
+// The initial response is a 201 with the Azure-AsyncOperation header set to the polling URL, which
+// returns the operation's status.  When Result is set and the operation succeeds, a final GET on a
+// URL other than the polling URL returns Result, as done for PUT and PATCH operations.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+func (t *Transport) AppendAzureAsyncOperationLRO(o *LROOptions) {
+	lro := o.defaults()
+	t.AppendResponse(WithStatusCode(http.StatusCreated), WithHeader("Azure-AsyncOperation", lro.PollingURL))
+	polling := WithPredicate(isPoll(lro.PollingURL))
+	for i := 0; i < lro.InProgressPolls; i++ {
+		t.AppendResponse(polling, withJSON(map[string]interface{}{"status": "InProgress"}))
+	}
+	status := map[string]interface{}{"status": lro.FinalState}
+	if lro.FinalState == LROFailed {
+		status["error"] = map[string]string{"code": "OperationFailed", "message": "the operation failed"}
+	}
+	t.AppendResponse(polling, withJSON(status))
+	if lro.FinalState == LROSucceeded && lro.Result != nil {
+		t.AppendResponse(WithPredicate(isFinalGet(lro.PollingURL)), WithBody(lro.Result), WithHeader("Content-Type", "application/json"))
+	}
+}
+
+// AppendLocationLRO scripts an operation using the Location pattern.
+// The initial response is a 202 with the Location header set to the polling URL, which returns
+// 202 while the operation is in progress.  On success, the polling URL returns a 200 with Result,
+// or a 204 when Result isn't set.  Failed and canceled operations return a 200 with the terminal
+// provisioning state.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+func (t *Transport) AppendLocationLRO(o *LROOptions) {
+	lro := o.defaults()
+	t.AppendResponse(WithStatusCode(http.StatusAccepted), WithHeader("Location", lro.PollingURL))
+	polling := WithPredicate(isPoll(lro.PollingURL))
+	t.RepeatResponse(lro.InProgressPolls, polling, WithStatusCode(http.StatusAccepted))
+	switch {
+	case lro.FinalState != LROSucceeded:
+		t.AppendResponse(polling, withProvisioningState(lro.FinalState))
+	case lro.Result != nil:
+		t.AppendResponse(polling, WithBody(lro.Result), WithHeader("Content-Type", "application/json"))
+	default:
+		t.AppendResponse(polling, WithStatusCode(http.StatusNoContent))
+	}
+}
+
+// AppendProvisioningStateLRO scripts a PUT or PATCH operation that reports its progress through the
+// resource's provisioningState.  The initial response is a 201 with the provisioning state "Creating"
+// and GET requests on the resource return "Updating" while the operation is in progress.  On success,
+// the final GET returns Result, or a provisioning state of "Succeeded" when Result isn't set.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+func (t *Transport) AppendProvisioningStateLRO(o *LROOptions) {
+	lro := o.defaults()
+	t.AppendResponse(WithStatusCode(http.StatusCreated), withProvisioningState("Creating"))
+	polling := WithPredicate(func(req *http.Request) bool { return req.Method == http.MethodGet })
+	t.RepeatResponse(lro.InProgressPolls, polling, withProvisioningState("Updating"))
+	if lro.FinalState == LROSucceeded && lro.Result != nil {
+		t.AppendResponse(polling, WithBody(lro.Result), WithHeader("Content-Type", "application/json"))
+		return
+	}
+	t.AppendResponse(polling, withProvisioningState(lro.FinalState))
+}
+
+// isPoll returns a predicate matching GET requests to the polling URL.
+func isPoll(pollingURL string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		return req.Method == http.MethodGet && req.URL.String() == pollingURL
+	}
+}
+
+// isFinalGet returns a predicate matching GET requests to any URL other than the polling URL.
+func isFinalGet(pollingURL string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		return req.Method == http.MethodGet && req.URL.String() != pollingURL
+	}
+}
+
+func withProvisioningState(state string) ResponseOption {
+	return withJSON(map[string]interface{}{
+		"properties": map[string]string{"provisioningState": state},
+	})
+}
+
+// withJSON sets the response's body to the JSON encoding of v.
+func withJSON(v interface{}) ResponseOption {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return responseOptionFunc(func(r *response) {
+		r.body = body
+		r.header.Set("Content-Type", "application/json")
+	})
+}
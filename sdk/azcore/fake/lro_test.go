@@ -0,0 +1,117 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package fake
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	armruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+const resourceURL = "https://management.azure.com/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Fake/widgets/w"
+
+type widget struct {
+	Name string `json:"name"`
+}
+
+func pollLRO(t *testing.T, tr *Transport, method string) (widget, error) {
+	t.Helper()
+	pl := runtime.NewPipeline(tr)
+	req, err := runtime.NewRequest(context.Background(), method, resourceURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poller, err := armruntime.NewPoller("fake.widget", "", resp, pl, func(resp *http.Response) error {
+		return runtime.NewResponseError(nil, resp)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w widget
+	_, err = poller.PollUntilDone(context.Background(), time.Millisecond, &w)
+	return w, err
+}
+
+func TestAzureAsyncOperationLRO(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendAzureAsyncOperationLRO(&LROOptions{InProgressPolls: 2, Result: []byte(`{"name":"w"}`)})
+	w, err := pollLRO(t, tr, http.MethodPut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "w" {
+		t.Fatalf("unexpected result %v", w)
+	}
+	tr.AssertRequestCount(t, 5)
+	tr.AssertRequest(t, 1, http.MethodGet, DefaultPollingURL)
+	tr.AssertRequest(t, 4, http.MethodGet, resourceURL)
+	tr.AssertDone(t)
+}
+
+func TestAzureAsyncOperationLROFailed(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendAzureAsyncOperationLRO(&LROOptions{FinalState: LROFailed})
+	if _, err := pollLRO(t, tr, http.MethodDelete); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+	tr.AssertDone(t)
+}
+
+func TestLocationLRO(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendLocationLRO(&LROOptions{InProgressPolls: 1, Result: []byte(`{"name":"w"}`)})
+	w, err := pollLRO(t, tr, http.MethodPost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "w" {
+		t.Fatalf("unexpected result %v", w)
+	}
+	tr.AssertRequestCount(t, 3)
+	tr.AssertDone(t)
+}
+
+func TestLocationLROCanceled(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendLocationLRO(&LROOptions{FinalState: LROCanceled})
+	if _, err := pollLRO(t, tr, http.MethodDelete); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+	tr.AssertDone(t)
+}
+
+func TestProvisioningStateLRO(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendProvisioningStateLRO(&LROOptions{InProgressPolls: 3, Result: []byte(`{"name":"w","properties":{"provisioningState":"Succeeded"}}`)})
+	w, err := pollLRO(t, tr, http.MethodPut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "w" {
+		t.Fatalf("unexpected result %v", w)
+	}
+	tr.AssertRequestCount(t, 5)
+	tr.AssertRequest(t, 4, http.MethodGet, resourceURL)
+	tr.AssertDone(t)
+}
+
+func TestProvisioningStateLROFailed(t *testing.T) {
+	tr := NewTransport()
+	tr.AppendProvisioningStateLRO(&LROOptions{FinalState: LROFailed})
+	if _, err := pollLRO(t, tr, http.MethodPatch); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+	tr.AssertDone(t)
+}
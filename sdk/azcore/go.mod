@@ -6,4 +6,11 @@ require (
 	golang.org/x/net v0.0.0-20210610132358-84b48f89b13b
 )
 
-go 1.16
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.3.6 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+go 1.18
@@ -64,7 +64,7 @@ func NewRequest(ctx context.Context, httpMethod string, endpoint string) (*Reque
 	if !(req.URL.Scheme == "http" || req.URL.Scheme == "https") {
 		return nil, fmt.Errorf("unsupported protocol scheme %s", req.URL.Scheme)
 	}
-	return &Request{req: req}, nil
+	return &Request{req: req, values: opValues{}}, nil
 }
 
 // Body returns the original body specified when the Request was created.
@@ -167,3 +167,23 @@ func (req *Request) Clone(ctx context.Context) *Request {
 	r2.req = req.req.Clone(ctx)
 	return &r2
 }
+
+// CloneWithOperationValues returns a clone of req, like Clone, with its own copy of req's operation values,
+// so that values set on the clone, or by the policies it's sent through, don't change req's.
+// Use CopyOperationValues to return the clone's values to req.
+func CloneWithOperationValues(ctx context.Context, req *Request) *Request {
+	r2 := req.Clone(ctx)
+	r2.values = opValues{}
+	CopyOperationValues(r2, req)
+	return r2
+}
+
+// CopyOperationValues sets each of src's operation values on dst.
+func CopyOperationValues(dst, src *Request) {
+	if dst.values == nil {
+		dst.values = opValues{}
+	}
+	for k, v := range src.values {
+		dst.values[k] = v
+	}
+}
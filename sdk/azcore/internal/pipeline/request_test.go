@@ -114,6 +114,31 @@ func TestRequestClone(t *testing.T) {
 	}
 }
 
+func TestRequestCloneWithOperationValues(t *testing.T) {
+	req, err := NewRequest(context.Background(), http.MethodGet, testURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clone shares the operation values so policies later in the chain can return values to earlier ones
+	req.Clone(context.Background()).SkipBodyDownload()
+	var skip shared.BodyDownloadPolicyOpValues
+	if req.OperationValue(&skip); !skip.Skip {
+		t.Fatal("Clone didn't share the operation values")
+	}
+	clone := CloneWithOperationValues(context.Background(), req)
+	if clone.OperationValue(&skip); !skip.Skip {
+		t.Fatal("missing operation value")
+	}
+	clone.SetOperationValue(shared.BodyDownloadPolicyOpValues{Skip: false})
+	if req.OperationValue(&skip); !skip.Skip {
+		t.Fatal("clone changed the original's operation value")
+	}
+	CopyOperationValues(req, clone)
+	if req.OperationValue(&skip); skip.Skip {
+		t.Fatal("operation value wasn't copied")
+	}
+}
+
 func TestNewRequestFail(t *testing.T) {
 	req, err := NewRequest(context.Background(), http.MethodOptions, "://test.contoso.com/")
 	if err == nil {
@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pipeline"
@@ -95,7 +96,7 @@ func (l *Poller) Poll(ctx context.Context) (*http.Response, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	if !StatusCodeValid(resp) {
+	if !l.statusCodeValid(resp) {
 		// the LRO failed.  unmarshall the error and update state
 		l.err = l.eu(resp)
 		l.resp = nil
@@ -114,6 +115,14 @@ func (l *Poller) Poll(ctx context.Context) (*http.Response, error) {
 	return l.resp, nil
 }
 
+// statusCodeValid returns true if the polling response's status code is valid for the LRO.
+func (l *Poller) statusCodeValid(resp *http.Response) bool {
+	if v, ok := l.lro.(StatusCodeValidator); ok {
+		return v.StatusCodeValid(resp)
+	}
+	return StatusCodeValid(resp)
+}
+
 // ResumeToken returns a token string that can be used to resume a poller that has not yet reached a terminal state.
 func (l *Poller) ResumeToken() (string, error) {
 	if l.Done() {
@@ -172,42 +181,162 @@ func (l *Poller) FinalResponse(ctx context.Context, respType interface{}) (*http
 // freq - the time to wait between polling intervals if the endpoint doesn't send a Retry-After header.
 //        A good starting value is 30 seconds.  Note that some resources might benefit from a different value.
 func (l *Poller) PollUntilDone(ctx context.Context, freq time.Duration, respType interface{}) (*http.Response, error) {
+	if freq == 0 {
+		// unlike PollUntilDoneOptions.Frequency, a zero freq means no delay between polls
+		freq = -1
+	}
+	return l.PollUntilDoneWithOptions(ctx, &PollUntilDoneOptions{Frequency: freq}, respType)
+}
+
+// minBackoffFrequency is the first backoff interval when PollUntilDoneOptions.Frequency is less than zero
+const minBackoffFrequency = 100 * time.Millisecond
+
+// PollUntilDoneOptions contains the optional values for Poller.PollUntilDoneWithOptions.
+type PollUntilDoneOptions struct {
+	// Frequency is the time to wait between polling intervals if the endpoint doesn't send a Retry-After header.
+	// The default value is 30 seconds.  A value less than zero means no delay between polls.
+	// Note that some resources might benefit from a different value.
+	Frequency time.Duration
+
+	// MaxFrequency enables exponential backoff when it's greater than Frequency.  The interval
+	// starts at Frequency and doubles after each poll, up to MaxFrequency.  When Frequency is less
+	// than zero, the first poll is repeated without delay and the interval then starts at 100 milliseconds.
+	MaxFrequency time.Duration
+
+	// Timeout is the maximum time to poll.  When it elapses before the LRO reaches a terminal
+	// state, polling stops and a *TimeoutError containing a resume token is returned.
+	// The default value is zero, which polls until the LRO completes or the context ends.
+	Timeout time.Duration
+
+	// Progress is called after each successful poll.
+	Progress func(Progress)
+}
+
+// Progress describes the progress of a long-running operation.
+type Progress struct {
+	// Status is the LRO's status, e.g. "InProgress" or "Succeeded".
+	Status string
+
+	// PercentComplete is the value of the percentComplete field in the polling response's body.
+	// It's nil when the body doesn't contain the field.
+	PercentComplete *float64
+
+	// Elapsed is the time since polling began.
+	Elapsed time.Duration
+}
+
+// TimeoutError is returned by Poller.PollUntilDoneWithOptions when PollUntilDoneOptions.Timeout
+// elapses before the LRO reaches a terminal state.  It wraps context.DeadlineExceeded.
+type TimeoutError struct {
+	// ResumeToken can be passed to NewPollerFromResumeToken to resume polling the LRO.
+	ResumeToken string
+
+	// Elapsed is the time spent polling.
+	Elapsed time.Duration
+}
+
+// Error implements the error interface for type TimeoutError.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("the LRO didn't complete within %s; use the resume token to resume polling", e.Elapsed.Round(time.Millisecond))
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (*TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// PollUntilDoneWithOptions is like PollUntilDone, with optional progress reporting, exponential
+// backoff and an overall timeout.  Pass nil to accept the default values.
+func (l *Poller) PollUntilDoneWithOptions(ctx context.Context, options *PollUntilDoneOptions, respType interface{}) (*http.Response, error) {
+	o := PollUntilDoneOptions{}
+	if options != nil {
+		o = *options
+	}
+	if o.Frequency == 0 {
+		o.Frequency = 30 * time.Second
+	} else if o.Frequency < 0 {
+		o.Frequency = 0
+	}
 	start := time.Now()
 	logPollUntilDoneExit := func(v interface{}) {
 		log.Writef(log.LongRunningOperation, "END PollUntilDone() for %T: %v, total time: %s", l.lro, v, time.Since(start))
 	}
 	log.Writef(log.LongRunningOperation, "BEGIN PollUntilDone() for %T", l.lro)
+	pollCtx := ctx
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	// fail returns err, or a *TimeoutError when the timeout caused it
+	fail := func(err error) (*http.Response, error) {
+		if pollCtx.Err() != nil && ctx.Err() == nil && !l.Done() {
+			if tk, tkErr := l.ResumeToken(); tkErr == nil {
+				err = &TimeoutError{ResumeToken: tk, Elapsed: time.Since(start)}
+			}
+		}
+		logPollUntilDoneExit(err)
+		return nil, err
+	}
 	if l.resp != nil {
 		// initial check for a retry-after header existing on the initial response
 		if retryAfter := shared.RetryAfter(l.resp); retryAfter > 0 {
 			log.Writef(log.LongRunningOperation, "initial Retry-After delay for %s", retryAfter.String())
-			if err := shared.Delay(ctx, retryAfter); err != nil {
-				logPollUntilDoneExit(err)
-				return nil, err
+			if err := shared.Delay(pollCtx, retryAfter); err != nil {
+				return fail(err)
 			}
 		}
 	}
+	interval := o.Frequency
 	// begin polling the endpoint until a terminal state is reached
 	for {
-		resp, err := l.Poll(ctx)
+		resp, err := l.Poll(pollCtx)
 		if err != nil {
-			logPollUntilDoneExit(err)
-			return nil, err
+			return fail(err)
+		}
+		if o.Progress != nil {
+			o.Progress(Progress{Status: l.lro.Status(), PercentComplete: percentComplete(resp), Elapsed: time.Since(start)})
 		}
 		if l.Done() {
 			logPollUntilDoneExit(l.lro.Status())
 			return l.FinalResponse(ctx, respType)
 		}
-		d := freq
+		d := interval
 		if retryAfter := shared.RetryAfter(resp); retryAfter > 0 {
 			log.Writef(log.LongRunningOperation, "Retry-After delay for %s", retryAfter.String())
 			d = retryAfter
 		} else {
 			log.Writef(log.LongRunningOperation, "delay for %s", d.String())
 		}
-		if err = shared.Delay(ctx, d); err != nil {
-			logPollUntilDoneExit(err)
-			return nil, err
+		if o.MaxFrequency > interval {
+			// doubling a zero interval would never back off
+			if interval = 2 * interval; interval < minBackoffFrequency {
+				interval = minBackoffFrequency
+			}
+			if interval > o.MaxFrequency {
+				interval = o.MaxFrequency
+			}
+		}
+		if err = shared.Delay(pollCtx, d); err != nil {
+			return fail(err)
+		}
+	}
+}
+
+// percentComplete returns the value of the percentComplete field in the response's body, if any.
+func percentComplete(resp *http.Response) *float64 {
+	jsonBody, err := shared.GetJSON(resp)
+	if err != nil {
+		return nil
+	}
+	switch v := jsonBody["percentComplete"].(type) {
+	case float64:
+		return &v
+	case string:
+		// some services return the value as a string
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return &f
 		}
 	}
+	return nil
 }
@@ -147,6 +147,42 @@ func TestNewPoller(t *testing.T) {
 	}
 }
 
+func TestPollUntilDoneZeroFrequency(t *testing.T) {
+	srv, close := mock.NewServer()
+	srv.RepeatResponse(3, mock.WithStatusCode(http.StatusAccepted))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusNoContent)) // terminal
+	defer close()
+	p := NewPoller(&fakePoller{Ep: srv.URL()}, &http.Response{StatusCode: http.StatusAccepted, Header: http.Header{}}, pipeline.NewPipeline(srv), nil)
+	// a zero frequency polls without delay
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := p.PollUntilDone(ctx, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestPollUntilDoneWithOptionsNegativeFrequencyBackoff(t *testing.T) {
+	srv, close := mock.NewServer()
+	srv.SetResponse(mock.WithStatusCode(http.StatusAccepted))
+	defer close()
+	p := NewPoller(&fakePoller{Ep: srv.URL()}, &http.Response{StatusCode: http.StatusAccepted, Header: http.Header{}}, pipeline.NewPipeline(srv), nil)
+	// the backoff starts at a non-zero interval, so polling doesn't spin
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err := p.PollUntilDoneWithOptions(ctx, &PollUntilDoneOptions{Frequency: -1, MaxFrequency: time.Second}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// polls after 0ms, 100ms and 300ms
+	if r := srv.Requests(); r > 4 {
+		t.Fatalf("unexpected poll count %d", r)
+	}
+}
+
 func TestNewPollerWithFinalGET(t *testing.T) {
 	srv, close := mock.NewServer()
 	srv.AppendResponse(mock.WithStatusCode(http.StatusAccepted), mock.WithHeader(shared.HeaderRetryAfter, "1"))
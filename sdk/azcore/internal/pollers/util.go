@@ -31,6 +31,12 @@ type Operation interface {
 	Status() string
 }
 
+// StatusCodeValidator is implemented by operations that accept polling responses
+// with status codes other than the ones accepted by StatusCodeValid.
+type StatusCodeValidator interface {
+	StatusCodeValid(resp *http.Response) bool
+}
+
 // IsTerminalState returns true if the LRO's state is terminal.
 func IsTerminalState(s string) bool {
 	return strings.EqualFold(s, StatusSucceeded) || strings.EqualFold(s, StatusFailed) || strings.EqualFold(s, StatusCanceled)
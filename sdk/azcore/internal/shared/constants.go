@@ -12,13 +12,21 @@ const (
 )
 
 const (
-	HeaderAzureAsync        = "Azure-AsyncOperation"
-	HeaderContentLength     = "Content-Length"
-	HeaderContentType       = "Content-Type"
-	HeaderLocation          = "Location"
-	HeaderOperationLocation = "Operation-Location"
-	HeaderRetryAfter        = "Retry-After"
-	HeaderUserAgent         = "User-Agent"
+	HeaderAzureAsync         = "Azure-AsyncOperation"
+	HeaderAcceptEncoding     = "Accept-Encoding"
+	HeaderContentEncoding    = "Content-Encoding"
+	HeaderContentLength      = "Content-Length"
+	HeaderContentType        = "Content-Type"
+	HeaderLocation           = "Location"
+	HeaderOperationLocation  = "Operation-Location"
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRetryAfterMS       = "Retry-After-Ms"
+	HeaderXMSRetryAfterMS    = "X-Ms-Retry-After-Ms"
+	HeaderUserAgent          = "User-Agent"
+	HeaderTraceParent        = "traceparent"
+	HeaderXMSClientRequestID = "x-ms-client-request-id"
+	HeaderXMSRequestID       = "x-ms-request-id"
+	HeaderXMSErrorCode       = "x-ms-error-code"
 )
 
 const (
@@ -10,11 +10,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -24,6 +27,9 @@ type CtxWithHTTPHeaderKey struct{}
 // CtxWithRetryOptionsKey is used as a context key for adding/retrieving RetryOptions.
 type CtxWithRetryOptionsKey struct{}
 
+// CtxWithOperationNameKey is used as a context key for adding/retrieving the operation name.
+type CtxWithOperationNameKey struct{}
+
 type nopCloser struct {
 	io.ReadSeeker
 }
@@ -42,18 +48,70 @@ type BodyDownloadPolicyOpValues struct {
 	Skip bool
 }
 
+// NewResponseError creates a *ResponseError for the non-success response, parsing the service's
+// error code and message from the response headers or body.
 func NewResponseError(inner error, resp *http.Response) error {
-	return &ResponseError{inner: inner, resp: resp}
+	respErr := &ResponseError{inner: inner, resp: resp}
+	if resp == nil {
+		return respErr
+	}
+	respErr.StatusCode = resp.StatusCode
+	respErr.RequestID = resp.Header.Get(HeaderXMSRequestID)
+	respErr.ErrorCode, respErr.Message = parseErrorBody(resp)
+	if code := resp.Header.Get(HeaderXMSErrorCode); code != "" {
+		respErr.ErrorCode = code
+	}
+	return respErr
 }
 
+// ResponseError is returned when a request is made to a service and
+// the service returns a non-success HTTP status code.
 type ResponseError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// ErrorCode is the error code returned by the service, from the x-ms-error-code
+	// header or the JSON or XML response body.  It's empty when the service didn't return one.
+	ErrorCode string
+
+	// Message is the error message returned by the service in the response body, if any.
+	Message string
+
+	// RequestID is the value of the x-ms-request-id response header, if any.
+	RequestID string
+
 	inner error
 	resp  *http.Response
 }
 
 // Error implements the error interface for type ResponseError.
 func (e *ResponseError) Error() string {
-	return e.inner.Error()
+	if e.inner != nil {
+		return e.inner.Error()
+	}
+	msg := &bytes.Buffer{}
+	if e.resp != nil && e.resp.Request != nil {
+		// omit the query string and user info as they can contain secrets
+		u := *e.resp.Request.URL
+		u.RawQuery = ""
+		u.User = nil
+		fmt.Fprintf(msg, "%s %s\n", e.resp.Request.Method, u.String())
+	}
+	if e.resp != nil {
+		fmt.Fprintf(msg, "RESPONSE %s\n", e.resp.Status)
+	} else {
+		fmt.Fprintf(msg, "RESPONSE %d\n", e.StatusCode)
+	}
+	if e.ErrorCode != "" {
+		fmt.Fprintf(msg, "ERROR CODE: %s\n", e.ErrorCode)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(msg, "MESSAGE: %s\n", e.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(msg, "REQUEST ID: %s\n", e.RequestID)
+	}
+	return strings.TrimSuffix(msg.String(), "\n")
 }
 
 // Unwrap returns the inner error.
@@ -71,6 +129,85 @@ func (e *ResponseError) NonRetriable() {
 	// marker method
 }
 
+// parseErrorBody returns the error code and message from a JSON or XML error response body.
+// The body is left intact so it can be read again.
+func parseErrorBody(resp *http.Response) (code, message string) {
+	body, err := peekBody(resp)
+	if err != nil || len(body) == 0 {
+		return "", ""
+	}
+	body = bytes.TrimSpace(body)
+	if body[0] == '<' {
+		return parseXMLError(body)
+	}
+	return parseJSONError(body)
+}
+
+// peekBody returns the response body, replacing it with an unread copy.
+func peekBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil, nil
+	}
+	// the body download policy's reader provides direct access to the downloaded body
+	if buf, ok := resp.Body.(interface{ Bytes() []byte }); ok {
+		return buf.Bytes(), nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// parseJSONError handles the error formats {"error":{"code":"","message":""}}, {"code":"","message":""},
+// {"odata.error":{"code":"","message":{"value":""}}} and {"error":"","error_description":""}.
+func parseJSONError(body []byte) (code, message string) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return "", ""
+	}
+	obj := raw
+	for _, key := range []string{"error", "odata.error"} {
+		inner, ok := raw[key]
+		if !ok {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(inner, &s); err == nil {
+			// OAuth2 error response
+			var desc string
+			_ = json.Unmarshal(raw["error_description"], &desc)
+			return s, desc
+		}
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(inner, &nested); err != nil {
+			return "", ""
+		}
+		obj = nested
+		break
+	}
+	_ = json.Unmarshal(obj["code"], &code)
+	if err := json.Unmarshal(obj["message"], &message); err != nil {
+		var localized struct {
+			Value string `json:"value"`
+		}
+		_ = json.Unmarshal(obj["message"], &localized)
+		message = localized.Value
+	}
+	return code, message
+}
+
+// parseXMLError handles the error format <Error><Code></Code><Message></Message></Error>.
+func parseXMLError(body []byte) (code, message string) {
+	var xmlErr struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(body, &xmlErr); err != nil {
+		return "", ""
+	}
+	return xmlErr.Code, strings.TrimSpace(xmlErr.Message)
+}
+
 // Delay waits for the duration to elapse or the context to be cancelled.
 func Delay(ctx context.Context, delay time.Duration) error {
 	select {
@@ -105,11 +242,18 @@ func GetJSON(resp *http.Response) (map[string]interface{}, error) {
 	return jsonBody, nil
 }
 
-// RetryAfter returns non-zero if the response contains a Retry-After header value.
+// RetryAfter returns non-zero if the response contains one of the headers with a "retry after" value.
+// Headers are checked in the following order: retry-after-ms, x-ms-retry-after-ms, retry-after
 func RetryAfter(resp *http.Response) time.Duration {
 	if resp == nil {
 		return 0
 	}
+	// the millisecond headers are checked first as they're more precise
+	for _, h := range []string{HeaderRetryAfterMS, HeaderXMSRetryAfterMS} {
+		if retryAfter, _ := strconv.Atoi(resp.Header.Get(h)); retryAfter > 0 {
+			return time.Duration(retryAfter) * time.Millisecond
+		}
+	}
 	ra := resp.Header.Get(HeaderRetryAfter)
 	if ra == "" {
 		return 0
@@ -120,6 +264,9 @@ func RetryAfter(resp *http.Response) time.Duration {
 		return time.Duration(retryAfter) * time.Second
 	} else if t, err := time.Parse(time.RFC1123, ra); err == nil {
 		return time.Until(t)
+	} else if t, err := http.ParseTime(ra); err == nil {
+		// RFC 850 and ANSI C formats
+		return time.Until(t)
 	}
 	return 0
 }
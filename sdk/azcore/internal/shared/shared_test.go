@@ -106,6 +106,24 @@ func TestRetryAfter(t *testing.T) {
 	if s := d / time.Second; s < 598 || s > 602 {
 		t.Fatalf("expected ~600 seconds, got %d", s)
 	}
+	resp.Header.Set(HeaderRetryAfter, "invalid")
+	if d = RetryAfter(resp); d != 0 {
+		t.Fatalf("unexpected retry-after value %d", d)
+	}
+	// millisecond values take precedence over retry-after
+	resp.Header.Set(HeaderRetryAfter, "300")
+	resp.Header.Set(HeaderXMSRetryAfterMS, "500")
+	if d = RetryAfter(resp); d != 500*time.Millisecond {
+		t.Fatalf("expected 500 milliseconds, got %d", d/time.Millisecond)
+	}
+	resp.Header.Set(HeaderRetryAfterMS, "250")
+	if d = RetryAfter(resp); d != 250*time.Millisecond {
+		t.Fatalf("expected 250 milliseconds, got %d", d/time.Millisecond)
+	}
+	resp.Header.Set(HeaderRetryAfterMS, "invalid")
+	if d = RetryAfter(resp); d != 500*time.Millisecond {
+		t.Fatalf("expected 500 milliseconds, got %d", d/time.Millisecond)
+	}
 }
 
 func TestHasStatusCode(t *testing.T) {
@@ -131,3 +149,63 @@ func TestEndpointToScope(t *testing.T) {
 		t.Fatalf("unexpected scope %s", s)
 	}
 }
+
+func TestNewResponseErrorParsing(t *testing.T) {
+	newResp := func(body string, header http.Header) *http.Response {
+		if header == nil {
+			header = http.Header{}
+		}
+		req, err := http.NewRequest(http.MethodGet, "https://contoso.com/widgets?sig=secret", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			Status:     "404 Not Found",
+			StatusCode: http.StatusNotFound,
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}
+	}
+	testCases := []struct {
+		label   string
+		resp    *http.Response
+		code    string
+		message string
+	}{
+		{"arm", newResp(`{"error":{"code":"ResourceNotFound","message":"not found"}}`, nil), "ResourceNotFound", "not found"},
+		{"flat", newResp(`{"code":"WidgetNotFound","message":"no widget"}`, nil), "WidgetNotFound", "no widget"},
+		{"odata", newResp(`{"odata.error":{"code":"TableNotFound","message":{"lang":"en-US","value":"no table"}}}`, nil), "TableNotFound", "no table"},
+		{"oauth", newResp(`{"error":"invalid_client","error_description":"bad credential"}`, nil), "invalid_client", "bad credential"},
+		{"xml", newResp(`<?xml version="1.0" encoding="utf-8"?><Error><Code>BlobNotFound</Code><Message>no blob</Message></Error>`, nil), "BlobNotFound", "no blob"},
+		{"header", newResp(`{"code":"Other"}`, http.Header{http.CanonicalHeaderKey(HeaderXMSErrorCode): []string{"ContainerNotFound"}}), "ContainerNotFound", ""},
+		{"garbage", newResp(`not an error`, nil), "", ""},
+		{"empty", newResp(``, nil), "", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.label, func(t *testing.T) {
+			tc.resp.Header.Set(HeaderXMSRequestID, "request-id")
+			err := NewResponseError(nil, tc.resp)
+			var respErr *ResponseError
+			if !errors.As(err, &respErr) {
+				t.Fatalf("unexpected error type %T", err)
+			}
+			if respErr.StatusCode != http.StatusNotFound || respErr.RequestID != "request-id" {
+				t.Fatalf("unexpected status %d or request ID %s", respErr.StatusCode, respErr.RequestID)
+			}
+			if respErr.ErrorCode != tc.code || respErr.Message != tc.message {
+				t.Fatalf("unexpected code %q or message %q", respErr.ErrorCode, respErr.Message)
+			}
+			if strings.Contains(err.Error(), "sig=") {
+				t.Fatalf("error message leaks query string: %s", err.Error())
+			}
+			if tc.code != "" && !strings.Contains(err.Error(), "ERROR CODE: "+tc.code) {
+				t.Fatalf("missing error code in %s", err.Error())
+			}
+			// the body must still be readable
+			if _, err := ioutil.ReadAll(tc.resp.Body); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
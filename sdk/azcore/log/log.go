@@ -29,6 +29,10 @@ const (
 	// LongRunningOperation entries contain information specific to long-running operations.
 	// This includes information like polling location, operation state and sleep intervals.
 	LongRunningOperation = log.LongRunningOperation
+
+	// Authentication entries contain information about token acquisition, such as failures to
+	// refresh a token in the background.
+	Authentication Classification = "Authentication"
 )
 
 // SetClassifications is used to control which classifications are written to
@@ -8,11 +8,17 @@ package policy
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pipeline"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/tracing"
 )
 
 // Policy represents an extensibility point for the Pipeline that can mutate the specified
@@ -29,15 +35,34 @@ type Request = pipeline.Request
 // ClientOptions contains optional settings for a client's pipeline.
 // All zero-value fields will be initialized with default values.
 type ClientOptions struct {
+	// CircuitBreaker configures the circuit breaker policy.
+	// The default value is nil which disables the circuit breaker.
+	CircuitBreaker *CircuitBreakerOptions
+
+	// Compression configures the compression policy.
+	// The default value is nil which disables compression.
+	Compression *CompressionOptions
+
 	// Logging configures the built-in logging policy.
 	Logging LogOptions
 
+	// Metrics configures the built-in metrics policy.
+	Metrics MetricsOptions
+
+	// RateLimiter limits the rate and concurrency of requests sent by the client.
+	// Create one with runtime.NewRateLimiter.  Clients sharing the same RateLimiter share the same limits.
+	// The default value is nil which disables client-side rate limiting.
+	RateLimiter RateLimiter
+
 	// Retry configures the built-in retry policy.
 	Retry RetryOptions
 
 	// Telemetry configures the built-in telemetry policy.
 	Telemetry TelemetryOptions
 
+	// Tracing configures the built-in tracing policy.
+	Tracing TracingOptions
+
 	// Transport sets the transport for HTTP requests.
 	Transport Transporter
 
@@ -50,12 +75,310 @@ type ClientOptions struct {
 	PerRetryPolicies []Policy
 }
 
+// CircuitBreakerOptions configures the circuit breaker policy's behavior.
+// Circuits are tracked independently for each host.
+type CircuitBreakerOptions struct {
+	// FailureRatio is the ratio of failed tries, within Window, that opens the circuit.
+	// The default value is 0.5.
+	FailureRatio float64
+
+	// MinimumTries is the minimum number of tries, within Window, before the circuit can open.
+	// The default value is 10.
+	MinimumTries int
+
+	// Window is the period of time over which failures are counted.
+	// The default value is 30 seconds.
+	Window time.Duration
+
+	// Cooldown is the time an open circuit waits before allowing a probe request (half-open).
+	// The default value is 30 seconds.
+	Cooldown time.Duration
+
+	// IsFailure returns true if the try should be counted as a failure.
+	// The *http.Response and error parameters are mutually exclusive.
+	// The default counts errors and HTTP status codes 500 and greater.
+	// Tries canceled by the caller are inconclusive: they aren't counted, and a canceled
+	// half-open probe leaves the circuit half-open for the next request to probe.
+	IsFailure func(*http.Response, error) bool
+
+	// OnStateChange is called when the state of a host's circuit changes.
+	// It's called synchronously so it should return quickly.
+	OnStateChange func(host string, from, to CircuitState)
+}
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitStateClosed indicates requests flow normally.
+	CircuitStateClosed CircuitState = 0
+
+	// CircuitStateOpen indicates requests fail immediately.
+	CircuitStateOpen CircuitState = 1
+
+	// CircuitStateHalfOpen indicates a single probe request is allowed to determine if the circuit can close.
+	CircuitStateHalfOpen CircuitState = 2
+)
+
+// String implements the fmt.Stringer interface for CircuitState.
+func (c CircuitState) String() string {
+	switch c {
+	case CircuitStateClosed:
+		return "Closed"
+	case CircuitStateOpen:
+		return "Open"
+	case CircuitStateHalfOpen:
+		return "HalfOpen"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(c))
+	}
+}
+
+// CompressionOptions configures the compression policy's behavior.
+type CompressionOptions struct {
+	// RequestEncoding is the Content-Encoding used to compress request bodies, "gzip" or "deflate".
+	// The default value is empty which sends request bodies uncompressed.  Only specify it for
+	// services that accept compressed requests.
+	RequestEncoding string
+
+	// MinRequestBodySize is the size, in bytes, of the smallest request body that's compressed.
+	// The default value is 1024.
+	MinRequestBodySize int64
+
+	// DisableResponseDecompression prevents the policy from requesting compressed responses.
+	DisableResponseDecompression bool
+}
+
+// FaultInjectionOptions configures the fault injection policy created by runtime.NewFaultInjectionPolicy().
+type FaultInjectionOptions struct {
+	// Rules are evaluated in order for each try of a request.  The first rule matching the request
+	// that passes its probability check injects its fault.
+	Rules []FaultRule
+}
+
+// FaultKind is the kind of fault injected by a FaultRule.
+type FaultKind string
+
+const (
+	// FaultKindTransportError fails the try with a transport error instead of sending it.
+	FaultKindTransportError FaultKind = "transportError"
+
+	// FaultKindDelay delays sending the try by the rule's Delay.
+	FaultKindDelay FaultKind = "delay"
+
+	// FaultKindTruncatedBody sends the try and truncates the response body after the rule's TruncateAfter
+	// bytes, failing its download with a transport error.  Shorter bodies aren't affected.
+	FaultKindTruncatedBody FaultKind = "truncatedBody"
+
+	// FaultKindStatusCode responds to the try with the rule's StatusCode instead of sending it.
+	FaultKindStatusCode FaultKind = "statusCode"
+
+	// FaultKindThrottle responds to the try with a throttling response containing the rule's RetryAfter.
+	FaultKindThrottle FaultKind = "throttle"
+)
+
+// FaultRule describes a fault and the requests it's injected into.
+type FaultRule struct {
+	// Kind is the kind of fault.
+	Kind FaultKind
+
+	// Host matches the request's host, case-insensitively.  The default value is empty which matches any host.
+	Host string
+
+	// Method matches the request's HTTP method.  The default value is empty which matches any method.
+	Method string
+
+	// PathPrefix matches the start of the request's URL path.  The default value is empty which matches any path.
+	PathPrefix string
+
+	// Probability is the probability, between zero and one, that the fault is injected into a matching try.
+	// The default value is zero which injects the fault into every matching try, the same as one.
+	Probability float64
+
+	// Count is the maximum number of times the fault is injected.  The default value is zero which means no limit.
+	Count int
+
+	// Delay is the delay injected by FaultKindDelay.  The default value is one second.
+	Delay time.Duration
+
+	// StatusCode is the status code returned by FaultKindStatusCode and FaultKindThrottle.
+	// The default value is 503 for FaultKindStatusCode and 429 for FaultKindThrottle.
+	StatusCode int
+
+	// RetryAfter is the delay requested by FaultKindThrottle.  The default value is one second.
+	RetryAfter time.Duration
+
+	// TruncateAfter is the number of response body bytes returned before FaultKindTruncatedBody fails.
+	// The default value is zero.
+	TruncateAfter int64
+}
+
 // LogOptions configures the logging policy's behavior.
 type LogOptions struct {
 	// IncludeBody indicates if request and response bodies should be included in logging.
 	// The default value is false.
 	// NOTE: enabling this can lead to disclosure of sensitive information, use with care.
 	IncludeBody bool
+
+	// MaxBodyBytes caps the number of body bytes logged when IncludeBody is true.
+	// The default value is zero, which logs the entire body.
+	MaxBodyBytes int
+
+	// AllowedHeaders is a list of request and response headers, in addition to a set of
+	// well-known headers, whose values are logged in clear. All other header values are REDACTED.
+	// Header names are case-insensitive.
+	AllowedHeaders []string
+
+	// AllowedQueryParams is a list of query parameters, in addition to api-version, whose
+	// values are logged in clear. All other query parameter values are REDACTED.
+	// Query parameter names are case-insensitive.
+	AllowedQueryParams []string
+
+	// Sink receives a structured record for each request and response instead of the
+	// free-form text written to the log listener. Header and query parameter values are
+	// redacted in the same way as the text output.
+	// The default value is nil, which writes free-form text to the log listener.
+	Sink func(LogRecord)
+}
+
+// LogRecord is a structured entry emitted by the logging policy to LogOptions.Sink.
+type LogRecord struct {
+	// Classification is log.Request for an outgoing request and log.Response for its response or error.
+	Classification log.Classification
+
+	// Method is the request's HTTP method.
+	Method string
+
+	// URL is the request's URL with query parameter values redacted per LogOptions.AllowedQueryParams.
+	URL string
+
+	// StatusCode is the response's status code. It's zero for requests and for tries that failed to get a response.
+	StatusCode int
+
+	// Try is the number of the try within the operation, starting at 1.
+	Try int32
+
+	// Duration is the time spent on this try. It's zero for requests.
+	Duration time.Duration
+
+	// RequestID is the service's x-ms-request-id when available, otherwise the request's x-ms-client-request-id.
+	RequestID string
+
+	// Header contains the request's or response's headers, redacted per LogOptions.AllowedHeaders.
+	Header http.Header
+
+	// Body contains the request or response body when LogOptions.IncludeBody is true and the content type is textual.
+	// It's truncated to LogOptions.MaxBodyBytes.
+	Body string
+
+	// Err is the error returned by a try that failed to get a response.
+	Err error
+}
+
+// MetricsOptions configures the metrics policy's behavior.
+type MetricsOptions struct {
+	// Recorder receives an event for each try and for each operation.
+	// Use runtime.NewHistogramRecorder to convert the events into histogram-friendly measurements.
+	// The default value is nil which disables metrics.
+	Recorder MetricsRecorder
+}
+
+// MetricsRecorder receives metrics events from the metrics policy.
+// Implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	// TryCompleted is called after each try of an operation.
+	TryCompleted(MetricsEvent)
+
+	// OperationCompleted is called once after an operation, including all of its retries, has completed.
+	OperationCompleted(MetricsEvent)
+}
+
+// MetricsEvent describes the outcome of a try or an operation.
+// For operation events, the values describe the final try except BytesSent and BytesReceived,
+// which are totals across all tries, and Latency, which includes retry delays.
+type MetricsEvent struct {
+	// Service is the name of the module, e.g. "armcompute", that created the pipeline.
+	Service string
+
+	// Operation is the name set with WithOperationName. It defaults to "HTTP <method>".
+	Operation string
+
+	// StatusCode is the HTTP status code of the response. It's zero when no response was received.
+	StatusCode int
+
+	// ErrorCode is the value of the x-ms-error-code response header, if any.
+	ErrorCode string
+
+	// Err is the error returned when no response was received.
+	Err error
+
+	// RetryCount is the number of retries that preceded the try, or that the operation made.
+	RetryCount int32
+
+	// BytesSent is the number of request body bytes sent.
+	BytesSent int64
+
+	// BytesReceived is the number of response body bytes received.
+	// It's the response's Content-Length when the body isn't downloaded by the pipeline.
+	BytesReceived int64
+
+	// Latency is the time taken by the try or operation.
+	Latency time.Duration
+}
+
+// Measurement is a single observation produced by the recorder returned from runtime.NewHistogramRecorder.
+type Measurement struct {
+	// Name is the metric's name, e.g. "azure_sdk_operation_duration_seconds".
+	Name string
+
+	// Value is the observed value.
+	Value float64
+
+	// Labels contains the service, operation, status_code and error_code of the observation.
+	Labels map[string]string
+}
+
+// RateLimiter limits the rate and concurrency of requests.
+// It's safe for concurrent use.
+type RateLimiter interface {
+	// Acquire blocks until the specified request can be sent or the context is done.
+	// On success, the returned release func must be called with the response (which can be nil)
+	// once the request has completed.
+	Acquire(ctx context.Context, req *http.Request) (release func(*http.Response), err error)
+}
+
+// RateLimitOptions configures the behavior of a RateLimiter created by runtime.NewRateLimiter.
+// Limits are tracked independently for each partition.
+type RateLimitOptions struct {
+	// RequestsPerSecond is the sustained number of requests per second allowed for a partition.
+	// The default value is zero which disables rate limiting.
+	RequestsPerSecond float64
+
+	// Burst is the maximum number of requests that can be sent at once for a partition.
+	// The default value is RequestsPerSecond rounded up to the nearest integer.
+	Burst int
+
+	// MaxConcurrentRequests is the maximum number of in-flight requests for a partition.
+	// The default value is zero which disables concurrency limiting.
+	MaxConcurrentRequests int
+
+	// PartitionKey returns the partition for the specified request.
+	// The default partitions requests by host.
+	PartitionKey func(*http.Request) string
+
+	// Adaptive enables adapting to the x-ms-ratelimit-remaining-* response headers returned by ARM.
+	// The read, write and delete budgets are tracked separately: GET and HEAD requests are limited by
+	// the *-reads headers, DELETE requests by the *-deletes headers and other requests by the *-writes
+	// headers.  Requests of each kind are limited to the lowest remaining value reported for that kind
+	// divided by AdaptiveWindow, so they slow down as the service's budget dwindles and pause for
+	// AdaptiveWindow when it's exhausted.  This applies even when RequestsPerSecond is zero.
+	// The default value is false which ignores the headers.
+	Adaptive bool
+
+	// AdaptiveWindow is the period over which the service's remaining requests are spread, and
+	// how long an adapted rate applies without a newer response.
+	// The default value is one hour, the period of ARM's budgets.
+	AdaptiveWindow time.Duration
 }
 
 // RetryOptions configures the retry policy's behavior.
@@ -73,18 +396,60 @@ type RetryOptions struct {
 
 	// RetryDelay specifies the initial amount of delay to use before retrying an operation.
 	// The delay increases exponentially with each retry up to the maximum specified by MaxRetryDelay.
+	// If the response contains a Retry-After, retry-after-ms or x-ms-retry-after-ms header, its value
+	// is used instead.
 	// The default value is four seconds.  A value less than zero means no delay between retries.
 	RetryDelay time.Duration
 
 	// MaxRetryDelay specifies the maximum delay allowed before retrying an operation.
+	// This includes any delay requested by the service via a Retry-After header.
 	// Typically the value is greater than or equal to the value specified in RetryDelay.
 	// The default Value is 120 seconds.  A value less than zero means there is no cap.
 	MaxRetryDelay time.Duration
 
 	// StatusCodes specifies the HTTP status codes that indicate the operation should be retried.
-	// The default value is the status codes in StatusCodesForRetry.
+	// The default value is 408, 429, 500, 502, 503 and 504.
 	// Specifying an empty slice will cause retries to happen only for transport errors.
+	// This field is ignored when ShouldRetry is specified.
 	StatusCodes []int
+
+	// ShouldRetry evaluates if the retry policy should retry the request.
+	// When specified, the function overrides comparison against the list of
+	// HTTP status codes and error checking within the retry policy.
+	// The *http.Response and error parameters are mutually exclusive, i.e.
+	// if one is nil, the other is not nil.
+	// A return value of true means the retry policy should retry.
+	// NOTE: requests are never retried when the context has been cancelled or its deadline
+	// exceeded, nor when the error implements errorinfo.NonRetriable.
+	ShouldRetry func(*http.Response, error) bool
+
+	// Backoff calculates the delay before retrying an operation.
+	// try is the number of the try that failed, starting at one, and previous is the delay
+	// returned for the prior retry; it's zero for the first retry.
+	// The delay is capped by MaxRetryDelay and is ignored if the service returned a Retry-After header.
+	// The default is exponential backoff with jitter, starting at RetryDelay.
+	Backoff func(try int32, previous time.Duration) time.Duration
+}
+
+// SecondaryReadOptions configures the secondary read policy created by runtime.NewSecondaryReadPolicy().
+// The policy sends GET and HEAD requests to a secondary endpoint, such as the secondary of a read-access
+// geo-redundant (RA-GRS) storage account. The endpoint that served a response is available from
+// Response.Request.URL.Host.
+type SecondaryReadOptions struct {
+	// Host is the secondary endpoint's host, e.g. "myaccount-secondary.blob.core.windows.net".
+	// Secondary reads are disabled when Host is empty.
+	Host string
+
+	// PrimaryTimeout bounds how long a read waits for the primary endpoint's response headers. When it
+	// elapses, the read to the primary is abandoned and sent to the secondary. It doesn't apply to
+	// downloading the response body.
+	// The default value is zero, which doesn't bound the primary.
+	PrimaryTimeout time.Duration
+
+	// HedgeDelay enables hedged reads. When the primary hasn't returned response headers within HedgeDelay,
+	// the read is also sent to the secondary and the first successful response is returned.
+	// The default value is zero, which sends the read to the secondary only after the primary fails.
+	HedgeDelay time.Duration
 }
 
 // TelemetryOptions configures the telemetry policy's behavior.
@@ -97,6 +462,81 @@ type TelemetryOptions struct {
 	Disabled bool
 }
 
+// TracingOptions configures the tracing policy's behavior.
+type TracingOptions struct {
+	// Tracer is used to create spans for each API call and each try of an HTTP request.
+	// The default value is nil which disables tracing.
+	Tracer tracing.Tracer
+}
+
+// TransportOptions configures the HTTP transport created by runtime.NewTransport().
+// Zero-value fields keep the SDK's default transport settings.
+type TransportOptions struct {
+	// Proxy is the URL of the proxy through which requests are sent.
+	// The default value is nil which reads the proxy from the HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	// environment variables.
+	Proxy *url.URL
+
+	// ProxyUsername and ProxyPassword are the credentials for the proxy.  They override any user
+	// info in Proxy and are ignored when Proxy is nil.
+	ProxyUsername string
+	ProxyPassword string
+
+	// DisableProxy sends requests directly, ignoring the proxy environment variables.
+	DisableProxy bool
+
+	// RootCAs is the set of root certificate authorities used to verify server certificates.
+	// The default value is nil which uses the host's root CA set.
+	RootCAs *x509.CertPool
+
+	// ClientCertificates contains certificates presented to servers requesting client authentication.
+	ClientCertificates []tls.Certificate
+
+	// MinTLSVersion is the minimum TLS version, e.g. tls.VersionTLS13.
+	// The default value is tls.VersionTLS12.  Lower versions are ignored unless
+	// AllowInsecureTLSVersion is true.
+	MinTLSVersion uint16
+
+	// AllowInsecureTLSVersion allows MinTLSVersion to be lower than tls.VersionTLS12.  Those versions
+	// are insecure; set this only to connect to servers which can't be upgraded.
+	AllowInsecureTLSVersion bool
+
+	// MaxConnsPerHost limits the number of connections per host, including those in use.
+	// The default value is zero which means no limit.
+	MaxConnsPerHost int
+
+	// MaxIdleConns limits the number of idle connections across all hosts.
+	// The default value is 100.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits the number of idle connections per host.
+	// The default value is zero which uses http.DefaultMaxIdleConnsPerHost.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection remains open.
+	// The default value is 90 seconds.
+	IdleConnTimeout time.Duration
+
+	// DisableHTTP2 restricts connections to HTTP/1.1.
+	DisableHTTP2 bool
+
+	// DialTimeout is the maximum time allowed to establish a TCP connection.
+	// The default value is 30 seconds.
+	DialTimeout time.Duration
+
+	// KeepAlive is the interval between TCP keep-alive probes.
+	// The default value is 30 seconds.  A value less than zero disables keep-alive probes.
+	KeepAlive time.Duration
+
+	// TLSHandshakeTimeout is the maximum time allowed for the TLS handshake.
+	// The default value is 10 seconds.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout is the maximum time to wait for a response's headers after sending
+	// the request.  The default value is zero which means no limit; see RetryOptions.TryTimeout.
+	ResponseHeaderTimeout time.Duration
+}
+
 // TokenRequestOptions contain specific parameter that may be used by credentials types when attempting to get a token.
 type TokenRequestOptions struct {
 	// Scopes contains the list of permission scopes required for the token.
@@ -104,6 +544,24 @@ type TokenRequestOptions struct {
 	// TenantID contains the tenant ID to use in a multi-tenant authentication scenario, if TenantID is set
 	// it will override the tenant ID that was added at credential creation time.
 	TenantID string
+	// Claims contains additional claims required in the token, such as those requested by a
+	// Continuous Access Evaluation or step-up authentication challenge.  It's a JSON object.
+	Claims string
+}
+
+// AuthorizationHandler allows SDKs to support service-specific authorization, such as discovering
+// the scope and tenant of a token from the service's authentication challenge.
+// The authorize func passed to each callback acquires a token with the given options and sets
+// the request's Authorization header.  Tokens are cached per scopes and tenant.
+type AuthorizationHandler struct {
+	// OnRequest is called before each try of a request.  When nil, the request is authorized
+	// with the policy's TokenRequestOptions.
+	OnRequest func(req *Request, authorize func(TokenRequestOptions) error) error
+
+	// OnChallenge is called when the service responds 401 with a WWW-Authenticate header.  When it
+	// returns nil, the request is sent again; an error fails the request.  When nil, the policy
+	// handles claims challenges.
+	OnChallenge func(req *Request, resp *http.Response, authorize func(TokenRequestOptions) error) error
 }
 
 // WithHTTPHeader adds the specified http.Header to the parent context.
@@ -118,3 +576,10 @@ func WithHTTPHeader(parent context.Context, header http.Header) context.Context
 func WithRetryOptions(parent context.Context, options RetryOptions) context.Context {
 	return context.WithValue(parent, shared.CtxWithRetryOptionsKey{}, options)
 }
+
+// WithOperationName adds the specified operation name to the parent context.
+// Use this to identify the API being called, e.g. "BlobClient.Download".
+// The operation name is used as the name of the span created by the tracing policy.
+func WithOperationName(parent context.Context, name string) context.Context {
+	return context.WithValue(parent, shared.CtxWithOperationNameKey{}, name)
+}
@@ -52,3 +52,27 @@ func TestWithRetryOptions(t *testing.T) {
 		t.Fatalf("unexpected value %d", opts.MaxRetries)
 	}
 }
+
+func TestWithOperationName(t *testing.T) {
+	ctx := WithOperationName(context.Background(), "Widgets.Get")
+	if ctx == nil {
+		t.Fatal("nil context")
+	}
+	raw := ctx.Value(shared.CtxWithOperationNameKey{})
+	name, ok := raw.(string)
+	if !ok {
+		t.Fatalf("unexpected type %T", raw)
+	}
+	if name != "Widgets.Get" {
+		t.Fatalf("unexpected value %s", name)
+	}
+}
+
+func TestCircuitStateString(t *testing.T) {
+	if s := CircuitStateHalfOpen.String(); s != "HalfOpen" {
+		t.Fatalf("unexpected string %s", s)
+	}
+	if s := CircuitState(7).String(); s != "CircuitState(7)" {
+		t.Fatalf("unexpected string %s", s)
+	}
+}
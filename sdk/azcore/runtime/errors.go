@@ -15,6 +15,8 @@ import (
 // NewResponseError wraps the specified error with an error that provides access to an HTTP response.
 // If an HTTP request returns a non-successful status code, wrap the response and the associated error
 // in this error type so that callers can access the underlying *http.Response as required.
+// The returned error is an *azcore.ResponseError containing the service's error code and message,
+// parsed from the x-ms-error-code header or the response body.  The inner error can be nil.
 // DO NOT wrap failed HTTP requests that returned an error and no response with this type.
 func NewResponseError(inner error, resp *http.Response) error {
 	return shared.NewResponseError(inner, resp)
@@ -0,0 +1,82 @@
+//go:build go1.18
+// +build go1.18
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+)
+
+// PagingHandler contains the required data for constructing a Pager.
+type PagingHandler[T any] struct {
+	// More returns a boolean indicating if there are more pages to fetch.
+	// It uses the provided page to make the determination.
+	More func(T) bool
+
+	// Fetcher fetches the first and subsequent pages.
+	// The previous page is nil when fetching the first page.
+	Fetcher func(ctx context.Context, previous *T) (T, error)
+}
+
+// Pager provides operations for iterating over paged responses.
+type Pager[T any] struct {
+	current *T
+	handler PagingHandler[T]
+}
+
+// NewPager creates an instance of Pager using the specified PagingHandler.
+func NewPager[T any](handler PagingHandler[T]) *Pager[T] {
+	return &Pager[T]{
+		handler: handler,
+	}
+}
+
+// More returns true if there are more pages to retrieve.
+func (p *Pager[T]) More() bool {
+	if p.current != nil {
+		return p.handler.More(*p.current)
+	}
+	// the first page hasn't been fetched yet
+	return true
+}
+
+// NextPage advances the pager to the next page.
+// Calling NextPage when More returns false returns an error.
+func (p *Pager[T]) NextPage(ctx context.Context) (T, error) {
+	if !p.More() {
+		return *new(T), errors.New("no more pages")
+	}
+	resp, err := p.handler.Fetcher(ctx, p.current)
+	if err != nil {
+		return *new(T), err
+	}
+	p.current = &resp
+	return resp, nil
+}
+
+// All returns an iterator over the remaining pages.
+// Iteration stops after the first error, which is yielded along with a zero-value page.
+// The iterator has the signature of an iter.Seq2[T, error], so Go 1.23 and later can range over it:
+//
+//	for page, err := range pager.All(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		// process page
+//	}
+//
+// Earlier versions can call it with a yield func returning false to stop iteration.
+func (p *Pager[T]) All(ctx context.Context) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		for p.More() {
+			page, err := p.NextPage(ctx)
+			if !yield(page, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build go1.23
+// +build go1.23
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPagerAll(t *testing.T) {
+	pager, fetched := newTestPager(3, 0)
+	values := []int{}
+	for page, err := range pager.All(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, page.Values...)
+	}
+	if *fetched != 3 || len(values) != 3 {
+		t.Fatalf("unexpected values %v", values)
+	}
+}
+
+func TestPagerAllBreak(t *testing.T) {
+	pager, fetched := newTestPager(3, 0)
+	for range pager.All(context.Background()) {
+		break
+	}
+	if *fetched != 1 {
+		t.Fatalf("unexpected fetch count %d", *fetched)
+	}
+}
+
+func TestPagerAllError(t *testing.T) {
+	pager, fetched := newTestPager(3, 2)
+	var lastErr error
+	pages := 0
+	for _, err := range pager.All(context.Background()) {
+		pages++
+		lastErr = err
+	}
+	if lastErr == nil {
+		t.Fatal("unexpected nil error")
+	}
+	if pages != 2 || *fetched != 2 {
+		t.Fatalf("unexpected page count %d", pages)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build go1.18
+// +build go1.18
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type pageResponse struct {
+	Values   []int
+	NextPage bool
+}
+
+func newTestPager(pages int, failOn int) (*Pager[pageResponse], *int) {
+	fetched := 0
+	return NewPager(PagingHandler[pageResponse]{
+		More: func(current pageResponse) bool {
+			return current.NextPage
+		},
+		Fetcher: func(ctx context.Context, previous *pageResponse) (pageResponse, error) {
+			if (previous == nil) != (fetched == 0) {
+				return pageResponse{}, errors.New("unexpected previous page")
+			}
+			fetched++
+			if fetched == failOn {
+				return pageResponse{}, errors.New("fetch failed")
+			}
+			return pageResponse{Values: []int{fetched}, NextPage: fetched < pages}, nil
+		},
+	}), &fetched
+}
+
+func TestPagerSinglePage(t *testing.T) {
+	pager, _ := newTestPager(1, 0)
+	if !pager.More() {
+		t.Fatal("expected more pages")
+	}
+	page, err := pager.NextPage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(page.Values); l != 1 {
+		t.Fatalf("unexpected page length %d", l)
+	}
+	if pager.More() {
+		t.Fatal("unexpected more pages")
+	}
+	if _, err = pager.NextPage(context.Background()); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+}
+
+func TestPagerMultiplePages(t *testing.T) {
+	pager, fetched := newTestPager(4, 0)
+	total := 0
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += page.Values[0]
+	}
+	if *fetched != 4 {
+		t.Fatalf("unexpected fetch count %d", *fetched)
+	}
+	if total != 10 {
+		t.Fatalf("unexpected total %d", total)
+	}
+}
+
+func TestPagerFetchError(t *testing.T) {
+	pager, _ := newTestPager(4, 2)
+	if _, err := pager.NextPage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	page, err := pager.NextPage(context.Background())
+	if err == nil {
+		t.Fatal("unexpected nil error")
+	}
+	if page.Values != nil {
+		t.Fatal("expected zero-value page")
+	}
+	// the failed fetch can be retried
+	if !pager.More() {
+		t.Fatal("expected more pages")
+	}
+}
+
+func TestPagerAllYield(t *testing.T) {
+	pager, fetched := newTestPager(3, 0)
+	pages := 0
+	pager.All(context.Background())(func(page pageResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		return pages < 2
+	})
+	if pages != 2 || *fetched != 2 {
+		t.Fatalf("unexpected page count %d, fetch count %d", pages, *fetched)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// PipelineOptions contains the policies a client adds to the pipeline created by NewClientPipeline.
+type PipelineOptions struct {
+	// PerCall contains the client's policies which are executed once per request,
+	// before the caller's ClientOptions.PerCallPolicies.
+	PerCall []policy.Policy
+
+	// PerRetry contains the client's policies which are executed once per request, and for each retry of that
+	// request, after the caller's ClientOptions.PerRetryPolicies.  This is where authentication policies belong.
+	PerRetry []policy.Policy
+}
+
+// NewClientPipeline creates a pipeline containing the built-in policies configured by options, plus the client's
+// policies in plOpts.  The telemetry and metrics policies, when enabled, will use the specified module and version info.
+// Pass nil to accept the default options; this is the same as passing a zero-value options.
+func NewClientPipeline(module, version string, plOpts PipelineOptions, options *policy.ClientOptions) Pipeline {
+	if options == nil {
+		options = &policy.ClientOptions{}
+	}
+	policies := []policy.Policy{
+		NewTracingPolicy(&options.Tracing),
+		NewMetricsPolicy(module, &options.Metrics),
+	}
+	if !options.Telemetry.Disabled {
+		policies = append(policies, NewTelemetryPolicy(module, version, &options.Telemetry))
+	}
+	policies = append(policies, plOpts.PerCall...)
+	policies = append(policies, options.PerCallPolicies...)
+	if options.Compression != nil {
+		policies = append(policies, NewCompressionPolicy(options.Compression))
+	}
+	policies = append(policies, NewRetryPolicy(&options.Retry))
+	if options.CircuitBreaker != nil {
+		policies = append(policies, NewCircuitBreakerPolicy(options.CircuitBreaker))
+	}
+	if options.RateLimiter != nil {
+		policies = append(policies, NewRateLimitPolicy(options.RateLimiter))
+	}
+	policies = append(policies, options.PerRetryPolicies...)
+	policies = append(policies, plOpts.PerRetry...)
+	policies = append(policies, NewLogPolicy(&options.Logging))
+	return NewPipeline(options.Transport, policies...)
+}
@@ -0,0 +1,155 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pipeline"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+// countingRateLimiter counts the requests it admits
+type countingRateLimiter struct {
+	mu       sync.Mutex
+	acquired int
+}
+
+func (c *countingRateLimiter) Acquire(ctx context.Context, req *http.Request) (func(*http.Response), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.acquired++
+	return func(*http.Response) {}, nil
+}
+
+func TestNewClientPipeline(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK))
+	var calls []string
+	record := func(name string) policy.Policy {
+		return pipeline.PolicyFunc(func(req *policy.Request) (*http.Response, error) {
+			calls = append(calls, name)
+			return req.Next()
+		})
+	}
+	var userAgent string
+	userAgentPolicy := pipeline.PolicyFunc(func(req *policy.Request) (*http.Response, error) {
+		userAgent = req.Raw().Header.Get(shared.HeaderUserAgent)
+		return req.Next()
+	})
+	rec := &testMetricsRecorder{}
+	limiter := &countingRateLimiter{}
+	pl := NewClientPipeline("azwidgets", "v1.0.0", PipelineOptions{
+		PerCall:  []policy.Policy{record("client per call")},
+		PerRetry: []policy.Policy{record("client per retry")},
+	}, &policy.ClientOptions{
+		Metrics:          policy.MetricsOptions{Recorder: rec},
+		RateLimiter:      limiter,
+		Retry:            *testRetryOptions(),
+		Transport:        srv,
+		PerCallPolicies:  []policy.Policy{record("caller per call"), userAgentPolicy},
+		PerRetryPolicies: []policy.Policy{record("caller per retry")},
+	})
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	expected := "client per call,caller per call,caller per retry,client per retry,caller per retry,client per retry"
+	if actual := strings.Join(calls, ","); actual != expected {
+		t.Fatalf("unexpected policy order %s", actual)
+	}
+	if !strings.HasPrefix(userAgent, "azsdk-go-azwidgets/v1.0.0") {
+		t.Fatalf("unexpected user agent %q", userAgent)
+	}
+	if limiter.acquired != 2 {
+		t.Fatalf("unexpected rate limiter count %d", limiter.acquired)
+	}
+	if l := len(rec.operations); l != 1 || rec.operations[0].Service != "azwidgets" {
+		t.Fatalf("unexpected operations %+v", rec.operations)
+	}
+}
+
+func TestNewClientPipelineNilOptions(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithStatusCode(http.StatusOK))
+	pl := NewClientPipeline("azwidgets", "v1.0.0", PipelineOptions{}, nil)
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestNewClientPipelineMetricsAcrossClones(t *testing.T) {
+	// the tracing policies and a retry policy with a TryTimeout clone the request for each try, and the
+	// per-try values set after a clone must still reach the policies before it
+	for _, test := range []struct {
+		name    string
+		options policy.ClientOptions
+	}{
+		{name: "tracing", options: policy.ClientOptions{Tracing: policy.TracingOptions{Tracer: &testTracer{}}, Retry: *testRetryOptions()}},
+		{name: "TryTimeout", options: policy.ClientOptions{Retry: policy.RetryOptions{RetryDelay: 20 * time.Millisecond, TryTimeout: time.Minute}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv, close := mock.NewServer()
+			defer close()
+			srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+			srv.AppendResponse(mock.WithBody([]byte(`{"size":1}`)))
+			rec := &testMetricsRecorder{}
+			test.options.Metrics = policy.MetricsOptions{Recorder: rec}
+			test.options.Transport = srv
+			pl := NewClientPipeline("azwidgets", "v1.0.0", PipelineOptions{}, &test.options)
+			req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = pl.Do(req); err != nil {
+				t.Fatal(err)
+			}
+			if l := len(rec.tries); l != 2 {
+				t.Fatalf("unexpected try count %d", l)
+			}
+			if rec.tries[0].RetryCount != 0 || rec.tries[1].RetryCount != 1 {
+				t.Fatalf("unexpected try retry counts %d, %d", rec.tries[0].RetryCount, rec.tries[1].RetryCount)
+			}
+			if l := len(rec.operations); l != 1 {
+				t.Fatalf("unexpected operation count %d", l)
+			}
+			if op := rec.operations[0]; op.RetryCount != 1 || op.BytesReceived != 10 {
+				t.Fatalf("unexpected operation %+v", op)
+			}
+			if tracer, ok := test.options.Tracing.Tracer.(*testTracer); ok {
+				if rc := tracer.spans[2].attrs[attrHTTPResendCount]; rc != 1 {
+					t.Fatalf("unexpected resend count %v", rc)
+				}
+			}
+		})
+	}
+}
@@ -4,14 +4,19 @@
 package runtime
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azlog "github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
 )
 
 const (
@@ -19,6 +24,7 @@ const (
 	headerXmsDate                = "x-ms-date"
 	headerAuthorization          = "Authorization"
 	headerAuxiliaryAuthorization = "x-ms-authorization-auxiliary"
+	headerWWWAuthenticate        = "WWW-Authenticate"
 )
 
 // BearerTokenPolicy authorizes requests with bearer tokens acquired from a TokenCredential.
@@ -27,9 +33,12 @@ type BearerTokenPolicy struct {
 	mainResource *expiringResource
 	// auxResources are additional resources that are required for cross-tenant applications
 	auxResources map[string]*expiringResource
+	// otherResources are the resources an AuthorizationHandler requested with scopes or a tenant other than the policy's
+	otherResources *resourcesByOptions
 	// the following fields are read-only
 	cred    azcore.TokenCredential
 	options policy.TokenRequestOptions
+	handler policy.AuthorizationHandler
 }
 
 type expiringResource struct {
@@ -47,12 +56,15 @@ type expiringResource struct {
 
 	// acquireResource is the callback function that actually acquires the resource
 	acquireResource acquireResource
+
+	// refresh is non-nil when the resource is refreshed in the background
+	refresh *backgroundRefresh
 }
 
 type acquireResource func(state interface{}) (newResource interface{}, newExpiration time.Time, err error)
 
 type acquiringResourceState struct {
-	req *policy.Request
+	ctx context.Context
 	p   BearerTokenPolicy
 }
 
@@ -60,26 +72,64 @@ type acquiringResourceState struct {
 // thread/goroutine at a time ever calls this function
 func acquire(state interface{}) (newResource interface{}, newExpiration time.Time, err error) {
 	s := state.(acquiringResourceState)
-	tk, err := s.p.cred.GetToken(s.req.Raw().Context(), s.p.options)
+	tk, err := s.p.cred.GetToken(s.ctx, s.p.options)
 	if err != nil {
 		return nil, time.Time{}, err
 	}
 	return tk, tk.ExpiresOn, nil
 }
 
+// resourcesByOptions caches a resource for each combination of scopes and tenant
+type resourcesByOptions struct {
+	mu        sync.Mutex
+	resources map[resourceOptionsKey]*expiringResource
+}
+
+type resourceOptionsKey struct {
+	scopes   string
+	tenantID string
+}
+
+// get returns the resource for opts, creating it if necessary
+func (r *resourcesByOptions) get(opts policy.TokenRequestOptions) *expiringResource {
+	key := resourceOptionsKey{scopes: strings.Join(opts.Scopes, " "), tenantID: opts.TenantID}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	er, ok := r.resources[key]
+	if !ok {
+		er = newExpiringResource(acquire)
+		r.resources[key] = er
+	}
+	return er
+}
+
 func newExpiringResource(ar acquireResource) *expiringResource {
 	return &expiringResource{cond: sync.NewCond(&sync.Mutex{}), acquireResource: ar}
 }
 
+// set replaces the resource's value, e.g. after a challenge revoked the previous one
+func (er *expiringResource) set(resource interface{}, expiration time.Time) {
+	er.cond.L.Lock()
+	defer er.cond.L.Unlock()
+	er.resource, er.expiration = resource, expiration
+	if er.refresh != nil {
+		er.scheduleRefresh(er.refresh.delay(expiration))
+	}
+}
+
 func (er *expiringResource) GetResource(state interface{}) (interface{}, error) {
 	// If the resource is expiring within this time window, update it eagerly.
 	// This allows other threads/goroutines to keep running by using the not-yet-expired
 	// resource value while one thread/goroutine updates the resource.
 	const window = 2 * time.Minute // This example updates the resource 2 minutes prior to expiration
 
-	now, acquire, resource := time.Now(), false, er.resource
+	now, acquire := time.Now(), false
+	var resource interface{}
 	// acquire exclusive lock
 	er.cond.L.Lock()
+	if er.refresh != nil {
+		er.refresh.used = true
+	}
 	for {
 		if er.expiration.IsZero() || er.expiration.Before(now) {
 			// The resource was never acquired or has expired
@@ -89,7 +139,8 @@ func (er *expiringResource) GetResource(state interface{}) (interface{}, error)
 				break
 			}
 			// Getting here means that this thread/goroutine will wait for the updated resource
-		} else if er.expiration.Add(-window).Before(now) {
+		} else if er.refresh == nil && er.expiration.Add(-window).Before(now) {
+			// (when refreshing in the background, the resource is renewed before reaching this window)
 			// The resource is valid but is expiring within the time window
 			if !er.acquiring {
 				// If another thread/goroutine is not acquiring/renewing the resource, this thread/goroutine will do it
@@ -107,6 +158,10 @@ func (er *expiringResource) GetResource(state interface{}) (interface{}, error)
 		// If we get here, wait for the new resource value to be acquired/updated
 		er.cond.Wait()
 	}
+	if er.refresh != nil && !acquire && !er.acquiring && er.refresh.timer == nil {
+		// background refresh stopped while the resource was idle, restart it
+		er.scheduleRefresh(er.refresh.delay(er.expiration))
+	}
 	er.cond.L.Unlock() // Release the lock so no threads/goroutines are blocked
 
 	var err error
@@ -120,6 +175,9 @@ func (er *expiringResource) GetResource(state interface{}) (interface{}, error)
 		if err == nil {
 			// No error, update resource & expiration
 			er.resource, er.expiration = resource, expiration
+			if er.refresh != nil {
+				er.scheduleRefresh(er.refresh.delay(expiration))
+			}
 		}
 		er.acquiring = false // Indicate that no thread/goroutine is currently acquiring the resrouce
 
@@ -130,6 +188,102 @@ func (er *expiringResource) GetResource(state interface{}) (interface{}, error)
 	return resource, err // Return the resource this thread/goroutine can use
 }
 
+// backgroundRefreshRetryDelay is the delay before retrying a failed background refresh
+const backgroundRefreshRetryDelay = 30 * time.Second
+
+type backgroundRefresh struct {
+	window  time.Duration
+	jitter  time.Duration
+	onError func(error)
+
+	// state returns the value passed to acquireResource, with a context that isn't bound to any request.
+	state func(ctx context.Context) interface{}
+
+	// timer is the pending refresh; it's nil when no refresh is scheduled
+	timer *time.Timer
+
+	// used indicates the resource was used since the last refresh.  Refreshing
+	// stops when the resource is idle, so abandoned policies don't refresh forever.
+	used bool
+}
+
+func newBackgroundRefresh(o *TokenRefreshOptions, state func(ctx context.Context) interface{}) *backgroundRefresh {
+	r := &backgroundRefresh{window: o.Window, jitter: o.Jitter, onError: o.OnError, state: state}
+	if r.window <= 0 {
+		r.window = 5 * time.Minute
+	}
+	if r.jitter == 0 {
+		r.jitter = 30 * time.Second
+	}
+	return r
+}
+
+// delay returns how long to wait before refreshing a resource expiring at expiration
+func (r *backgroundRefresh) delay(expiration time.Time) time.Duration {
+	remaining := time.Until(expiration)
+	d := remaining - r.window
+	if r.jitter > 0 {
+		d -= time.Duration(rand.Int63n(int64(r.jitter)))
+	}
+	if d <= 0 {
+		// the resource's lifetime is shorter than the window; don't refresh it continuously
+		d = remaining / 2
+	}
+	return d
+}
+
+// scheduleRefresh schedules a background refresh after delay.  The caller must hold er.cond.L.
+func (er *expiringResource) scheduleRefresh(delay time.Duration) {
+	if er.refresh.timer != nil {
+		er.refresh.timer.Stop()
+	}
+	er.refresh.timer = time.AfterFunc(delay, er.refreshInBackground)
+}
+
+// refreshInBackground acquires a new resource value while callers continue to use the current one.
+// When acquisition fails, the current value is kept and acquisition is retried until it expires.
+// Acquisition is canceled when the current value expires, so that callers waiting for a value
+// aren't blocked by a refresh that doesn't return; one of them then acquires the value instead.
+func (er *expiringResource) refreshInBackground() {
+	er.cond.L.Lock()
+	er.refresh.timer = nil
+	remaining := time.Until(er.expiration)
+	if !er.refresh.used || er.acquiring || remaining <= 0 {
+		// the resource is idle or expired, or a caller is acquiring it and will schedule the next refresh
+		er.cond.L.Unlock()
+		return
+	}
+	er.refresh.used = false
+	er.acquiring = true
+	er.cond.L.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), remaining)
+	resource, expiration, err := er.acquireResource(er.refresh.state(ctx))
+	cancel()
+
+	er.cond.L.Lock()
+	er.acquiring = false
+	if err == nil {
+		er.resource, er.expiration = resource, expiration
+		er.scheduleRefresh(er.refresh.delay(expiration))
+	} else if remaining = time.Until(er.expiration); remaining > 0 {
+		er.refresh.used = true
+		retry := backgroundRefreshRetryDelay
+		if remaining/2 < retry {
+			retry = remaining / 2
+		}
+		er.scheduleRefresh(retry)
+	}
+	er.cond.L.Unlock()
+	er.cond.Broadcast()
+	if err != nil {
+		log.Writef(azlog.Authentication, "background token refresh failed: %v", err)
+		if er.refresh.onError != nil {
+			er.refresh.onError(err)
+		}
+	}
+}
+
 // NewBearerTokenPolicy creates a policy object that authorizes requests with bearer tokens.
 // cred: an azcore.TokenCredential implementation such as a credential object from azidentity
 // opts: optional settings. Pass nil to accept default values; this is the same as passing a zero-value options.
@@ -137,7 +291,11 @@ func NewBearerTokenPolicy(cred azcore.TokenCredential, opts AuthenticationOption
 	p := &BearerTokenPolicy{
 		cred:         cred,
 		options:      opts.TokenRequest,
+		handler:      opts.AuthorizationHandler,
 		mainResource: newExpiringResource(acquire),
+		otherResources: &resourcesByOptions{
+			resources: map[resourceOptionsKey]*expiringResource{},
+		},
 	}
 	if len(opts.AuxiliaryTenants) > 0 {
 		p.auxResources = map[string]*expiringResource{}
@@ -146,39 +304,239 @@ func NewBearerTokenPolicy(cred azcore.TokenCredential, opts AuthenticationOption
 		p.auxResources[t] = newExpiringResource(acquire)
 
 	}
+	if opts.BackgroundRefresh != nil {
+		mainP := *p
+		p.mainResource.refresh = newBackgroundRefresh(opts.BackgroundRefresh, func(ctx context.Context) interface{} {
+			return acquiringResourceState{ctx: ctx, p: mainP}
+		})
+		for t, er := range p.auxResources {
+			auxP := *p
+			auxP.options.TenantID = t
+			er.refresh = newBackgroundRefresh(opts.BackgroundRefresh, func(ctx context.Context) interface{} {
+				return acquiringResourceState{ctx: ctx, p: auxP}
+			})
+		}
+	}
 	return p
 }
 
-// Do authorizes a request with a bearer token
+// Do authorizes a request with a bearer token.  When the service responds with an authentication
+// challenge, the challenge is passed to the AuthorizationHandler.  By default, the policy handles
+// claims challenges, such as those returned for Continuous Access Evaluation, by requesting a new
+// token with the challenge's claims.  The request is then sent again, once.
 func (b *BearerTokenPolicy) Do(req *policy.Request) (*http.Response, error) {
-	as := acquiringResourceState{
-		p:   *b,
-		req: req,
+	authorize := func(opts policy.TokenRequestOptions) error {
+		return b.authorize(req, opts)
+	}
+	var err error
+	if b.handler.OnRequest != nil {
+		err = b.handler.OnRequest(req, authorize)
+	} else {
+		err = authorize(b.options)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = b.authorizeAuxiliary(req, ""); err != nil {
+		return nil, err
+	}
+	resp, err := req.Next()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || resp.Header.Get(headerWWWAuthenticate) == "" {
+		return resp, err
+	}
+	claims := challengeClaims(resp)
+	if b.handler.OnChallenge != nil {
+		err = b.handler.OnChallenge(req, resp, authorize)
+	} else {
+		if claims == "" {
+			// not a challenge this policy can satisfy
+			return resp, nil
+		}
+		opts := b.options
+		opts.Claims = claims
+		err = authorize(opts)
 	}
-	tk, err := b.mainResource.GetResource(as)
+	Drain(resp)
 	if err != nil {
 		return nil, err
 	}
-	if token, ok := tk.(*azcore.AccessToken); ok {
+	if claims != "" {
+		// the auxiliary tenants' tokens must satisfy the challenge too
+		if err = b.authorizeAuxiliary(req, claims); err != nil {
+			return nil, err
+		}
+	}
+	if err = req.RewindBody(); err != nil {
+		return nil, err
+	}
+	return req.Next()
+}
+
+// authorize sets the request's Authorization header to a token acquired with opts.  Tokens are cached
+// per scopes and tenant; a cached token is replaced by a token satisfying a claims challenge.
+func (b *BearerTokenPolicy) authorize(req *policy.Request, opts policy.TokenRequestOptions) error {
+	er := b.mainResource
+	if opts.TenantID != b.options.TenantID || !equalScopes(opts.Scopes, b.options.Scopes) {
+		er = b.otherResources.get(opts)
+	}
+	var token *azcore.AccessToken
+	if opts.Claims == "" {
+		bCopy := *b
+		bCopy.options = opts
+		tk, err := er.GetResource(acquiringResourceState{ctx: req.Raw().Context(), p: bCopy})
+		if err != nil {
+			return err
+		}
+		token, _ = tk.(*azcore.AccessToken)
+	} else {
+		tk, err := b.cred.GetToken(req.Raw().Context(), opts)
+		if err != nil {
+			return err
+		}
+		er.set(tk, tk.ExpiresOn)
+		token = tk
+	}
+	if token != nil {
 		req.Raw().Header.Set(headerXmsDate, time.Now().UTC().Format(http.TimeFormat))
 		req.Raw().Header.Set(headerAuthorization, bearerTokenPrefix+token.Token)
 	}
+	return nil
+}
+
+// authorizeAuxiliary sets the header containing the tokens for auxiliary tenants, if any.  When claims
+// isn't empty, the cached tokens are replaced by tokens satisfying the claims challenge.
+func (b *BearerTokenPolicy) authorizeAuxiliary(req *policy.Request, claims string) error {
 	auxTokens := []string{}
 	for tenant, er := range b.auxResources {
 		bCopy := *b
 		bCopy.options.TenantID = tenant
-		auxAS := acquiringResourceState{
-			p:   bCopy,
-			req: req,
+		var auxTk interface{}
+		var err error
+		if claims == "" {
+			auxTk, err = er.GetResource(acquiringResourceState{ctx: req.Raw().Context(), p: bCopy})
+		} else {
+			opts := bCopy.options
+			opts.Claims = claims
+			var tk *azcore.AccessToken
+			if tk, err = b.cred.GetToken(req.Raw().Context(), opts); err == nil {
+				er.set(tk, tk.ExpiresOn)
+				auxTk = tk
+			}
 		}
-		auxTk, err := er.GetResource(auxAS)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		auxTokens = append(auxTokens, fmt.Sprintf("%s%s", bearerTokenPrefix, auxTk.(*azcore.AccessToken).Token))
 	}
 	if len(auxTokens) > 0 {
 		req.Raw().Header.Set(headerAuxiliaryAuthorization, strings.Join(auxTokens, ", "))
 	}
-	return req.Next()
+	return nil
+}
+
+func equalScopes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Challenge is an authentication challenge from a WWW-Authenticate header.
+type Challenge struct {
+	// Scheme is the authentication scheme, e.g. "Bearer".
+	Scheme string
+
+	// Parameters contains the challenge's parameters, e.g. "authorization" and "claims".
+	// The keys are lower case.
+	Parameters map[string]string
+}
+
+// ParseChallenges returns the authentication challenges in the response's WWW-Authenticate headers.
+// AuthorizationHandler implementations can use it to discover e.g. the authority and scope of a service.
+func ParseChallenges(resp *http.Response) []Challenge {
+	challenges := []Challenge{}
+	for _, header := range resp.Header.Values(headerWWWAuthenticate) {
+		challenges = append(challenges, parseChallenges(header)...)
+	}
+	return challenges
+}
+
+// parseChallenges parses a header value containing one or more challenges, e.g.
+// Bearer realm="", error="insufficient_claims", claims="eyJ...", Basic realm="x"
+func parseChallenges(header string) []Challenge {
+	challenges := []Challenge{}
+	s := header
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if s == "" {
+			return challenges
+		}
+		end := strings.IndexAny(s, " ,=")
+		if end < 0 {
+			end = len(s)
+		}
+		name := s[:end]
+		s = strings.TrimLeft(s[end:], " ")
+		if !strings.HasPrefix(s, "=") {
+			// a token that isn't followed by '=' starts a new challenge
+			challenges = append(challenges, Challenge{Scheme: name, Parameters: map[string]string{}})
+			continue
+		}
+		s = strings.TrimLeft(s[1:], " ")
+		var value string
+		value, s = parseChallengeValue(s)
+		if len(challenges) > 0 {
+			challenges[len(challenges)-1].Parameters[strings.ToLower(name)] = value
+		}
+	}
+}
+
+// parseChallengeValue returns the value at the start of s, which may be a quoted string, and the remainder of s.
+func parseChallengeValue(s string) (string, string) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, " ,")
+		if end < 0 {
+			end = len(s)
+		}
+		return s[:end], s[end:]
+	}
+	value := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				value.WriteByte(s[i])
+			}
+		case '"':
+			return value.String(), s[i+1:]
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	// unterminated quoted string
+	return value.String(), ""
+}
+
+// challengeClaims returns the decoded claims of the response's Bearer challenge, if any.
+func challengeClaims(resp *http.Response) string {
+	for _, c := range ParseChallenges(resp) {
+		if !strings.EqualFold(c.Scheme, "Bearer") || c.Parameters["claims"] == "" {
+			continue
+		}
+		encoded := c.Parameters["claims"]
+		claims, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			claims, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		}
+		if err == nil {
+			return string(claims)
+		}
+	}
+	return ""
 }
@@ -5,7 +5,11 @@ package runtime
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
 	"strings"
+	"sync"
 
 	"errors"
 	"net/http"
@@ -13,6 +17,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
 )
@@ -190,3 +195,393 @@ func TestBearerTokenWithAuxiliaryTenants(t *testing.T) {
 		t.Fatalf("unexpected auxiliary authorization header %s", auxH)
 	}
 }
+
+// transport that records the authorization header and body of each request
+type recordingTransport struct {
+	srv    *mock.Server
+	auth   []string
+	bodies []string
+}
+
+func (r *recordingTransport) Do(req *http.Request) (*http.Response, error) {
+	r.auth = append(r.auth, req.Header.Get(headerAuthorization))
+	body := ""
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+		req.Body = ioutil.NopCloser(strings.NewReader(body))
+	}
+	r.bodies = append(r.bodies, body)
+	return r.srv.Do(req)
+}
+
+func TestParseChallenges(t *testing.T) {
+	challenges := parseChallenges(`Bearer realm="", authorization_uri="https://login.microsoftonline.com/common/oauth2/authorize", error="insufficient_claims", claims="eyJhIjoxfQ==", Basic realm="a \"quoted\" realm", PoP nonce=abc`)
+	if len(challenges) != 3 {
+		t.Fatalf("unexpected challenges %v", challenges)
+	}
+	if c := challenges[0]; c.Scheme != "Bearer" || c.Parameters["realm"] != "" || c.Parameters["error"] != "insufficient_claims" || c.Parameters["claims"] != "eyJhIjoxfQ==" {
+		t.Fatalf("unexpected challenge %v", c)
+	}
+	if c := challenges[1]; c.Scheme != "Basic" || c.Parameters["realm"] != `a "quoted" realm` {
+		t.Fatalf("unexpected challenge %v", c)
+	}
+	if c := challenges[2]; c.Scheme != "PoP" || c.Parameters["nonce"] != "abc" {
+		t.Fatalf("unexpected challenge %v", c)
+	}
+}
+
+func TestBearerPolicy_ClaimsChallenge(t *testing.T) {
+	const claims = `{"access_token":{"nbf":{"essential":true,"value":"1603742800"}}}`
+	srv, close := mock.NewTLSServer()
+	defer close()
+	challenge := `Bearer realm="", error="insufficient_claims", claims="` + base64.StdEncoding.EncodeToString([]byte(claims)) + `"`
+	srv.AppendResponse(mock.WithStatusCode(http.StatusUnauthorized), mock.WithHeader(headerWWWAuthenticate, challenge))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK))
+	tokenRequests := []policy.TokenRequestOptions{}
+	cred := mockCredential{getTokenImpl: func(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+		tokenRequests = append(tokenRequests, options)
+		return &azcore.AccessToken{Token: fmt.Sprintf("token%d", len(tokenRequests)), ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}}
+	tr := &recordingTransport{srv: srv}
+	pl := NewPipeline(tr, NewBearerTokenPolicy(cred, AuthenticationOptions{TokenRequest: policy.TokenRequestOptions{Scopes: []string{scope}}}))
+	req, err := NewRequest(context.Background(), http.MethodPut, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = req.SetBody(shared.NopCloser(strings.NewReader("body")), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if len(tokenRequests) != 2 || tokenRequests[0].Claims != "" || tokenRequests[1].Claims != claims {
+		t.Fatalf("unexpected token requests %v", tokenRequests)
+	}
+	if tokenRequests[1].Scopes[0] != scope {
+		t.Fatalf("unexpected scopes %v", tokenRequests[1].Scopes)
+	}
+	if h := resp.Request.Header.Get(headerAuthorization); h != bearerTokenPrefix+"token2" {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+	if len(tr.bodies) != 2 || tr.bodies[1] != "body" {
+		t.Fatalf("unexpected bodies %q", tr.bodies)
+	}
+	// the token acquired for the challenge replaces the cached token
+	req, err = NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = pl.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	if h := resp.Request.Header.Get(headerAuthorization); h != bearerTokenPrefix+"token2" || len(tokenRequests) != 2 {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+}
+
+func TestBearerPolicy_ChallengeWithoutClaims(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusUnauthorized), mock.WithHeader(headerWWWAuthenticate, `Bearer realm="", error="invalid_token"`))
+	pl := NewPipeline(srv, NewBearerTokenPolicy(mockCredential{}, AuthenticationOptions{}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if r := srv.Requests(); r != 1 {
+		t.Fatalf("expected 1 request, got %d", r)
+	}
+}
+
+func TestBearerPolicy_AuthorizationHandler(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusUnauthorized), mock.WithHeader(headerWWWAuthenticate, `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK))
+	var tokenRequests []policy.TokenRequestOptions
+	cred := mockCredential{getTokenImpl: func(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+		tokenRequests = append(tokenRequests, options)
+		return &azcore.AccessToken{Token: options.TenantID, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}}
+	// a handler like Key Vault's, which sends the first request without a token to discover the tenant and scope
+	discovered := false
+	handler := policy.AuthorizationHandler{
+		OnRequest: func(req *policy.Request, authorize func(policy.TokenRequestOptions) error) error {
+			if !discovered {
+				return nil
+			}
+			return errors.New("unexpected call after discovery")
+		},
+		OnChallenge: func(req *policy.Request, resp *http.Response, authorize func(policy.TokenRequestOptions) error) error {
+			c := ParseChallenges(resp)[0]
+			discovered = true
+			tenant := c.Parameters["authorization"][strings.LastIndex(c.Parameters["authorization"], "/")+1:]
+			return authorize(policy.TokenRequestOptions{Scopes: []string{c.Parameters["resource"] + "/.default"}, TenantID: tenant})
+		},
+	}
+	tr := &recordingTransport{srv: srv}
+	pl := NewPipeline(tr, NewBearerTokenPolicy(cred, AuthenticationOptions{AuthorizationHandler: handler}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if h := tr.auth[0]; h != "" {
+		t.Fatalf("unexpected Authorization header on first request: %s", h)
+	}
+	if h := resp.Request.Header.Get(headerAuthorization); h != bearerTokenPrefix+"tenant" {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+	if len(tokenRequests) != 1 || tokenRequests[0].Scopes[0] != "https://vault.azure.net/.default" {
+		t.Fatalf("unexpected token requests %v", tokenRequests)
+	}
+}
+
+func TestBearerPolicy_AuthorizationHandlerCachesTokens(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	var tokenRequests []policy.TokenRequestOptions
+	cred := mockCredential{getTokenImpl: func(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+		tokenRequests = append(tokenRequests, options)
+		return &azcore.AccessToken{Token: options.TenantID + options.Scopes[0], ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}}
+	// a handler requesting tokens with the scope and tenant of each request's host
+	handler := policy.AuthorizationHandler{
+		OnRequest: func(req *policy.Request, authorize func(policy.TokenRequestOptions) error) error {
+			return authorize(policy.TokenRequestOptions{Scopes: []string{req.Raw().URL.Path[1:]}, TenantID: "tenant"})
+		},
+	}
+	pl := NewPipeline(srv, NewBearerTokenPolicy(cred, AuthenticationOptions{AuthorizationHandler: handler}))
+	for _, path := range []string{"a", "b", "a", "b"} {
+		srv.AppendResponse(mock.WithStatusCode(http.StatusOK))
+		req, err := NewRequest(context.Background(), http.MethodGet, srv.URL()+"/"+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := pl.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := resp.Request.Header.Get(headerAuthorization); h != bearerTokenPrefix+"tenant"+path {
+			t.Fatalf("unexpected Authorization header %s", h)
+		}
+	}
+	if len(tokenRequests) != 2 {
+		t.Fatalf("expected a token request for each scope, got %v", tokenRequests)
+	}
+}
+
+func TestBearerPolicy_ClaimsChallengeAuxiliaryTenants(t *testing.T) {
+	const claims = `{"access_token":{"nbf":{"essential":true,"value":"1603742800"}}}`
+	srv, close := mock.NewTLSServer()
+	defer close()
+	challenge := `Bearer realm="", error="insufficient_claims", claims="` + base64.StdEncoding.EncodeToString([]byte(claims)) + `"`
+	srv.AppendResponse(mock.WithStatusCode(http.StatusUnauthorized), mock.WithHeader(headerWWWAuthenticate, challenge))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK))
+	cred := mockCredential{getTokenImpl: func(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+		tk := options.TenantID
+		if options.Claims != "" {
+			tk += "-claims"
+		}
+		return &azcore.AccessToken{Token: tk, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}}
+	pl := NewPipeline(srv, NewBearerTokenPolicy(cred, AuthenticationOptions{
+		TokenRequest:     policy.TokenRequestOptions{Scopes: []string{scope}},
+		AuxiliaryTenants: []string{"aux"},
+	}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if h := resp.Request.Header.Get(headerAuxiliaryAuthorization); h != bearerTokenPrefix+"aux-claims" {
+		t.Fatalf("unexpected auxiliary authorization header %s", h)
+	}
+}
+
+// credential returning tokens with the specified lifetime, numbered in order of acquisition
+type sequentialCredential struct {
+	mu       sync.Mutex
+	lifetime time.Duration
+	calls    int
+	fail     func(call int) bool
+	// hang returns true for calls which don't return until their context is done
+	hang func(call int) bool
+}
+
+func (c *sequentialCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.hang != nil && c.hang(c.calls) {
+		c.mu.Unlock()
+		<-ctx.Done()
+		c.mu.Lock()
+		return nil, ctx.Err()
+	}
+	if c.fail != nil && c.fail(c.calls) {
+		return nil, errors.New("refresh failed")
+	}
+	return &azcore.AccessToken{Token: fmt.Sprintf("token%d", c.calls), ExpiresOn: time.Now().Add(c.lifetime)}, nil
+}
+
+func (c *sequentialCredential) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func authorizeTestRequest(t *testing.T, srv *mock.Server, pl Pipeline) string {
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Request.Header.Get(headerAuthorization)
+}
+
+func TestBearerPolicy_BackgroundRefresh(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	cred := &sequentialCredential{lifetime: time.Second}
+	pl := NewPipeline(srv, NewBearerTokenPolicy(cred, AuthenticationOptions{
+		BackgroundRefresh: &TokenRefreshOptions{Window: 800 * time.Millisecond, Jitter: -1},
+	}))
+	if h := authorizeTestRequest(t, srv, pl); h != bearerTokenPrefix+"token1" {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+	// the token should be refreshed ~200ms later, before any request needs it
+	time.Sleep(400 * time.Millisecond)
+	if c := cred.count(); c != 2 {
+		t.Fatalf("expected 2 token requests, got %d", c)
+	}
+	if h := authorizeTestRequest(t, srv, pl); h != bearerTokenPrefix+"token2" {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+	if c := cred.count(); c != 2 {
+		t.Fatalf("expected 2 token requests, got %d", c)
+	}
+}
+
+func TestBearerPolicy_BackgroundRefreshStopsWhenIdle(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	cred := &sequentialCredential{lifetime: 300 * time.Millisecond}
+	pl := NewPipeline(srv, NewBearerTokenPolicy(cred, AuthenticationOptions{
+		BackgroundRefresh: &TokenRefreshOptions{Window: 200 * time.Millisecond, Jitter: -1},
+	}))
+	authorizeTestRequest(t, srv, pl)
+	// one refresh because the token was used, then none because it wasn't
+	time.Sleep(600 * time.Millisecond)
+	if c := cred.count(); c != 2 {
+		t.Fatalf("expected 2 token requests, got %d", c)
+	}
+	// the token expired while idle, so the next request acquires one
+	if h := authorizeTestRequest(t, srv, pl); h != bearerTokenPrefix+"token3" {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+}
+
+func TestBearerPolicy_BackgroundRefreshFails(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	cred := &sequentialCredential{lifetime: time.Second, fail: func(call int) bool { return call == 2 }}
+	errs := make(chan error, 1)
+	pl := NewPipeline(srv, NewBearerTokenPolicy(cred, AuthenticationOptions{
+		BackgroundRefresh: &TokenRefreshOptions{
+			Window:  800 * time.Millisecond,
+			Jitter:  -1,
+			OnError: func(err error) { errs <- err },
+		},
+	}))
+	authorizeTestRequest(t, srv, pl)
+	select {
+	case err := <-errs:
+		if err.Error() != "refresh failed" {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a refresh error")
+	}
+	// the cached token is still valid, so the request uses it without acquiring a new one
+	if h := authorizeTestRequest(t, srv, pl); h != bearerTokenPrefix+"token1" {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+	if c := cred.count(); c != 2 {
+		t.Fatalf("expected 2 token requests, got %d", c)
+	}
+	// the refresh is retried before the token expires
+	time.Sleep(600 * time.Millisecond)
+	if h := authorizeTestRequest(t, srv, pl); h != bearerTokenPrefix+"token3" {
+		t.Fatalf("unexpected Authorization header %s", h)
+	}
+}
+
+func TestBearerPolicy_BackgroundRefreshHangs(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	cred := &sequentialCredential{lifetime: 300 * time.Millisecond, hang: func(call int) bool { return call == 2 }}
+	errs := make(chan error, 1)
+	pl := NewPipeline(srv, NewBearerTokenPolicy(cred, AuthenticationOptions{
+		BackgroundRefresh: &TokenRefreshOptions{
+			Window:  200 * time.Millisecond,
+			Jitter:  -1,
+			OnError: func(err error) { errs <- err },
+		},
+	}))
+	authorizeTestRequest(t, srv, pl)
+	// the refresh starts ~100ms later and hangs; it's canceled when the token expires
+	time.Sleep(400 * time.Millisecond)
+	done := make(chan string, 1)
+	go func() {
+		done <- authorizeTestRequest(t, srv, pl)
+	}()
+	select {
+	case h := <-done:
+		if h != bearerTokenPrefix+"token3" {
+			t.Fatalf("unexpected Authorization header %s", h)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request waited for the hung refresh")
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error %v", err)
+		}
+	default:
+		t.Fatal("expected a refresh error")
+	}
+}
@@ -0,0 +1,167 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
+)
+
+func setCircuitBreakerDefaults(o *policy.CircuitBreakerOptions) {
+	if o.FailureRatio <= 0 {
+		o.FailureRatio = 0.5
+	}
+	if o.MinimumTries <= 0 {
+		o.MinimumTries = 10
+	}
+	if o.Window <= 0 {
+		o.Window = 30 * time.Second
+	}
+	if o.Cooldown <= 0 {
+		o.Cooldown = 30 * time.Second
+	}
+	if o.IsFailure == nil {
+		o.IsFailure = func(resp *http.Response, err error) bool {
+			if err != nil {
+				return true
+			}
+			return resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+}
+
+// NewCircuitBreakerPolicy creates a policy object that stops sending requests to a host once its failure rate exceeds
+// the configured threshold.  Requests to a host with an open circuit fail with an *azcore.CircuitOpenError.
+// Place this policy after the retry policy so that each try is counted and the retry policy stops once the circuit opens.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+func NewCircuitBreakerPolicy(o *policy.CircuitBreakerOptions) policy.Policy {
+	if o == nil {
+		o = &policy.CircuitBreakerOptions{}
+	}
+	p := &circuitBreakerPolicy{options: *o, circuits: map[string]*circuit{}}
+	setCircuitBreakerDefaults(&p.options)
+	return p
+}
+
+type circuitBreakerPolicy struct {
+	options policy.CircuitBreakerOptions
+
+	// mu protects circuits and their contents
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit contains the state for a single host
+type circuit struct {
+	state    policy.CircuitState
+	openedAt time.Time
+
+	// probing indicates that a half-open probe request is in progress
+	probing bool
+
+	// counters for the current window
+	windowStart time.Time
+	tries       int
+	failures    int
+}
+
+type circuitTransition struct {
+	from, to policy.CircuitState
+}
+
+// setState changes the circuit's state, returning the transition.
+// must be called with the lock held.
+func (c *circuit) setState(to policy.CircuitState, now time.Time) circuitTransition {
+	t := circuitTransition{from: c.state, to: to}
+	c.state = to
+	switch to {
+	case policy.CircuitStateOpen:
+		c.openedAt = now
+	case policy.CircuitStateClosed:
+		c.windowStart, c.tries, c.failures = now, 0, 0
+	}
+	return t
+}
+
+func (p *circuitBreakerPolicy) Do(req *policy.Request) (*http.Response, error) {
+	host := req.Raw().URL.Host
+	transitions := []circuitTransition{}
+	now := time.Now()
+	p.mu.Lock()
+	c, ok := p.circuits[host]
+	if !ok {
+		c = &circuit{windowStart: now}
+		p.circuits[host] = c
+	}
+	if c.state == policy.CircuitStateOpen {
+		if remaining := c.openedAt.Add(p.options.Cooldown).Sub(now); remaining > 0 {
+			p.mu.Unlock()
+			return nil, &azcore.CircuitOpenError{Host: host, RetryAfter: remaining}
+		}
+		transitions = append(transitions, c.setState(policy.CircuitStateHalfOpen, now))
+	}
+	probe := false
+	if c.state == policy.CircuitStateHalfOpen {
+		if c.probing {
+			p.mu.Unlock()
+			p.notify(host, transitions)
+			return nil, &azcore.CircuitOpenError{Host: host}
+		}
+		c.probing, probe = true, true
+	}
+	p.mu.Unlock()
+	p.notify(host, transitions)
+
+	resp, err := req.Next()
+	// the caller cancelling the request says nothing about the health of the host
+	inconclusive := errors.Is(err, context.Canceled)
+	failed := !inconclusive && p.options.IsFailure(resp, err)
+
+	transitions = transitions[:0]
+	now = time.Now()
+	p.mu.Lock()
+	if probe {
+		c.probing = false
+		if inconclusive {
+			// stay half-open so that the next request probes the host
+		} else if failed {
+			transitions = append(transitions, c.setState(policy.CircuitStateOpen, now))
+		} else {
+			transitions = append(transitions, c.setState(policy.CircuitStateClosed, now))
+		}
+	} else if c.state == policy.CircuitStateClosed && !inconclusive {
+		if now.Sub(c.windowStart) > p.options.Window {
+			c.windowStart, c.tries, c.failures = now, 0, 0
+		}
+		c.tries++
+		if failed {
+			c.failures++
+		}
+		if c.tries >= p.options.MinimumTries && float64(c.failures)/float64(c.tries) >= p.options.FailureRatio {
+			transitions = append(transitions, c.setState(policy.CircuitStateOpen, now))
+		}
+	}
+	p.mu.Unlock()
+	p.notify(host, transitions)
+	return resp, err
+}
+
+func (p *circuitBreakerPolicy) notify(host string, transitions []circuitTransition) {
+	for _, t := range transitions {
+		log.Writef(log.RetryPolicy, "circuit for host %s changed from %s to %s", host, t.from, t.to)
+		if p.options.OnStateChange != nil {
+			p.options.OnStateChange(host, t.from, t.to)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+type stateChange struct {
+	host     string
+	from, to policy.CircuitState
+}
+
+func TestCircuitBreakerPolicy(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.RepeatResponse(2, mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendResponse()
+	changes := []stateChange{}
+	pl := NewPipeline(srv, NewCircuitBreakerPolicy(&policy.CircuitBreakerOptions{
+		MinimumTries: 2,
+		Cooldown:     50 * time.Millisecond,
+		OnStateChange: func(host string, from, to policy.CircuitState) {
+			changes = append(changes, stateChange{host: host, from: from, to: to})
+		},
+	}))
+	send := func() (*http.Response, error) {
+		req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pl.Do(req)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := send()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected status code %d", resp.StatusCode)
+		}
+	}
+	// circuit is now open
+	_, err := send()
+	var coe *azcore.CircuitOpenError
+	if !errors.As(err, &coe) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if coe.RetryAfter <= 0 {
+		t.Fatalf("unexpected RetryAfter %v", coe.RetryAfter)
+	}
+	if r := srv.Requests(); r != 2 {
+		t.Fatalf("unexpected request count %d", r)
+	}
+	time.Sleep(coe.RetryAfter)
+	// the failed probe reopens the circuit
+	if _, err = send(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = send(); !errors.As(err, &coe) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	time.Sleep(coe.RetryAfter)
+	// the successful probe closes the circuit
+	resp, err := send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	u, err := url.Parse(srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []stateChange{
+		{host: u.Host, from: policy.CircuitStateClosed, to: policy.CircuitStateOpen},
+		{host: u.Host, from: policy.CircuitStateOpen, to: policy.CircuitStateHalfOpen},
+		{host: u.Host, from: policy.CircuitStateHalfOpen, to: policy.CircuitStateOpen},
+		{host: u.Host, from: policy.CircuitStateOpen, to: policy.CircuitStateHalfOpen},
+		{host: u.Host, from: policy.CircuitStateHalfOpen, to: policy.CircuitStateClosed},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("unexpected state changes %v", changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("unexpected state change %v, expected %v", changes[i], expected[i])
+		}
+	}
+}
+
+func TestCircuitBreakerPolicyStopsRetries(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithStatusCode(http.StatusInternalServerError))
+	pl := NewPipeline(srv, NewRetryPolicy(testRetryOptions()), NewCircuitBreakerPolicy(&policy.CircuitBreakerOptions{MinimumTries: 2}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pl.Do(req)
+	var coe *azcore.CircuitOpenError
+	if !errors.As(err, &coe) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if r := srv.Requests(); r != 2 {
+		t.Fatalf("unexpected request count %d", r)
+	}
+}
+
+func TestCircuitBreakerPolicyIgnoresCanceled(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetError(context.Canceled)
+	pl := NewPipeline(srv, NewCircuitBreakerPolicy(&policy.CircuitBreakerOptions{MinimumTries: 1}))
+	for i := 0; i < 3; i++ {
+		req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = pl.Do(req); !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+}
+
+func TestCircuitBreakerPolicyCanceledProbe(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendError(context.Canceled)
+	srv.AppendResponse()
+	changes := []stateChange{}
+	pl := NewPipeline(srv, NewCircuitBreakerPolicy(&policy.CircuitBreakerOptions{
+		MinimumTries: 1,
+		Cooldown:     10 * time.Millisecond,
+		OnStateChange: func(host string, from, to policy.CircuitState) {
+			changes = append(changes, stateChange{host: host, from: from, to: to})
+		},
+	}))
+	send := func() error {
+		req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pl.Do(req)
+		return err
+	}
+	if err := send(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	// the canceled probe doesn't show the host is healthy, so the circuit stays half-open
+	if err := send(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if l := len(changes); l != 2 || changes[1].to != policy.CircuitStateHalfOpen {
+		t.Fatalf("unexpected state changes %v", changes)
+	}
+	// the next request probes the host and closes the circuit
+	if err := send(); err != nil {
+		t.Fatal(err)
+	}
+	if l := len(changes); l != 3 || changes[2].from != policy.CircuitStateHalfOpen || changes[2].to != policy.CircuitStateClosed {
+		t.Fatalf("unexpected state changes %v", changes)
+	}
+}
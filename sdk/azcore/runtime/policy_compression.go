@@ -0,0 +1,199 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+type compressionPolicy struct {
+	options policy.CompressionOptions
+}
+
+// NewCompressionPolicy creates a policy object that compresses request bodies and decompresses responses.
+// Place this policy before the retry policy so that bodies are compressed once per operation.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+// Request bodies are compressed into memory, so a streaming.NewRequestProgress callback reports the
+// uncompressed bytes as they're compressed rather than as they're sent.
+func NewCompressionPolicy(o *policy.CompressionOptions) policy.Policy {
+	cp := policy.CompressionOptions{}
+	if o != nil {
+		cp = *o
+	}
+	cp.RequestEncoding = strings.ToLower(cp.RequestEncoding)
+	if cp.MinRequestBodySize == 0 {
+		cp.MinRequestBodySize = 1024
+	}
+	return &compressionPolicy{options: cp}
+}
+
+func (p *compressionPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if p.options.RequestEncoding != "" && req.Body() != nil && req.Raw().Header.Get(shared.HeaderContentEncoding) == "" &&
+		req.Raw().ContentLength >= p.options.MinRequestBodySize {
+		if err := compressRequestBody(req, p.options.RequestEncoding); err != nil {
+			return nil, err
+		}
+	}
+	// don't decompress responses when the caller negotiated its own encoding
+	decompress := !p.options.DisableResponseDecompression && req.Raw().Header.Get(shared.HeaderAcceptEncoding) == ""
+	if decompress {
+		req.Raw().Header.Set(shared.HeaderAcceptEncoding, encodingGzip+", "+encodingDeflate)
+	}
+	resp, err := req.Next()
+	if err != nil || !decompress {
+		return resp, err
+	}
+	if err = decompressResponseBody(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// compressRequestBody replaces the request's body with its compressed form.  The original body
+// is kept when compression doesn't make it smaller.
+func compressRequestBody(req *policy.Request, encoding string) error {
+	body := req.Body()
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch encoding {
+	case encodingGzip:
+		w = gzip.NewWriter(buf)
+	case encodingDeflate:
+		w = zlib.NewWriter(buf)
+	default:
+		return fmt.Errorf("unsupported request encoding %q", encoding)
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if int64(buf.Len()) >= req.Raw().ContentLength {
+		_, err := body.Seek(0, io.SeekStart)
+		return err
+	}
+	// the compressed body replaces the original, which won't be read again
+	body.Close()
+	if err := req.SetBody(shared.NopCloser(bytes.NewReader(buf.Bytes())), req.Raw().Header.Get(shared.HeaderContentType)); err != nil {
+		return err
+	}
+	req.Raw().Header.Set(shared.HeaderContentEncoding, encoding)
+	return nil
+}
+
+// decompressResponseBody replaces a compressed response body with its decompressed form.
+// A body already downloaded by the pipeline is decompressed in memory; otherwise it's decompressed as it's read.
+func decompressResponseBody(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get(shared.HeaderContentEncoding)))
+	if encoding != encodingGzip && encoding != encodingDeflate || !hasResponseBody(resp) {
+		return nil
+	}
+	if buf, ok := resp.Body.(*nopClosingBytesReader); ok {
+		if len(buf.Bytes()) == 0 {
+			return nil
+		}
+		r, err := newDecompressor(encoding, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			return fmt.Errorf("decompressing response body: %w", err)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("decompressing response body: %w", err)
+		}
+		resp.Body = &nopClosingBytesReader{s: b}
+		resp.ContentLength = int64(len(b))
+	} else {
+		// the decompressor reads the body's header, so it's created on the first Read rather than here
+		resp.Body = &decompressingBody{encoding: encoding, body: resp.Body}
+		resp.ContentLength = -1
+	}
+	resp.Header.Del(shared.HeaderContentEncoding)
+	resp.Header.Del(shared.HeaderContentLength)
+	resp.Uncompressed = true
+	return nil
+}
+
+// hasResponseBody returns false when resp can't have a body, such as the response to a HEAD request.
+// A Content-Encoding header on such a response describes the representation, not an empty body.
+func hasResponseBody(resp *http.Response) bool {
+	if resp.Body == nil || resp.Body == http.NoBody || resp.ContentLength == 0 {
+		return false
+	}
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return false
+	}
+	return resp.Request == nil || resp.Request.Method != http.MethodHead
+}
+
+// newDecompressor returns a reader decompressing r.  A "deflate" body should be in zlib format
+// but some servers send raw deflate data, so both are accepted.
+func newDecompressor(encoding string, r io.Reader) (io.ReadCloser, error) {
+	if encoding == encodingGzip {
+		return gzip.NewReader(r)
+	}
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	// a zlib header's compression method is 8 and its first two bytes are a multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decompressingBody decompresses the original body as it's read, and closes both the
+// decompressor and the original body.
+type decompressingBody struct {
+	encoding string
+	r        io.ReadCloser
+	err      error
+	body     io.ReadCloser
+}
+
+func (d *decompressingBody) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		d.r, d.err = newDecompressor(d.encoding, d.body)
+		if d.err != nil && d.err != io.EOF {
+			d.err = fmt.Errorf("decompressing response body: %w", d.err)
+		}
+	}
+	if d.err != nil {
+		// an empty body has nothing to decompress
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
+
+func (d *decompressingBody) Close() error {
+	if d.r != nil {
+		d.r.Close()
+	}
+	return d.body.Close()
+}
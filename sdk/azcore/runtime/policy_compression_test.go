@@ -0,0 +1,256 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+)
+
+func compressTestData(t *testing.T, encoding string, raw bool, data string) []byte {
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	var err error
+	switch {
+	case encoding == encodingGzip:
+		w = gzip.NewWriter(buf)
+	case raw:
+		w, err = flate.NewWriter(buf, flate.DefaultCompression)
+	default:
+		w = zlib.NewWriter(buf)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressionPolicyRequest(t *testing.T) {
+	payload := strings.Repeat(`{"name": "value"}`, 200)
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		var bodies []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ce := r.Header.Get(shared.HeaderContentEncoding); ce != encoding {
+				t.Errorf("unexpected Content-Encoding %q", ce)
+			}
+			if ct := r.Header.Get(shared.HeaderContentType); ct != shared.ContentTypeAppJSON {
+				t.Errorf("unexpected Content-Type %q", ct)
+			}
+			rc, err := newDecompressor(encoding, r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bodies = append(bodies, string(b))
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		var progress int64
+		pl := NewPipeline(srv.Client(), NewCompressionPolicy(&policy.CompressionOptions{RequestEncoding: encoding}), NewRetryPolicy(testRetryOptions()))
+		req, err := NewRequest(context.Background(), http.MethodPut, srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := streaming.NewRequestProgress(streaming.NopCloser(strings.NewReader(payload)), func(n int64) { progress = n })
+		if err = req.SetBody(body, shared.ContentTypeAppJSON); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := pl.Do(req)
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status code %d", resp.StatusCode)
+		}
+		// the retry sends the same compressed body
+		if len(bodies) != 2 || bodies[0] != payload || bodies[1] != payload {
+			t.Fatalf("unexpected bodies for %s", encoding)
+		}
+		if progress != int64(len(payload)) {
+			t.Fatalf("unexpected progress %d", progress)
+		}
+	}
+}
+
+func TestCompressionPolicySmallRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ce := r.Header.Get(shared.HeaderContentEncoding); ce != "" {
+			t.Errorf("unexpected Content-Encoding %q", ce)
+		}
+		if b, _ := ioutil.ReadAll(r.Body); string(b) != "small" {
+			t.Errorf("unexpected body %q", b)
+		}
+	}))
+	defer srv.Close()
+	pl := NewPipeline(srv.Client(), NewCompressionPolicy(&policy.CompressionOptions{RequestEncoding: encodingGzip}))
+	req, err := NewRequest(context.Background(), http.MethodPut, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = req.SetBody(streaming.NopCloser(strings.NewReader("small")), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pl.Do(req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompressionPolicyResponse(t *testing.T) {
+	const payload = `{"value": "decompressed"}`
+	for _, test := range []struct {
+		encoding string
+		raw      bool
+		skip     bool
+	}{
+		{encodingGzip, false, false},
+		{encodingDeflate, false, false},
+		{encodingDeflate, true, false},
+		{encodingGzip, false, true},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ae := r.Header.Get(shared.HeaderAcceptEncoding); ae != "gzip, deflate" {
+				t.Errorf("unexpected Accept-Encoding %q", ae)
+			}
+			w.Header().Set(shared.HeaderContentEncoding, test.encoding)
+			_, _ = w.Write(compressTestData(t, test.encoding, test.raw, payload))
+		}))
+		pl := NewPipeline(srv.Client(), NewCompressionPolicy(nil))
+		req, err := NewRequest(context.Background(), http.MethodGet, srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.skip {
+			req.SkipBodyDownload()
+		}
+		resp, err := pl.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Payload(resp)
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != payload {
+			t.Fatalf("unexpected body %q", b)
+		}
+		if resp.Header.Get(shared.HeaderContentEncoding) != "" || !resp.Uncompressed {
+			t.Fatal("expected response to be marked uncompressed")
+		}
+	}
+}
+
+func TestCompressionPolicyEmptyResponse(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		method string
+		status int
+		skip   bool
+	}{
+		{"HEAD", http.MethodHead, http.StatusOK, false},
+		{"HEAD streaming", http.MethodHead, http.StatusOK, true},
+		{"204 streaming", http.MethodGet, http.StatusNoContent, true},
+		{"304 streaming", http.MethodGet, http.StatusNotModified, true},
+		{"empty streaming", http.MethodGet, http.StatusOK, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(shared.HeaderContentEncoding, encodingGzip)
+				w.WriteHeader(test.status)
+			}))
+			defer srv.Close()
+			pl := NewPipeline(srv.Client(), NewCompressionPolicy(nil))
+			req, err := NewRequest(context.Background(), test.method, srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.skip {
+				req.SkipBodyDownload()
+			}
+			resp, err := pl.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != test.status {
+				t.Fatalf("unexpected status code %d", resp.StatusCode)
+			}
+			if b, err := Payload(resp); err != nil || len(b) != 0 {
+				t.Fatalf("unexpected body %q, error %v", b, err)
+			}
+		})
+	}
+}
+
+func TestCompressionPolicyStreamingChunkedEmptyResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(shared.HeaderContentEncoding, encodingDeflate)
+		// flushing before writing sends a chunked body of unknown length
+		w.(http.Flusher).Flush()
+	}))
+	defer srv.Close()
+	pl := NewPipeline(srv.Client(), NewCompressionPolicy(nil))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SkipBodyDownload()
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if b, err := ioutil.ReadAll(resp.Body); err != nil || len(b) != 0 {
+		t.Fatalf("unexpected body %q, error %v", b, err)
+	}
+}
+
+func TestCompressionPolicyCallerAcceptEncoding(t *testing.T) {
+	compressed := compressTestData(t, encodingGzip, false, "data")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(shared.HeaderContentEncoding, encodingGzip)
+		_, _ = w.Write(compressed)
+	}))
+	defer srv.Close()
+	pl := NewPipeline(srv.Client(), NewCompressionPolicy(nil))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Raw().Header.Set(shared.HeaderAcceptEncoding, encodingGzip)
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the caller negotiated the encoding so the body is returned as is
+	if b, err := Payload(resp); err != nil || !bytes.Equal(b, compressed) {
+		t.Fatalf("unexpected body %q", b)
+	}
+}
@@ -0,0 +1,286 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
+)
+
+// FaultInjectionEnvVar is the environment variable read by FaultInjectionOptionsFromEnvironment.
+const FaultInjectionEnvVar = "AZURE_SDK_FAULT_INJECTION"
+
+// FaultInjectedError is the transport error returned for faults of kind FaultKindTransportError and
+// FaultKindTruncatedBody.  It implements net.Error and is temporary, like the errors it simulates.
+type FaultInjectedError struct {
+	msg string
+}
+
+// Error implements the error interface for type FaultInjectedError.
+func (e *FaultInjectedError) Error() string {
+	return e.msg
+}
+
+// Timeout returns false; it implements net.Error.
+func (*FaultInjectedError) Timeout() bool {
+	return false
+}
+
+// Temporary returns true; it implements net.Error.
+func (*FaultInjectedError) Temporary() bool {
+	return true
+}
+
+type faultInjectionPolicy struct {
+	rules []policy.FaultRule
+
+	mu sync.Mutex
+	// injected counts the faults injected by each rule
+	injected []int
+}
+
+// NewFaultInjectionPolicy creates a policy object that injects faults into requests, for testing an
+// application's resilience without a misbehaving service.  Add it to PerRetryPolicies so that each
+// try can be faulted and the retry policy's handling of the faults is exercised.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+// A policy without rules is a no-op.
+func NewFaultInjectionPolicy(o *policy.FaultInjectionOptions) policy.Policy {
+	if o == nil {
+		o = &policy.FaultInjectionOptions{}
+	}
+	rules := make([]policy.FaultRule, len(o.Rules))
+	copy(rules, o.Rules)
+	return &faultInjectionPolicy{rules: rules, injected: make([]int, len(rules))}
+}
+
+func (p *faultInjectionPolicy) Do(req *policy.Request) (*http.Response, error) {
+	rule, ok := p.match(req.Raw())
+	if !ok {
+		return req.Next()
+	}
+	log.Writef(log.Request, "fault injection: injecting %s into %s %s", rule.Kind, req.Raw().Method, req.Raw().URL.Redacted())
+	switch rule.Kind {
+	case policy.FaultKindTransportError:
+		return nil, &FaultInjectedError{msg: "fault injection: connection reset"}
+	case policy.FaultKindDelay:
+		d := rule.Delay
+		if d == 0 {
+			d = time.Second
+		}
+		select {
+		case <-time.After(d):
+		case <-req.Raw().Context().Done():
+			return nil, req.Raw().Context().Err()
+		}
+		return req.Next()
+	case policy.FaultKindTruncatedBody:
+		resp, err := req.Next()
+		if err != nil {
+			return resp, err
+		}
+		return injectTruncatedBody(req, resp, rule.TruncateAfter)
+	case policy.FaultKindStatusCode:
+		code := rule.StatusCode
+		if code == 0 {
+			code = http.StatusServiceUnavailable
+		}
+		return faultResponse(req, code, http.Header{}), nil
+	case policy.FaultKindThrottle:
+		code := rule.StatusCode
+		if code == 0 {
+			code = http.StatusTooManyRequests
+		}
+		retryAfter := rule.RetryAfter
+		if retryAfter == 0 {
+			retryAfter = time.Second
+		}
+		header := http.Header{}
+		header.Set(shared.HeaderRetryAfter, strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+		header.Set(shared.HeaderRetryAfterMS, strconv.FormatInt(retryAfter.Milliseconds(), 10))
+		return faultResponse(req, code, header), nil
+	}
+	return nil, fmt.Errorf("fault injection: unknown fault kind %q", rule.Kind)
+}
+
+// match returns the first rule matching the request that should inject its fault.
+func (p *faultInjectionPolicy) match(req *http.Request) (policy.FaultRule, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, rule := range p.rules {
+		if rule.Host != "" && !strings.EqualFold(rule.Host, req.URL.Host) {
+			continue
+		}
+		if rule.Method != "" && !strings.EqualFold(rule.Method, req.Method) {
+			continue
+		}
+		if !strings.HasPrefix(req.URL.Path, rule.PathPrefix) {
+			continue
+		}
+		if rule.Count > 0 && p.injected[i] >= rule.Count {
+			continue
+		}
+		if rule.Probability > 0 && rule.Probability < 1 && rand.Float64() >= rule.Probability {
+			continue
+		}
+		p.injected[i]++
+		return rule, true
+	}
+	return policy.FaultRule{}, false
+}
+
+func faultResponse(req *policy.Request, statusCode int, header http.Header) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode: statusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       &nopClosingBytesReader{},
+		Request:    req.Raw(),
+	}
+}
+
+// injectTruncatedBody fails the response's body after n bytes.  A body the pipeline already downloaded fails
+// like its download did; other bodies fail when the caller reads past n bytes.  Bodies no longer than n bytes
+// aren't truncated, so they don't fail.
+func injectTruncatedBody(req *policy.Request, resp *http.Response, n int64) (*http.Response, error) {
+	err := &FaultInjectedError{msg: "fault injection: unexpected EOF reading response body"}
+	if body, ok := resp.Body.(*nopClosingBytesReader); ok {
+		if int64(len(body.Bytes())) <= n {
+			return resp, nil
+		}
+		body.Set(body.Bytes()[:n])
+		return resp, newBodyDownloadError(err, req)
+	}
+	resp.Body = &truncatedBody{body: resp.Body, remaining: n, err: err}
+	return resp, nil
+}
+
+// truncatedBody returns the first bytes of body, then fails with err if body has more
+type truncatedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+	failed    bool
+}
+
+func (t *truncatedBody) Read(p []byte) (int, error) {
+	if t.failed {
+		return 0, t.err
+	}
+	if t.remaining <= 0 {
+		// the body is truncated only if it continues past the limit
+		var b [1]byte
+		n, err := io.ReadFull(t.body, b[:])
+		if n > 0 {
+			t.failed = true
+			return 0, t.err
+		}
+		return 0, err
+	}
+	if int64(len(p)) > t.remaining {
+		p = p[:t.remaining]
+	}
+	n, err := t.body.Read(p)
+	t.remaining -= int64(n)
+	return n, err
+}
+
+func (t *truncatedBody) Close() error {
+	return t.body.Close()
+}
+
+type faultRuleJSON struct {
+	Kind          policy.FaultKind `json:"kind"`
+	Host          string           `json:"host"`
+	Method        string           `json:"method"`
+	PathPrefix    string           `json:"pathPrefix"`
+	Probability   float64          `json:"probability"`
+	Count         int              `json:"count"`
+	Delay         string           `json:"delay"`
+	StatusCode    int              `json:"statusCode"`
+	RetryAfter    string           `json:"retryAfter"`
+	TruncateAfter int64            `json:"truncateAfter"`
+}
+
+// ParseFaultRules parses fault rules from a JSON array, e.g.
+//
+//	[{"kind": "throttle", "host": "management.azure.com", "probability": 0.1, "retryAfter": "2s"}]
+//
+// Durations are in the format accepted by time.ParseDuration.
+func ParseFaultRules(data []byte) ([]policy.FaultRule, error) {
+	var parsed []faultRuleJSON
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("parsing fault rules: %w", err)
+	}
+	rules := make([]policy.FaultRule, 0, len(parsed))
+	for _, r := range parsed {
+		rule := policy.FaultRule{
+			Kind:          r.Kind,
+			Host:          r.Host,
+			Method:        r.Method,
+			PathPrefix:    r.PathPrefix,
+			Probability:   r.Probability,
+			Count:         r.Count,
+			StatusCode:    r.StatusCode,
+			TruncateAfter: r.TruncateAfter,
+		}
+		switch r.Kind {
+		case policy.FaultKindTransportError, policy.FaultKindDelay, policy.FaultKindTruncatedBody, policy.FaultKindStatusCode, policy.FaultKindThrottle:
+		default:
+			return nil, fmt.Errorf("parsing fault rules: unknown fault kind %q", r.Kind)
+		}
+		var err error
+		if r.Delay != "" {
+			if rule.Delay, err = time.ParseDuration(r.Delay); err != nil {
+				return nil, fmt.Errorf("parsing fault rules: %w", err)
+			}
+		}
+		if r.RetryAfter != "" {
+			if rule.RetryAfter, err = time.ParseDuration(r.RetryAfter); err != nil {
+				return nil, fmt.Errorf("parsing fault rules: %w", err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// FaultInjectionOptionsFromEnvironment returns options containing the fault rules in the
+// AZURE_SDK_FAULT_INJECTION environment variable, in the format accepted by ParseFaultRules.
+// It returns nil options when the variable isn't set, so faults can be enabled per environment:
+//
+//	if o, err := runtime.FaultInjectionOptionsFromEnvironment(); err == nil && o != nil {
+//	    options.PerRetryPolicies = append(options.PerRetryPolicies, runtime.NewFaultInjectionPolicy(o))
+//	}
+func FaultInjectionOptionsFromEnvironment() (*policy.FaultInjectionOptions, error) {
+	v := os.Getenv(FaultInjectionEnvVar)
+	if v == "" {
+		return nil, nil
+	}
+	rules, err := ParseFaultRules([]byte(v))
+	if err != nil {
+		return nil, err
+	}
+	return &policy.FaultInjectionOptions{Rules: rules}, nil
+}
@@ -0,0 +1,256 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+func newFaultTestPipeline(srv *mock.Server, rules ...policy.FaultRule) Pipeline {
+	return NewPipeline(srv, NewRetryPolicy(testRetryOptions()), NewFaultInjectionPolicy(&policy.FaultInjectionOptions{Rules: rules}))
+}
+
+func TestFaultInjectionNoRules(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse()
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewPipeline(srv, NewFaultInjectionPolicy(nil)).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestFaultInjectionTransportErrorRetried(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse()
+	pl := newFaultTestPipeline(srv, policy.FaultRule{Kind: policy.FaultKindTransportError, Count: 2})
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	// the two faulted tries never reached the server
+	if r := srv.Requests(); r != 1 {
+		t.Fatalf("expected 1 request, got %d", r)
+	}
+}
+
+func TestFaultInjectionThrottle(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse()
+	pl := newFaultTestPipeline(srv, policy.FaultRule{Kind: policy.FaultKindThrottle, Count: 1, RetryAfter: 100 * time.Millisecond})
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Fatalf("retry didn't honor the injected delay: %s", d)
+	}
+}
+
+func TestFaultInjectionMatching(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse()
+	pl := NewPipeline(srv, NewFaultInjectionPolicy(&policy.FaultInjectionOptions{Rules: []policy.FaultRule{
+		{Kind: policy.FaultKindStatusCode, Method: http.MethodPut, StatusCode: http.StatusConflict},
+		{Kind: policy.FaultKindStatusCode, PathPrefix: "/faulted"},
+		{Kind: policy.FaultKindStatusCode, Host: "contoso.invalid"},
+	}}))
+	for _, test := range []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/ok", http.StatusOK},
+		{http.MethodPut, "/ok", http.StatusConflict},
+		{http.MethodGet, "/faulted/path", http.StatusServiceUnavailable},
+	} {
+		req, err := NewRequest(context.Background(), test.method, srv.URL()+test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := pl.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != test.code {
+			t.Fatalf("unexpected status code %d for %s %s", resp.StatusCode, test.method, test.path)
+		}
+	}
+}
+
+func TestFaultInjectionTruncatedBody(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte("0123456789")))
+	// a downloaded body fails like its download did, so the retry policy retries it
+	pl := newFaultTestPipeline(srv, policy.FaultRule{Kind: policy.FaultKindTruncatedBody, Count: 1})
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := Payload(resp); err != nil || string(b) != "0123456789" {
+		t.Fatalf("unexpected body %q", b)
+	}
+	if r := srv.Requests(); r != 2 {
+		t.Fatalf("expected 2 requests, got %d", r)
+	}
+	// a streamed body fails when it's read
+	pl = newFaultTestPipeline(srv, policy.FaultRule{Kind: policy.FaultKindTruncatedBody, TruncateAfter: 4})
+	req, err = NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SkipBodyDownload()
+	if resp, err = pl.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || string(b) != "0123" {
+		t.Fatalf("unexpected result %q, %v", b, err)
+	}
+	resp.Body.Close()
+	// bodies no longer than TruncateAfter aren't truncated
+	pl = newFaultTestPipeline(srv, policy.FaultRule{Kind: policy.FaultKindTruncatedBody, TruncateAfter: 10})
+	for _, skip := range []bool{false, true} {
+		req, err = NewRequest(context.Background(), http.MethodGet, srv.URL())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if skip {
+			req.SkipBodyDownload()
+		}
+		if resp, err = pl.Do(req); err != nil {
+			t.Fatal(err)
+		}
+		if b, err = ioutil.ReadAll(resp.Body); err != nil || string(b) != "0123456789" {
+			t.Fatalf("unexpected result %q, %v", b, err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestFaultInjectionTruncatedDownloadedBody(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte("0123456789")))
+	// without retries, the response has the bytes before the fault
+	pl := NewPipeline(srv, NewRetryPolicy(&policy.RetryOptions{MaxRetries: -1}), NewFaultInjectionPolicy(&policy.FaultInjectionOptions{
+		Rules: []policy.FaultRule{{Kind: policy.FaultKindTruncatedBody, TruncateAfter: 4}},
+	}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if b, _ := ioutil.ReadAll(resp.Body); string(b) != "0123" {
+		t.Fatalf("unexpected body %q", b)
+	}
+}
+
+func TestFaultInjectionDelay(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse()
+	pl := NewPipeline(srv, NewFaultInjectionPolicy(&policy.FaultInjectionOptions{Rules: []policy.FaultRule{{Kind: policy.FaultKindDelay, Delay: time.Hour}}}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := NewRequest(ctx, http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pl.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFaultInjectionOptionsFromEnvironment(t *testing.T) {
+	os.Unsetenv(FaultInjectionEnvVar)
+	if o, err := FaultInjectionOptionsFromEnvironment(); o != nil || err != nil {
+		t.Fatalf("unexpected result %v, %v", o, err)
+	}
+	t.Setenv(FaultInjectionEnvVar, `[{"kind": "throttle", "host": "management.azure.com", "probability": 0.5, "retryAfter": "2s"}, {"kind": "delay", "delay": "150ms", "count": 3}]`)
+	o, err := FaultInjectionOptionsFromEnvironment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Rules) != 2 {
+		t.Fatalf("unexpected rules %v", o.Rules)
+	}
+	if r := o.Rules[0]; r.Kind != policy.FaultKindThrottle || r.Host != "management.azure.com" || r.Probability != 0.5 || r.RetryAfter != 2*time.Second {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+	if r := o.Rules[1]; r.Kind != policy.FaultKindDelay || r.Delay != 150*time.Millisecond || r.Count != 3 {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+	for _, invalid := range []string{`{}`, `[{"kind": "explode"}]`, `[{"kind": "delay", "delay": "soon"}]`, `[{"kind": "delay", "unknown": 1}]`} {
+		if _, err := ParseFaultRules([]byte(invalid)); err == nil {
+			t.Fatalf("expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestFaultInjectionThrottleHeaders(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	pl := NewPipeline(srv, NewFaultInjectionPolicy(&policy.FaultInjectionOptions{Rules: []policy.FaultRule{{Kind: policy.FaultKindThrottle, RetryAfter: 1500 * time.Millisecond}}}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get(shared.HeaderRetryAfter) != "2" || resp.Header.Get(shared.HeaderRetryAfterMS) != "1500" {
+		t.Fatalf("unexpected response %d %v", resp.StatusCode, resp.Header)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,8 +22,50 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
 )
 
+const redacted = "REDACTED"
+
+// defaultAllowedHeaders are the headers whose values are always logged in clear.
+var defaultAllowedHeaders = []string{
+	"accept",
+	"cache-control",
+	"connection",
+	"content-length",
+	"content-type",
+	"date",
+	"etag",
+	"expires",
+	"if-match",
+	"if-modified-since",
+	"if-none-match",
+	"if-unmodified-since",
+	"last-modified",
+	"ms-cv",
+	"pragma",
+	"request-id",
+	"retry-after",
+	"retry-after-ms",
+	"server",
+	"traceparent",
+	"transfer-encoding",
+	"user-agent",
+	"www-authenticate",
+	"x-ms-client-request-id",
+	"x-ms-request-id",
+	"x-ms-retry-after-ms",
+	"x-ms-return-client-request-id",
+}
+
+// defaultAllowedQueryParams are the query parameters whose values are always logged in clear.
+var defaultAllowedQueryParams = []string{
+	"api-version",
+}
+
 type logPolicy struct {
-	options policy.LogOptions
+	includeBody    bool
+	maxBodyBytes   int
+	allowedHeaders map[string]struct{}
+	allowedQP      map[string]struct{}
+	sink           func(policy.LogRecord)
 }
 
 // NewLogPolicy creates a request/response logging policy object configured using the specified options.
@@ -30,7 +74,25 @@ func NewLogPolicy(o *policy.LogOptions) policy.Policy {
 	if o == nil {
 		o = &policy.LogOptions{}
 	}
-	return &logPolicy{options: *o}
+	return &logPolicy{
+		includeBody:    o.IncludeBody,
+		maxBodyBytes:   o.MaxBodyBytes,
+		allowedHeaders: newAllowList(defaultAllowedHeaders, o.AllowedHeaders),
+		allowedQP:      newAllowList(defaultAllowedQueryParams, o.AllowedQueryParams),
+		sink:           o.Sink,
+	}
+}
+
+// newAllowList returns the case-insensitive set of the specified names.
+func newAllowList(defaults, names []string) map[string]struct{} {
+	allowed := make(map[string]struct{}, len(defaults)+len(names))
+	for _, name := range defaults {
+		allowed[name] = struct{}{}
+	}
+	for _, name := range names {
+		allowed[strings.ToLower(name)] = struct{}{}
+	}
+	return allowed
 }
 
 // logPolicyOpValues is the struct containing the per-operation values
@@ -48,14 +110,18 @@ func (p *logPolicy) Do(req *policy.Request) (*http.Response, error) {
 	opValues.try++ // The first try is #1 (not #0)
 	req.SetOperationValue(opValues)
 
+	if p.sink != nil {
+		return p.doStructured(req, opValues.try)
+	}
+
 	// Log the outgoing request as informational
 	if log.Should(log.Request) {
 		b := &bytes.Buffer{}
 		fmt.Fprintf(b, "==> OUTGOING REQUEST (Try=%d)\n", opValues.try)
-		writeRequestWithResponse(b, req, nil, nil)
+		p.writeRequestWithResponse(b, req, nil, nil)
 		var err error
-		if p.options.IncludeBody {
-			err = writeReqBody(req, b)
+		if p.includeBody {
+			err = p.writeReqBody(req, b)
 		}
 		log.Write(log.Request, b.String())
 		if err != nil {
@@ -80,18 +146,146 @@ func (p *logPolicy) Do(req *policy.Request) (*http.Response, error) {
 			fmt.Fprint(b, "RESPONSE RECEIVED\n")
 		}
 
-		writeRequestWithResponse(b, req, response, err)
+		p.writeRequestWithResponse(b, req, response, err)
 		if err != nil {
 			// skip frames runtime.Callers() and runtime.StackTrace()
 			b.WriteString(diag.StackTrace(2, 32))
-		} else if p.options.IncludeBody {
-			err = writeRespBody(response, b)
+		} else if p.includeBody {
+			err = p.writeRespBody(response, b)
 		}
 		log.Write(log.Response, b.String())
 	}
 	return response, err
 }
 
+// doStructured sends the request, emitting a record for it and for its response to the sink.
+func (p *logPolicy) doStructured(req *policy.Request, try int32) (*http.Response, error) {
+	reqRecord := policy.LogRecord{
+		Classification: log.Request,
+		Method:         req.Raw().Method,
+		URL:            p.redactURL(req.Raw().URL),
+		Try:            try,
+		RequestID:      req.Raw().Header.Get(shared.HeaderXMSClientRequestID),
+		Header:         p.redactHeader(req.Raw().Header),
+	}
+	if p.includeBody {
+		body, err := readReqBody(req, &bytes.Buffer{})
+		if err != nil {
+			return nil, err
+		}
+		reqRecord.Body = string(truncateBody(body, p.maxBodyBytes))
+	}
+	p.sink(reqRecord)
+
+	tryStart := time.Now()
+	response, err := req.Next()
+	respRecord := reqRecord
+	respRecord.Classification = log.Response
+	respRecord.Duration = time.Since(tryStart)
+	respRecord.Header = nil
+	respRecord.Body = ""
+	if err != nil {
+		respRecord.Err = p.redactErr(err)
+		p.sink(respRecord)
+		return response, err
+	}
+	respRecord.StatusCode = response.StatusCode
+	respRecord.Header = p.redactHeader(response.Header)
+	if id := response.Header.Get(shared.HeaderXMSRequestID); id != "" {
+		respRecord.RequestID = id
+	}
+	if p.includeBody {
+		var body []byte
+		body, err = readRespBody(response, &bytes.Buffer{})
+		respRecord.Body = string(truncateBody(body, p.maxBodyBytes))
+	}
+	p.sink(respRecord)
+	return response, err
+}
+
+// writeRequestWithResponse appends a formatted HTTP request into a Buffer. If request and/or err are
+// not nil, then these are also written into the Buffer.
+func (p *logPolicy) writeRequestWithResponse(b *bytes.Buffer, req *policy.Request, resp *http.Response, err error) {
+	// Write the request into the buffer.
+	fmt.Fprint(b, "   "+req.Raw().Method+" "+p.redactURL(req.Raw().URL)+"\n")
+	p.writeHeader(b, req.Raw().Header)
+	if resp != nil {
+		fmt.Fprintln(b, "   --------------------------------------------------------------------------------")
+		fmt.Fprint(b, "   RESPONSE Status: "+resp.Status+"\n")
+		p.writeHeader(b, resp.Header)
+	}
+	if err != nil {
+		fmt.Fprintln(b, "   --------------------------------------------------------------------------------")
+		fmt.Fprint(b, "   ERROR:\n"+p.redactErr(err).Error()+"\n")
+	}
+}
+
+// writeHeader appends an HTTP request's or response's header into a Buffer.
+func (p *logPolicy) writeHeader(b *bytes.Buffer, header http.Header) {
+	if len(header) == 0 {
+		b.WriteString("   (no headers)\n")
+		return
+	}
+	keys := make([]string, 0, len(header))
+	// Alphabetize the headers
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// Redact the value of any header not in the allow-list to prevent secrets from persisting in logs
+		value := interface{}(redacted)
+		if _, ok := p.allowedHeaders[strings.ToLower(k)]; ok {
+			value = header[k]
+		}
+		fmt.Fprintf(b, "   %s: %+v\n", k, value)
+	}
+}
+
+// redactHeader returns a copy of header with the values of headers not in the allow-list redacted.
+func (p *logPolicy) redactHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for k, v := range header {
+		if _, ok := p.allowedHeaders[strings.ToLower(k)]; ok {
+			clone[k] = append([]string(nil), v...)
+		} else {
+			clone[k] = []string{redacted}
+		}
+	}
+	return clone
+}
+
+// redactURL returns u as a string with the values of query parameters not in the allow-list redacted.
+func (p *logPolicy) redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	qp := u.Query()
+	for k := range qp {
+		if _, ok := p.allowedQP[strings.ToLower(k)]; !ok {
+			qp[k] = []string{redacted}
+		}
+	}
+	clone := *u
+	clone.RawQuery = qp.Encode()
+	return clone.String()
+}
+
+// redactErr redacts the query parameters in the URL included in a transport error.
+func (p *logPolicy) redactErr(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return err
+	}
+	clone := *urlErr
+	clone.URL = p.redactURL(u)
+	return &clone
+}
+
 // returns true if the request/response body should be logged.
 // this is determined by looking at the content-type header value.
 func shouldLogBody(b *bytes.Buffer, contentType string) bool {
@@ -106,50 +300,77 @@ func shouldLogBody(b *bytes.Buffer, contentType string) bool {
 }
 
 // writes to a buffer, used for logging purposes
-func writeReqBody(req *policy.Request, b *bytes.Buffer) error {
+func (p *logPolicy) writeReqBody(req *policy.Request, b *bytes.Buffer) error {
+	body, err := readReqBody(req, b)
+	if len(body) > 0 {
+		logBody(b, body, p.maxBodyBytes)
+	}
+	return err
+}
+
+// readReqBody returns the request body if it should be logged, writing the reason it's not to the buffer.
+func readReqBody(req *policy.Request, b *bytes.Buffer) ([]byte, error) {
 	if req.Raw().Body == nil {
 		fmt.Fprint(b, "   Request contained no body\n")
-		return nil
+		return nil, nil
 	}
 	if ct := req.Raw().Header.Get(shared.HeaderContentType); !shouldLogBody(b, ct) {
-		return nil
+		return nil, nil
 	}
 	body, err := ioutil.ReadAll(req.Raw().Body)
 	if err != nil {
 		fmt.Fprintf(b, "   Failed to read request body: %s\n", err.Error())
-		return err
+		return nil, err
 	}
 	if err := req.RewindBody(); err != nil {
-		return err
+		return nil, err
 	}
-	logBody(b, body)
-	return nil
+	return body, nil
 }
 
 // writes to a buffer, used for logging purposes
-func writeRespBody(resp *http.Response, b *bytes.Buffer) error {
+func (p *logPolicy) writeRespBody(resp *http.Response, b *bytes.Buffer) error {
+	body, err := readRespBody(resp, b)
+	if len(body) > 0 {
+		logBody(b, body, p.maxBodyBytes)
+	}
+	return err
+}
+
+// readRespBody returns the response body if it should be logged, writing the reason it's not to the buffer.
+func readRespBody(resp *http.Response, b *bytes.Buffer) ([]byte, error) {
 	ct := resp.Header.Get(shared.HeaderContentType)
 	if ct == "" {
 		fmt.Fprint(b, "   Response contained no body\n")
-		return nil
+		return nil, nil
 	} else if !shouldLogBody(b, ct) {
-		return nil
+		return nil, nil
 	}
 	body, err := Payload(resp)
 	if err != nil {
 		fmt.Fprintf(b, "   Failed to read response body: %s\n", err.Error())
-		return err
+		return nil, err
 	}
-	if len(body) > 0 {
-		logBody(b, body)
-	} else {
+	if len(body) == 0 {
 		fmt.Fprint(b, "   Response contained no body\n")
 	}
-	return nil
+	return body, nil
 }
 
-func logBody(b *bytes.Buffer, body []byte) {
+// truncateBody returns body truncated to max bytes when max is greater than zero.
+func truncateBody(body []byte, max int) []byte {
+	if max > 0 && len(body) > max {
+		return body[:max]
+	}
+	return body
+}
+
+// logBody writes body to the buffer, truncated to max bytes when max is greater than zero.
+func logBody(b *bytes.Buffer, body []byte, max int) {
 	fmt.Fprintln(b, "   --------------------------------------------------------------------------------")
-	fmt.Fprintln(b, string(body))
+	fmt.Fprintln(b, string(truncateBody(body, max)))
+	if logged := len(truncateBody(body, max)); logged < len(body) {
+		fmt.Fprintf(b, "   (truncated %d of %d bytes)\n", len(body)-logged, len(body))
+	}
 	fmt.Fprintln(b, "   --------------------------------------------------------------------------------")
 }
@@ -14,6 +14,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
 )
@@ -145,3 +147,129 @@ func TestShouldLogBody(t *testing.T) {
 		t.Fatal("logging shouldn't write message")
 	}
 }
+
+func TestPolicyLoggingRedaction(t *testing.T) {
+	rawlog := map[log.Classification]string{}
+	log.SetListener(func(cls log.Classification, s string) {
+		rawlog[cls] = s
+	})
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithHeader("x-ms-encryption-key", "secret"), mock.WithHeader("x-ms-request-id", "abc"))
+	pl := NewPipeline(srv, NewLogPolicy(&policy.LogOptions{
+		AllowedHeaders:     []string{"X-Visible"},
+		AllowedQueryParams: []string{"Comp"},
+	}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL()+"?api-version=2021-01-01&comp=list&sig=secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Raw().Header.Set("x-visible", "shown")
+	req.Raw().Header.Set("x-ms-encryption-key", "secret")
+	if _, err := pl.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logReq := rawlog[log.Request]
+	if !strings.Contains(logReq, "api-version=2021-01-01&comp=list&sig=REDACTED") {
+		t.Fatalf("unexpected URL in log:\n%s", logReq)
+	}
+	if !strings.Contains(logReq, "X-Visible: [shown]") {
+		t.Fatalf("missing allowed header:\n%s", logReq)
+	}
+	logResp := rawlog[log.Response]
+	if strings.Contains(logReq+logResp, "secret") {
+		t.Fatalf("secret leaked into log:\n%s\n%s", logReq, logResp)
+	}
+	if !strings.Contains(logResp, "X-Ms-Request-Id: [abc]") {
+		t.Fatalf("missing default allowed header:\n%s", logResp)
+	}
+}
+
+func TestPolicyLoggingMaxBodyBytes(t *testing.T) {
+	rawlog := map[log.Classification]string{}
+	log.SetListener(func(cls log.Classification, s string) {
+		rawlog[cls] = s
+	})
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithHeader("Content-Type", "text/plain"), mock.WithBody([]byte("0123456789")))
+	pl := NewPipeline(srv, NewLogPolicy(&policy.LogOptions{IncludeBody: true, MaxBodyBytes: 4}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logResp := rawlog[log.Response]
+	if !strings.Contains(logResp, "0123\n") || strings.Contains(logResp, "01234") {
+		t.Fatalf("body wasn't truncated:\n%s", logResp)
+	}
+	if !strings.Contains(logResp, "(truncated 6 of 10 bytes)") {
+		t.Fatalf("missing truncation note:\n%s", logResp)
+	}
+	// the response body itself must not be truncated
+	if body, err := Payload(resp); err != nil {
+		t.Fatal(err)
+	} else if string(body) != "0123456789" {
+		t.Fatalf("unexpected body %s", string(body))
+	}
+}
+
+func TestPolicyLoggingSink(t *testing.T) {
+	rawlog := map[log.Classification]string{}
+	log.SetListener(func(cls log.Classification, s string) {
+		rawlog[cls] = s
+	})
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendResponse(mock.WithHeader("x-ms-request-id", "service-id"), mock.WithHeader("Content-Type", "application/json"), mock.WithBody([]byte(`{"size":1}`)))
+	var records []policy.LogRecord
+	pl := NewPipeline(srv, NewRetryPolicy(testRetryOptions()), NewLogPolicy(&policy.LogOptions{
+		IncludeBody:  true,
+		MaxBodyBytes: 5,
+		Sink: func(r policy.LogRecord) {
+			records = append(records, r)
+		},
+	}))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL()+"?sig=secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Raw().Header.Set(shared.HeaderXMSClientRequestID, "client-id")
+	req.Raw().Header.Set("Authorization", "secret")
+	if _, err := pl.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := rawlog[log.Request]; ok {
+		t.Fatal("unexpected free-form request entry")
+	}
+	if _, ok := rawlog[log.Response]; ok {
+		t.Fatal("unexpected free-form response entry")
+	}
+	if l := len(records); l != 4 {
+		t.Fatalf("unexpected record count %d", l)
+	}
+	for i, r := range records {
+		if r.Method != http.MethodGet {
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+		if !strings.HasSuffix(r.URL, "?sig=REDACTED") {
+			t.Fatalf("unexpected URL %s", r.URL)
+		}
+		if want := int32(i/2 + 1); r.Try != want {
+			t.Fatalf("unexpected try %d, want %d", r.Try, want)
+		}
+	}
+	if r := records[0]; r.Classification != log.Request || r.RequestID != "client-id" || r.Header.Get("Authorization") != "REDACTED" {
+		t.Fatalf("unexpected request record %+v", r)
+	}
+	if r := records[1]; r.Classification != log.Response || r.StatusCode != http.StatusServiceUnavailable || r.RequestID != "client-id" {
+		t.Fatalf("unexpected response record %+v", r)
+	}
+	if r := records[3]; r.StatusCode != http.StatusOK || r.RequestID != "service-id" || r.Body != `{"siz` || r.Duration <= 0 {
+		t.Fatalf("unexpected response record %+v", r)
+	}
+}
@@ -0,0 +1,156 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+type metricsPolicy struct {
+	service  string
+	recorder policy.MetricsRecorder
+}
+
+// metricsPolicyOpValues is the struct containing the per-operation values
+type metricsPolicyOpValues struct {
+	recorder      policy.MetricsRecorder
+	service       string
+	operation     string
+	try           int32
+	bytesSent     int64
+	bytesReceived int64
+}
+
+// NewMetricsPolicy creates a policy object that reports per-try and per-operation events to the
+// configured MetricsRecorder.  The service parameter is the name of the module creating the pipeline.
+// Place this policy before the retry policy.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+// When no Recorder is specified the policy is a no-op.
+func NewMetricsPolicy(service string, o *policy.MetricsOptions) policy.Policy {
+	if o == nil {
+		o = &policy.MetricsOptions{}
+	}
+	return &metricsPolicy{service: service, recorder: o.Recorder}
+}
+
+func (p *metricsPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if p.recorder == nil {
+		return req.Next()
+	}
+	operation, ok := req.Raw().Context().Value(shared.CtxWithOperationNameKey{}).(string)
+	if !ok || operation == "" {
+		operation = "HTTP " + req.Raw().Method
+	}
+	// the per-try events are reported by metricsTryPolicy which accumulates the totals
+	req.SetOperationValue(metricsPolicyOpValues{recorder: p.recorder, service: p.service, operation: operation})
+	start := time.Now()
+	resp, err := req.Next()
+	var opValues metricsPolicyOpValues
+	req.OperationValue(&opValues)
+	event := newMetricsEvent(opValues, resp, err, time.Since(start))
+	if opValues.try > 0 {
+		event.RetryCount = opValues.try - 1
+	}
+	event.BytesSent = opValues.bytesSent
+	event.BytesReceived = opValues.bytesReceived
+	p.recorder.OperationCompleted(event)
+	return resp, err
+}
+
+// metricsTryPolicy reports an event for each try of an HTTP request.
+// It's a no-op unless the request passed through the metrics policy.
+func metricsTryPolicy(req *policy.Request) (*http.Response, error) {
+	var opValues metricsPolicyOpValues
+	if req.OperationValue(&opValues); opValues.recorder == nil {
+		return req.Next()
+	}
+	opValues.try++
+	start := time.Now()
+	resp, err := req.Next()
+	event := newMetricsEvent(opValues, resp, err, time.Since(start))
+	event.RetryCount = opValues.try - 1
+	if req.Raw().ContentLength > 0 {
+		event.BytesSent = req.Raw().ContentLength
+	}
+	if resp != nil {
+		event.BytesReceived = responseBodySize(resp)
+	}
+	opValues.bytesSent += event.BytesSent
+	opValues.bytesReceived += event.BytesReceived
+	req.SetOperationValue(opValues)
+	opValues.recorder.TryCompleted(event)
+	return resp, err
+}
+
+func newMetricsEvent(opValues metricsPolicyOpValues, resp *http.Response, err error, latency time.Duration) policy.MetricsEvent {
+	event := policy.MetricsEvent{
+		Service:   opValues.service,
+		Operation: opValues.operation,
+		Err:       err,
+		Latency:   latency,
+	}
+	if resp != nil {
+		event.StatusCode = resp.StatusCode
+		event.ErrorCode = resp.Header.Get(shared.HeaderXMSErrorCode)
+	}
+	return event
+}
+
+// responseBodySize returns the size of the downloaded response body, or its Content-Length when
+// the body wasn't downloaded.
+func responseBodySize(resp *http.Response) int64 {
+	if buf, ok := resp.Body.(*nopClosingBytesReader); ok {
+		return int64(len(buf.Bytes()))
+	}
+	if resp.ContentLength > 0 {
+		return resp.ContentLength
+	}
+	return 0
+}
+
+type histogramRecorder struct {
+	emit func(policy.Measurement)
+}
+
+// NewHistogramRecorder creates a MetricsRecorder that converts events into measurements suitable for
+// histograms and counters, passing each one to emit.  Each try produces azure_sdk_try_duration_seconds.
+// Each operation produces azure_sdk_operation_duration_seconds, azure_sdk_operation_retries,
+// azure_sdk_operation_sent_bytes and azure_sdk_operation_received_bytes.  Measurements are labeled
+// with the service, operation, status_code and error_code.
+func NewHistogramRecorder(emit func(policy.Measurement)) policy.MetricsRecorder {
+	return &histogramRecorder{emit: emit}
+}
+
+func (h *histogramRecorder) TryCompleted(e policy.MetricsEvent) {
+	h.emit(policy.Measurement{Name: "azure_sdk_try_duration_seconds", Value: e.Latency.Seconds(), Labels: metricsLabels(e)})
+}
+
+func (h *histogramRecorder) OperationCompleted(e policy.MetricsEvent) {
+	h.emit(policy.Measurement{Name: "azure_sdk_operation_duration_seconds", Value: e.Latency.Seconds(), Labels: metricsLabels(e)})
+	h.emit(policy.Measurement{Name: "azure_sdk_operation_retries", Value: float64(e.RetryCount), Labels: metricsLabels(e)})
+	h.emit(policy.Measurement{Name: "azure_sdk_operation_sent_bytes", Value: float64(e.BytesSent), Labels: metricsLabels(e)})
+	h.emit(policy.Measurement{Name: "azure_sdk_operation_received_bytes", Value: float64(e.BytesReceived), Labels: metricsLabels(e)})
+}
+
+// metricsLabels returns the labels for an event.  Each measurement gets its own map so emit can retain it.
+func metricsLabels(e policy.MetricsEvent) map[string]string {
+	status := ""
+	if e.StatusCode != 0 {
+		status = strconv.Itoa(e.StatusCode)
+	}
+	return map[string]string{
+		"service":     e.Service,
+		"operation":   e.Operation,
+		"status_code": status,
+		"error_code":  e.ErrorCode,
+	}
+}
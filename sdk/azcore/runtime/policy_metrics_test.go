@@ -0,0 +1,163 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+type testMetricsRecorder struct {
+	mu         sync.Mutex
+	tries      []policy.MetricsEvent
+	operations []policy.MetricsEvent
+}
+
+func (r *testMetricsRecorder) TryCompleted(e policy.MetricsEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tries = append(r.tries, e)
+}
+
+func (r *testMetricsRecorder) OperationCompleted(e policy.MetricsEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, e)
+}
+
+func TestMetricsPolicy(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable), mock.WithHeader(shared.HeaderXMSErrorCode, "ServerBusy"))
+	srv.AppendResponse(mock.WithBody([]byte(`{"size":1}`)))
+	rec := &testMetricsRecorder{}
+	pl := NewPipeline(srv, NewMetricsPolicy("azwidgets", &policy.MetricsOptions{Recorder: rec}), NewRetryPolicy(testRetryOptions()))
+	req, err := NewRequest(policy.WithOperationName(context.Background(), "Widgets.Put"), http.MethodPut, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := req.SetBody(shared.NopCloser(strings.NewReader("12345")), shared.ContentTypeAppJSON); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if l := len(rec.tries); l != 2 {
+		t.Fatalf("unexpected try count %d", l)
+	}
+	if e := rec.tries[0]; e.StatusCode != http.StatusServiceUnavailable || e.ErrorCode != "ServerBusy" || e.RetryCount != 0 || e.BytesSent != 5 {
+		t.Fatalf("unexpected first try %+v", e)
+	}
+	if e := rec.tries[1]; e.StatusCode != http.StatusOK || e.ErrorCode != "" || e.RetryCount != 1 || e.BytesReceived != 10 {
+		t.Fatalf("unexpected second try %+v", e)
+	}
+	if l := len(rec.operations); l != 1 {
+		t.Fatalf("unexpected operation count %d", l)
+	}
+	op := rec.operations[0]
+	if op.Service != "azwidgets" || op.Operation != "Widgets.Put" {
+		t.Fatalf("unexpected names %s %s", op.Service, op.Operation)
+	}
+	if op.StatusCode != http.StatusOK || op.RetryCount != 1 || op.BytesSent != 10 || op.BytesReceived != 10 {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+	if op.Latency < rec.tries[0].Latency+rec.tries[1].Latency {
+		t.Fatalf("operation latency %v less than try latencies", op.Latency)
+	}
+}
+
+func TestMetricsPolicyError(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetError(fatalError{s: "boom"})
+	rec := &testMetricsRecorder{}
+	pl := NewPipeline(srv, NewMetricsPolicy("azwidgets", &policy.MetricsOptions{Recorder: rec}), NewRetryPolicy(testRetryOptions()))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pl.Do(req); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+	if l := len(rec.tries); l != 1 {
+		t.Fatalf("unexpected try count %d", l)
+	}
+	op := rec.operations[0]
+	if op.Operation != "HTTP GET" || op.Err == nil || op.StatusCode != 0 {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+}
+
+func TestMetricsPolicyNoRecorder(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse()
+	pl := NewPipeline(srv, NewMetricsPolicy("azwidgets", nil))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pl.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	var opValues metricsPolicyOpValues
+	if req.OperationValue(&opValues) {
+		t.Fatal("unexpected metrics op values")
+	}
+}
+
+func TestHistogramRecorder(t *testing.T) {
+	var measurements []policy.Measurement
+	rec := NewHistogramRecorder(func(m policy.Measurement) {
+		measurements = append(measurements, m)
+	})
+	event := policy.MetricsEvent{
+		Service:       "azwidgets",
+		Operation:     "Widgets.Get",
+		StatusCode:    http.StatusNotFound,
+		ErrorCode:     "WidgetNotFound",
+		RetryCount:    2,
+		BytesReceived: 42,
+	}
+	rec.TryCompleted(event)
+	rec.OperationCompleted(event)
+	names := []string{
+		"azure_sdk_try_duration_seconds",
+		"azure_sdk_operation_duration_seconds",
+		"azure_sdk_operation_retries",
+		"azure_sdk_operation_sent_bytes",
+		"azure_sdk_operation_received_bytes",
+	}
+	if len(measurements) != len(names) {
+		t.Fatalf("unexpected measurement count %d", len(measurements))
+	}
+	for i, m := range measurements {
+		if m.Name != names[i] {
+			t.Fatalf("unexpected name %s", m.Name)
+		}
+		if m.Labels["service"] != "azwidgets" || m.Labels["operation"] != "Widgets.Get" || m.Labels["status_code"] != "404" || m.Labels["error_code"] != "WidgetNotFound" {
+			t.Fatalf("unexpected labels %v", m.Labels)
+		}
+	}
+	if v := measurements[2].Value; v != 2 {
+		t.Fatalf("unexpected retries %f", v)
+	}
+	if v := measurements[4].Value; v != 42 {
+		t.Fatalf("unexpected received bytes %f", v)
+	}
+}
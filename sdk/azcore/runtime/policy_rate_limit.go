@@ -0,0 +1,343 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// ARM returns the remaining number of requests for the current window in headers
+// such as x-ms-ratelimit-remaining-subscription-reads
+const headerRateLimitRemainingPrefix = "X-Ms-Ratelimit-Remaining-"
+
+const (
+	// defaultAdaptiveWindow is the default value of RateLimitOptions.AdaptiveWindow, the period of ARM's budgets
+	defaultAdaptiveWindow = time.Hour
+	// partitionIdleTimeout is how long a partition must be unused before it's evicted
+	partitionIdleTimeout = 5 * time.Minute
+)
+
+// NewRateLimiter creates a RateLimiter configured using the specified options.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+// Share the returned RateLimiter across clients to apply the same limits to all of them.
+func NewRateLimiter(o *policy.RateLimitOptions) policy.RateLimiter {
+	if o == nil {
+		o = &policy.RateLimitOptions{}
+	}
+	rl := &rateLimiter{options: *o, partitions: map[string]*rateLimitPartition{}, lastEviction: time.Now()}
+	if rl.options.Burst <= 0 {
+		rl.options.Burst = int(math.Ceil(rl.options.RequestsPerSecond))
+	}
+	if rl.options.AdaptiveWindow <= 0 {
+		rl.options.AdaptiveWindow = defaultAdaptiveWindow
+	}
+	if rl.options.PartitionKey == nil {
+		rl.options.PartitionKey = func(req *http.Request) string {
+			return req.URL.Host
+		}
+	}
+	return rl
+}
+
+type rateLimiter struct {
+	options policy.RateLimitOptions
+
+	// mu protects partitions, lastEviction and the partitions' state
+	mu           sync.Mutex
+	partitions   map[string]*rateLimitPartition
+	lastEviction time.Time
+}
+
+// rateLimitPartition contains the state for a single partition
+type rateLimitPartition struct {
+	// sem limits concurrency, it's nil when concurrency limiting is disabled
+	sem chan struct{}
+
+	// the following fields are protected by rateLimiter.mu.
+
+	// bucket limits the partition to the configured rate
+	bucket tokenBucket
+
+	// budgets are the limits derived from the service's remaining requests, by budgetKind
+	budgets [budgetKinds]adaptiveBudget
+
+	// users is the number of requests between Acquire and release
+	users int
+	// lastUsed is when the partition was last acquired or released
+	lastUsed time.Time
+}
+
+// partition returns the partition for key, creating it if necessary.  The caller must hold rl.mu.
+func (rl *rateLimiter) partition(key string, now time.Time) *rateLimitPartition {
+	rl.evictIdle(now)
+	p, ok := rl.partitions[key]
+	if !ok {
+		p = &rateLimitPartition{bucket: tokenBucket{tokens: float64(rl.options.Burst), last: now}}
+		if rl.options.MaxConcurrentRequests > 0 {
+			p.sem = make(chan struct{}, rl.options.MaxConcurrentRequests)
+		}
+		rl.partitions[key] = p
+	}
+	p.users++
+	p.lastUsed = now
+	return p
+}
+
+// evictIdle removes the partitions which haven't been used for partitionIdleTimeout, at most once
+// per partitionIdleTimeout.  An idle partition's bucket is full and it has no adaptive rate, so
+// recreating it later doesn't change the limits.  The caller must hold rl.mu.
+func (rl *rateLimiter) evictIdle(now time.Time) {
+	if now.Sub(rl.lastEviction) < partitionIdleTimeout {
+		return
+	}
+	rl.lastEviction = now
+	for key, p := range rl.partitions {
+		if p.users == 0 && now.Sub(p.lastUsed) >= partitionIdleTimeout && !p.adapting(now) {
+			delete(rl.partitions, key)
+		}
+	}
+}
+
+// release returns the partition's concurrency slot once a request has completed
+func (rl *rateLimiter) release(p *rateLimitPartition) {
+	rl.mu.Lock()
+	p.users--
+	p.lastUsed = time.Now()
+	rl.mu.Unlock()
+	p.releaseSlot()
+}
+
+// Acquire implements the policy.RateLimiter interface for rateLimiter.
+func (rl *rateLimiter) Acquire(ctx context.Context, req *http.Request) (func(*http.Response), error) {
+	key := rl.options.PartitionKey(req)
+	rl.mu.Lock()
+	p := rl.partition(key, time.Now())
+	rl.mu.Unlock()
+	// acquire a concurrency slot first so that waiting for a slot doesn't consume tokens
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			rl.mu.Lock()
+			p.users--
+			rl.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	if err := rl.take(ctx, p, methodBudget(req.Method)); err != nil {
+		rl.release(p)
+		return nil, err
+	}
+	return func(resp *http.Response) {
+		if rl.options.Adaptive {
+			rl.adapt(p, resp)
+		}
+		rl.release(p)
+	}, nil
+}
+
+// take reserves a token from the partition's bucket and, when the service's budget for requests of
+// the specified kind limits the partition, from that budget's bucket.  It waits until both are available.
+func (rl *rateLimiter) take(ctx context.Context, p *rateLimitPartition, kind budgetKind) error {
+	for {
+		rl.mu.Lock()
+		now := time.Now()
+		budget := &p.budgets[kind]
+		adapting := now.Before(budget.until)
+		if adapting && budget.rate == 0 {
+			// the service reported no remaining requests, pause until the budget's window has passed
+			wait := budget.until.Sub(now)
+			rl.mu.Unlock()
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		burst := math.Max(1, float64(rl.options.Burst))
+		var wait time.Duration
+		limited := rl.options.RequestsPerSecond > 0
+		if limited {
+			wait = p.bucket.reserve(rl.options.RequestsPerSecond, burst, now)
+		}
+		if adapting {
+			if w := budget.bucket.reserve(budget.rate, burst, now); w > wait {
+				wait = w
+			}
+		}
+		rl.mu.Unlock()
+		if wait <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(wait):
+			return nil
+		case <-ctx.Done():
+			// return the reserved tokens
+			rl.mu.Lock()
+			if limited {
+				p.bucket.tokens++
+			}
+			if adapting {
+				budget.bucket.tokens++
+			}
+			rl.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+}
+
+// adapt limits the partition's requests of each kind reported by the service to the lowest remaining
+// value for that kind spread over the adaptive window, and caps the budget's available tokens at that
+// value.  Requests of a kind whose budget is exhausted pause until the window has passed.
+func (rl *rateLimiter) adapt(p *rateLimitPartition, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	remaining := [budgetKinds]int{-1, -1, -1}
+	for k, v := range resp.Header {
+		k = http.CanonicalHeaderKey(k)
+		if !strings.HasPrefix(k, headerRateLimitRemainingPrefix) || len(v) == 0 {
+			continue
+		}
+		kind, ok := headerBudget(k)
+		if !ok {
+			continue
+		}
+		if r, err := strconv.Atoi(v[0]); err == nil && (remaining[kind] < 0 || r < remaining[kind]) {
+			remaining[kind] = r
+		}
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	for kind, r := range remaining {
+		if r < 0 {
+			continue
+		}
+		budget := &p.budgets[kind]
+		if !now.Before(budget.until) {
+			// start with a full bucket
+			budget.bucket = tokenBucket{tokens: math.Max(1, float64(rl.options.Burst)), last: now}
+		}
+		budget.rate = float64(r) / rl.options.AdaptiveWindow.Seconds()
+		budget.until = now.Add(rl.options.AdaptiveWindow)
+		if float64(r) < budget.bucket.tokens {
+			budget.bucket.tokens = float64(r)
+		}
+	}
+}
+
+// adapting returns true when any of the service's budgets limits the partition.  The caller must hold rl.mu.
+func (p *rateLimitPartition) adapting(now time.Time) bool {
+	for _, b := range p.budgets {
+		if now.Before(b.until) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenBucket is the state of a token bucket.  tokens can be negative which
+// indicates that requests are waiting for tokens.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token from the bucket, which refills at rate up to burst, and returns how
+// long the caller must wait for the token to be available.
+func (b *tokenBucket) reserve(rate, burst float64, now time.Time) time.Duration {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	b.tokens--
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// budgetKind identifies the ARM budget which applies to a request
+type budgetKind int
+
+const (
+	budgetReads budgetKind = iota
+	budgetWrites
+	budgetDeletes
+	budgetKinds
+)
+
+// adaptiveBudget is a limit derived from the service's remaining requests of one kind.
+// It applies until until.
+type adaptiveBudget struct {
+	rate   float64
+	until  time.Time
+	bucket tokenBucket
+}
+
+// methodBudget returns the budget for requests with the specified method
+func methodBudget(method string) budgetKind {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return budgetReads
+	case http.MethodDelete:
+		return budgetDeletes
+	default:
+		return budgetWrites
+	}
+}
+
+// headerBudget returns the budget reported by a remaining requests header such as
+// X-Ms-Ratelimit-Remaining-Subscription-Reads, or false when the header doesn't report one.
+func headerBudget(header string) (budgetKind, bool) {
+	switch {
+	case strings.HasSuffix(header, "-Reads"):
+		return budgetReads, true
+	case strings.HasSuffix(header, "-Writes"):
+		return budgetWrites, true
+	case strings.HasSuffix(header, "-Deletes"):
+		return budgetDeletes, true
+	default:
+		return 0, false
+	}
+}
+
+func (p *rateLimitPartition) releaseSlot() {
+	if p.sem != nil {
+		<-p.sem
+	}
+}
+
+type rateLimitPolicy struct {
+	limiter policy.RateLimiter
+}
+
+// NewRateLimitPolicy creates a policy object that limits the rate and concurrency of requests using the specified RateLimiter.
+// Place this policy after the retry policy so that each try is limited.
+// When the RateLimiter is nil the policy is a no-op.
+func NewRateLimitPolicy(limiter policy.RateLimiter) policy.Policy {
+	return &rateLimitPolicy{limiter: limiter}
+}
+
+func (p *rateLimitPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if p.limiter == nil {
+		return req.Next()
+	}
+	release, err := p.limiter.Acquire(req.Raw().Context(), req.Raw())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := req.Next()
+	release(resp)
+	return resp, err
+}
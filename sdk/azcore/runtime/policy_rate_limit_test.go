@@ -0,0 +1,264 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+func newRateLimitTestRequest(ctx context.Context, t *testing.T, u string) *http.Request {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestRateLimitPolicyNoLimiter(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse()
+	pl := NewPipeline(srv, NewRateLimitPolicy(nil))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimitPolicyRate(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse()
+	limiter := NewRateLimiter(&policy.RateLimitOptions{RequestsPerSecond: 20, Burst: 1})
+	pl := NewPipeline(srv, NewRateLimitPolicy(limiter))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = pl.Do(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first request is sent immediately, the remaining two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("requests weren't rate limited, elapsed %v", elapsed)
+	}
+}
+
+func TestRateLimiterContextCancelled(t *testing.T) {
+	limiter := NewRateLimiter(&policy.RateLimitOptions{RequestsPerSecond: 0.01})
+	release, err := limiter.Acquire(context.Background(), newRateLimitTestRequest(context.Background(), t, "https://contoso.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = limiter.Acquire(ctx, newRateLimitTestRequest(ctx, t, "https://contoso.com")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// other partitions aren't affected
+	release, err = limiter.Acquire(context.Background(), newRateLimitTestRequest(context.Background(), t, "https://fabrikam.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(nil)
+}
+
+func TestRateLimiterConcurrency(t *testing.T) {
+	limiter := NewRateLimiter(&policy.RateLimitOptions{
+		MaxConcurrentRequests: 1,
+		PartitionKey: func(*http.Request) string {
+			return "all"
+		},
+	})
+	release, err := limiter.Acquire(context.Background(), newRateLimitTestRequest(context.Background(), t, "https://contoso.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// the custom partition key places both hosts in the same partition
+	if _, err = limiter.Acquire(ctx, newRateLimitTestRequest(ctx, t, "https://fabrikam.com")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	acquired := make(chan error)
+	waiting := newRateLimitTestRequest(context.Background(), t, "https://fabrikam.com")
+	go func() {
+		release, err := limiter.Acquire(context.Background(), waiting)
+		if err == nil {
+			release(nil)
+		}
+		acquired <- err
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("slot acquired while in use")
+	case <-time.After(10 * time.Millisecond):
+	}
+	release(nil)
+	if err = <-acquired; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimiterAdaptive(t *testing.T) {
+	// adapting applies even without a configured rate
+	limiter := NewRateLimiter(&policy.RateLimitOptions{Adaptive: true, AdaptiveWindow: time.Second})
+	req := newRateLimitTestRequest(context.Background(), t, "https://management.azure.com")
+	release, err := limiter.Acquire(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(&http.Response{Header: http.Header{
+		"X-Ms-Ratelimit-Remaining-Subscription-Reads": []string{"11999"},
+		"X-Ms-Ratelimit-Remaining-Tenant-Reads":       []string{"20"},
+	}})
+	// the lowest remaining value is spread over the window: 20 requests per second after a burst of one
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err = limiter.Acquire(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release(nil)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("requests weren't slowed, elapsed %v", elapsed)
+	}
+}
+
+func TestRateLimiterAdaptiveDisabled(t *testing.T) {
+	// adapting is disabled by default, so an exhausted budget doesn't pause the partition for the default hour
+	limiter := NewRateLimiter(&policy.RateLimitOptions{RequestsPerSecond: 10, Burst: 100})
+	req := newRateLimitTestRequest(context.Background(), t, "https://management.azure.com")
+	release, err := limiter.Acquire(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(&http.Response{Header: http.Header{"X-Ms-Ratelimit-Remaining-Tenant-Reads": []string{"0"}}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	release, err = limiter.Acquire(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(nil)
+}
+
+func TestRateLimiterAdaptivePause(t *testing.T) {
+	limiter := NewRateLimiter(&policy.RateLimitOptions{RequestsPerSecond: 10, Burst: 100, Adaptive: true, AdaptiveWindow: 100 * time.Millisecond})
+	write := newRateLimitTestRequest(context.Background(), t, "https://management.azure.com")
+	write.Method = http.MethodPut
+	release, err := limiter.Acquire(context.Background(), write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(&http.Response{Header: http.Header{"X-Ms-Ratelimit-Remaining-Subscription-Writes": []string{"0"}}})
+	// the write budget is exhausted so writes pause until the window has passed
+	start := time.Now()
+	release, err = limiter.Acquire(context.Background(), write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(nil)
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("writes didn't pause, elapsed %v", elapsed)
+	}
+	// a paused budget honors the context
+	release, err = limiter.Acquire(context.Background(), write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(&http.Response{Header: http.Header{"X-Ms-Ratelimit-Remaining-Subscription-Writes": []string{"0"}}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	paused := newRateLimitTestRequest(ctx, t, "https://management.azure.com")
+	paused.Method = http.MethodPatch
+	if _, err = limiter.Acquire(ctx, paused); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// reads and deletes have their own budgets, so they aren't paused
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodDelete} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		req := newRateLimitTestRequest(ctx, t, "https://management.azure.com")
+		req.Method = method
+		release, err = limiter.Acquire(ctx, req)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		release(nil)
+	}
+}
+
+func TestRateLimiterEvictsIdlePartitions(t *testing.T) {
+	rl := NewRateLimiter(&policy.RateLimitOptions{RequestsPerSecond: 10}).(*rateLimiter)
+	for _, host := range []string{"https://contoso.com", "https://fabrikam.com"} {
+		release, err := rl.Acquire(context.Background(), newRateLimitTestRequest(context.Background(), t, host))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if host == "https://contoso.com" {
+			release(nil)
+		} else {
+			// fabrikam's request is still in flight
+			defer release(nil)
+		}
+	}
+	// pretend the partitions were last used long ago
+	rl.mu.Lock()
+	for _, p := range rl.partitions {
+		p.lastUsed = time.Now().Add(-2 * partitionIdleTimeout)
+	}
+	rl.lastEviction = time.Now().Add(-2 * partitionIdleTimeout)
+	rl.mu.Unlock()
+	release, err := rl.Acquire(context.Background(), newRateLimitTestRequest(context.Background(), t, "https://example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(nil)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if _, ok := rl.partitions["contoso.com"]; ok {
+		t.Fatal("idle partition wasn't evicted")
+	}
+	if _, ok := rl.partitions["fabrikam.com"]; !ok {
+		t.Fatal("partition in use was evicted")
+	}
+	if len(rl.partitions) != 2 {
+		t.Fatalf("unexpected partition count %d", len(rl.partitions))
+	}
+}
+
+func TestRateLimiterDefaultPartition(t *testing.T) {
+	rl := NewRateLimiter(nil).(*rateLimiter)
+	u, err := url.Parse("https://contoso.com/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := rl.options.PartitionKey(&http.Request{URL: u}); key != "contoso.com" {
+		t.Fatalf("unexpected partition key %s", key)
+	}
+}
@@ -41,6 +41,7 @@ func setDefaults(o *policy.RetryOptions) {
 	if o.StatusCodes == nil {
 		o.StatusCodes = []int{
 			http.StatusRequestTimeout,      // 408
+			http.StatusTooManyRequests,     // 429
 			http.StatusInternalServerError, // 500
 			http.StatusBadGateway,          // 502
 			http.StatusServiceUnavailable,  // 503
@@ -99,6 +100,7 @@ func (p *retryPolicy) Do(req *policy.Request) (resp *http.Response, err error) {
 		defer rwbody.realClose()
 	}
 	try := int32(1)
+	var prevDelay time.Duration
 	for {
 		resp = nil // reset
 		log.Writef(log.RetryPolicy, "\n=====> Try=%d %s %s", try, req.Raw().Method, req.Raw().URL.String())
@@ -130,10 +132,17 @@ func (p *retryPolicy) Do(req *policy.Request) (resp *http.Response, err error) {
 			log.Writef(log.RetryPolicy, "error %v", err)
 		}
 
-		if err == nil && !HasStatusCode(resp, options.StatusCodes...) {
+		if options.ShouldRetry != nil {
+			// a non-nil ShouldRetry overrides our HTTP status code and error checks
+			if !options.ShouldRetry(resp, err) {
+				log.Write(log.RetryPolicy, "exit due to ShouldRetry")
+				return
+			}
+		} else if err == nil && !HasStatusCode(resp, options.StatusCodes...) {
 			// if there is no error and the response code isn't in the list of retry codes then we're done.
 			return
-		} else if ctxErr := req.Raw().Context().Err(); ctxErr != nil {
+		}
+		if ctxErr := req.Raw().Context().Err(); ctxErr != nil {
 			// don't retry if the parent context has been cancelled or its deadline exceeded
 			err = ctxErr
 			log.Writef(log.RetryPolicy, "abort due to %v", err)
@@ -160,8 +169,16 @@ func (p *retryPolicy) Do(req *policy.Request) (resp *http.Response, err error) {
 		// use the delay from retry-after if available
 		delay := shared.RetryAfter(resp)
 		if delay <= 0 {
-			delay = calcDelay(options, try)
+			if options.Backoff != nil {
+				delay = options.Backoff(try, prevDelay)
+			} else {
+				delay = calcDelay(options, try)
+			}
+		}
+		if delay > options.MaxRetryDelay {
+			delay = options.MaxRetryDelay
 		}
+		prevDelay = delay
 		log.Writef(log.RetryPolicy, "End Try #%d, Delay=%v", try, delay)
 		select {
 		case <-time.After(delay):
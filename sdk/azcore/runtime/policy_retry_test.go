@@ -13,6 +13,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -592,6 +593,173 @@ func newRewindTrackingBody(s string) *rewindTrackingBody {
 	}
 }
 
+func TestRetryPolicyRetryAfterThrottled(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusTooManyRequests), mock.WithHeader("x-ms-retry-after-ms", "10"))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusTooManyRequests), mock.WithHeader("retry-after-ms", "10"))
+	srv.AppendResponse()
+	// the default RetryDelay of four seconds would time out the test
+	pl := NewPipeline(srv, NewRetryPolicy(nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := NewRequest(ctx, http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	if r := srv.Requests(); r != 3 {
+		t.Fatalf("wrong retry count, got %d expected %d", r, 3)
+	}
+}
+
+func TestRetryPolicyRetryAfterCapped(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable), mock.WithHeader("Retry-After", "3600"))
+	srv.AppendResponse()
+	pl := NewPipeline(srv, NewRetryPolicy(&policy.RetryOptions{MaxRetryDelay: 10 * time.Millisecond}))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := NewRequest(ctx, http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	if r := srv.Requests(); r != 2 {
+		t.Fatalf("wrong retry count, got %d expected %d", r, 2)
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusConflict), mock.WithHeader("x-ms-error-code", "OperationInProgress"))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusConflict), mock.WithHeader("x-ms-error-code", "ResourceExists"))
+	srv.AppendResponse()
+	opts := testRetryOptions()
+	opts.ShouldRetry = func(resp *http.Response, err error) bool {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp.Header.Get("x-ms-error-code") == "OperationInProgress"
+	}
+	pl := NewPipeline(srv, NewRetryPolicy(opts))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	if r := srv.Requests(); r != 2 {
+		t.Fatalf("wrong retry count, got %d expected %d", r, 2)
+	}
+}
+
+func TestRetryPolicyShouldRetryNonRetriable(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendError(fatalError{s: "boom"})
+	srv.AppendResponse()
+	opts := testRetryOptions()
+	opts.ShouldRetry = func(*http.Response, error) bool {
+		return true
+	}
+	pl := NewPipeline(srv, NewRetryPolicy(opts))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = pl.Do(req); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+	if r := srv.Requests(); r != 1 {
+		t.Fatalf("wrong retry count, got %d expected %d", r, 1)
+	}
+}
+
+func TestRetryPolicyCustomBackoff(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.RepeatResponse(3, mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendResponse()
+	tries := []int32{}
+	prevs := []time.Duration{}
+	opts := policy.RetryOptions{
+		// the default RetryDelay of four seconds would time out the test
+		Backoff: func(try int32, previous time.Duration) time.Duration {
+			tries = append(tries, try)
+			prevs = append(prevs, previous)
+			// linear
+			return time.Duration(try) * time.Millisecond
+		},
+	}
+	pl := NewPipeline(srv, NewRetryPolicy(nil))
+	ctx, cancel := context.WithTimeout(policy.WithRetryOptions(context.Background(), opts), 2*time.Second)
+	defer cancel()
+	req, err := NewRequest(ctx, http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	if !reflect.DeepEqual(tries, []int32{1, 2, 3}) {
+		t.Fatalf("unexpected tries %v", tries)
+	}
+	if !reflect.DeepEqual(prevs, []time.Duration{0, time.Millisecond, 2 * time.Millisecond}) {
+		t.Fatalf("unexpected previous delays %v", prevs)
+	}
+}
+
+func TestRetryPolicyCustomBackoffCapped(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendResponse()
+	opts := policy.RetryOptions{
+		MaxRetryDelay: time.Millisecond,
+		Backoff: func(int32, time.Duration) time.Duration {
+			return time.Hour
+		},
+	}
+	pl := NewPipeline(srv, NewRetryPolicy(&opts))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := NewRequest(ctx, http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
 // used to track the number of times a request body has been rewound
 type rewindTrackingBody struct {
 	body   *strings.Reader
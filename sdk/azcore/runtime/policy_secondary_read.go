@@ -0,0 +1,204 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pipeline"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// NewSecondaryReadPolicy creates a policy object that sends GET and HEAD requests to a secondary endpoint when the
+// primary fails with a 5xx or an error, or is slower than the configured thresholds.  Other requests, and all requests
+// when o.Host is empty, pass through unchanged.
+// Place this policy after the retry policy so that each try can be served by either endpoint.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+func NewSecondaryReadPolicy(o *policy.SecondaryReadOptions) policy.Policy {
+	if o == nil {
+		o = &policy.SecondaryReadOptions{}
+	}
+	return &secondaryReadPolicy{options: *o}
+}
+
+type secondaryReadPolicy struct {
+	options policy.SecondaryReadOptions
+}
+
+func (p *secondaryReadPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+	if p.options.Host == "" || (raw.Method != http.MethodGet && raw.Method != http.MethodHead) || raw.URL.Host == p.options.Host {
+		return req.Next()
+	}
+	ctx := raw.Context()
+	results := make(chan *secondaryReadLeg, 2)
+	primary := p.send(req, false, results)
+	legs := []*secondaryReadLeg{primary}
+	pending := 1
+
+	// the timers apply only while waiting for the primary's response headers
+	var hedge, timeout <-chan time.Time
+	if p.options.HedgeDelay > 0 {
+		timer := time.NewTimer(p.options.HedgeDelay)
+		defer timer.Stop()
+		hedge = timer.C
+	}
+	if p.options.PrimaryTimeout > 0 {
+		timer := time.NewTimer(p.options.PrimaryTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	sendSecondary := func() {
+		hedge, timeout = nil, nil
+		legs = append(legs, p.send(req, true, results))
+		pending++
+	}
+
+	abandoned := false
+	var failedPrimary, failedSecondary *secondaryReadLeg
+	for pending > 0 {
+		select {
+		case <-hedge:
+			sendSecondary()
+		case <-timeout:
+			// abandon the primary, its failure isn't reported if the secondary fails too
+			abandoned = true
+			primary.cancel()
+			sendSecondary()
+		case leg := <-results:
+			pending--
+			if !leg.failed() {
+				// first successful response wins, abandon the other leg if it's still in flight
+				for _, l := range legs {
+					if l != leg {
+						l.cancel()
+					}
+				}
+				go discardSecondaryReadLegs(results, pending)
+				return leg.result(req)
+			}
+			if leg.secondary {
+				failedSecondary = leg
+			} else {
+				failedPrimary = leg
+				if len(legs) == 1 && ctx.Err() == nil {
+					sendSecondary()
+				}
+			}
+		}
+	}
+
+	// both endpoints failed, report the primary's failure unless it was abandoned
+	if abandoned {
+		failedPrimary, failedSecondary = failedSecondary, failedPrimary
+	}
+	failedSecondary.discard()
+	return failedPrimary.result(req)
+}
+
+// send starts sending a clone of req to the primary or secondary endpoint.  The clone returns once the response
+// headers arrive, so that the policy's timers don't apply to downloading the body.  The completed leg is written
+// to results.  Each clone has its own operation values, as the legs run concurrently; the chosen leg's values
+// are returned to req by result.
+func (p *secondaryReadPolicy) send(req *policy.Request, secondary bool, results chan<- *secondaryReadLeg) *secondaryReadLeg {
+	leg := &secondaryReadLeg{secondary: secondary}
+	var ctx context.Context
+	ctx, leg.cancel = context.WithCancel(req.Raw().Context())
+	clone := pipeline.CloneWithOperationValues(ctx, req)
+	clone.SkipBodyDownload()
+	leg.req = clone
+	if secondary {
+		clone.Raw().URL.Host = p.options.Host
+		clone.Raw().Host = ""
+	}
+	go func() {
+		leg.resp, leg.err = clone.Next()
+		if leg.resp != nil && leg.resp.Request == nil {
+			leg.resp.Request = clone.Raw()
+		}
+		results <- leg
+	}()
+	return leg
+}
+
+// discardSecondaryReadLegs releases the n legs still in flight once they complete.
+func discardSecondaryReadLegs(results <-chan *secondaryReadLeg, n int) {
+	for ; n > 0; n-- {
+		(<-results).discard()
+	}
+}
+
+// secondaryReadLeg is one attempt of a read against the primary or secondary endpoint.
+type secondaryReadLeg struct {
+	secondary bool
+	req       *policy.Request
+	cancel    context.CancelFunc
+	resp      *http.Response
+	err       error
+}
+
+func (l *secondaryReadLeg) failed() bool {
+	return l.err != nil || l.resp.StatusCode >= http.StatusInternalServerError
+}
+
+// result returns the leg's response, downloading its body unless req skips body downloads.
+// The leg's context is released once the body has been downloaded or closed.
+func (l *secondaryReadLeg) result(req *policy.Request) (*http.Response, error) {
+	// return the values set by the leg's policies, such as its try count, keeping req's body download setting
+	var opValues shared.BodyDownloadPolicyOpValues
+	req.OperationValue(&opValues)
+	pipeline.CopyOperationValues(req, l.req)
+	req.SetOperationValue(opValues)
+	if l.err != nil {
+		l.cancel()
+		return l.resp, l.err
+	}
+	if _, downloaded := l.resp.Body.(*nopClosingBytesReader); downloaded {
+		l.cancel()
+		return l.resp, nil
+	}
+	if opValues.Skip {
+		l.resp.Body = &cancelOnCloseBody{ReadCloser: l.resp.Body, cancel: l.cancel}
+		return l.resp, nil
+	}
+	b, err := ioutil.ReadAll(l.resp.Body)
+	l.resp.Body.Close()
+	l.cancel()
+	if err != nil {
+		return l.resp, newBodyDownloadError(err, req)
+	}
+	l.resp.Body = &nopClosingBytesReader{s: b}
+	return l.resp, nil
+}
+
+// discard releases the leg's response and context.
+func (l *secondaryReadLeg) discard() {
+	if l == nil {
+		return
+	}
+	l.cancel()
+	if l.resp != nil {
+		l.resp.Body.Close()
+	}
+}
+
+// cancelOnCloseBody cancels a leg's context once the caller is done with its response body.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,211 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+func newSecondaryReadTestServers(t *testing.T) (*mock.Server, *mock.Server, string) {
+	primary, closePrimary := mock.NewServer()
+	t.Cleanup(closePrimary)
+	secondary, closeSecondary := mock.NewServer()
+	t.Cleanup(closeSecondary)
+	u, err := url.Parse(secondary.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return primary, secondary, u.Host
+}
+
+// hostCountingTransport counts the requests sent to each host
+type hostCountingTransport struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (h *hostCountingTransport) Do(req *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	if h.counts == nil {
+		h.counts = map[string]int{}
+	}
+	h.counts[req.URL.Host]++
+	h.mu.Unlock()
+	return defaultHTTPClient.Do(req)
+}
+
+func (h *hostCountingTransport) requests(host string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.counts[host]
+}
+
+func doSecondaryRead(t *testing.T, tp policy.Transporter, o *policy.SecondaryReadOptions, method, u string) *http.Response {
+	req, err := NewRequest(context.Background(), method, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewPipeline(tp, NewSecondaryReadPolicy(o)).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func requireSecondaryReadResponse(t *testing.T, resp *http.Response, statusCode int, host, body string) {
+	if resp.StatusCode != statusCode {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if resp.Request.URL.Host != host {
+		t.Fatalf("unexpected host %s", resp.Request.URL.Host)
+	}
+	b, err := Payload(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != body {
+		t.Fatalf("unexpected body %q", string(b))
+	}
+}
+
+func TestSecondaryReadPolicyDisabled(t *testing.T) {
+	primary, _, host := newSecondaryReadTestServers(t)
+	primary.SetResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	tp := &hostCountingTransport{}
+	resp := doSecondaryRead(t, tp, nil, http.MethodGet, primary.URL())
+	requireSecondaryReadResponse(t, resp, http.StatusServiceUnavailable, resp.Request.URL.Host, "")
+	if tp.requests(host) != 0 {
+		t.Fatal("unexpected request to the secondary")
+	}
+}
+
+func TestSecondaryReadPolicyOnPrimaryFailure(t *testing.T) {
+	primary, secondary, host := newSecondaryReadTestServers(t)
+	primary.SetResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	secondary.SetResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte("secondary")))
+	resp := doSecondaryRead(t, nil, &policy.SecondaryReadOptions{Host: host}, http.MethodGet, primary.URL())
+	requireSecondaryReadResponse(t, resp, http.StatusOK, host, "secondary")
+	resp = doSecondaryRead(t, nil, &policy.SecondaryReadOptions{Host: host}, http.MethodHead, primary.URL())
+	requireSecondaryReadResponse(t, resp, http.StatusOK, host, "")
+}
+
+func TestSecondaryReadPolicyNotForWrites(t *testing.T) {
+	primary, _, host := newSecondaryReadTestServers(t)
+	primary.SetResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	tp := &hostCountingTransport{}
+	resp := doSecondaryRead(t, tp, &policy.SecondaryReadOptions{Host: host}, http.MethodPut, primary.URL())
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if tp.requests(host) != 0 {
+		t.Fatal("unexpected request to the secondary")
+	}
+}
+
+func TestSecondaryReadPolicyBothFail(t *testing.T) {
+	primary, secondary, host := newSecondaryReadTestServers(t)
+	primary.SetResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	secondary.SetResponse(mock.WithStatusCode(http.StatusInternalServerError))
+	resp := doSecondaryRead(t, nil, &policy.SecondaryReadOptions{Host: host}, http.MethodGet, primary.URL())
+	// the primary's failure is reported
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Request.URL.Host == host {
+		t.Fatalf("unexpected response %d from %s", resp.StatusCode, resp.Request.URL.Host)
+	}
+}
+
+func TestSecondaryReadPolicyPrimaryTimeout(t *testing.T) {
+	primary, secondary, host := newSecondaryReadTestServers(t)
+	primary.SetResponse(mock.WithSlowResponse(time.Second))
+	secondary.SetResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte("secondary")))
+	start := time.Now()
+	resp := doSecondaryRead(t, nil, &policy.SecondaryReadOptions{Host: host, PrimaryTimeout: 20 * time.Millisecond}, http.MethodGet, primary.URL())
+	if time.Since(start) >= time.Second {
+		t.Fatal("the read waited for the primary")
+	}
+	requireSecondaryReadResponse(t, resp, http.StatusOK, host, "secondary")
+
+	// the secondary's failure is reported when the primary was abandoned
+	secondary.SetResponse(mock.WithStatusCode(http.StatusInternalServerError))
+	resp = doSecondaryRead(t, nil, &policy.SecondaryReadOptions{Host: host, PrimaryTimeout: 20 * time.Millisecond}, http.MethodGet, primary.URL())
+	requireSecondaryReadResponse(t, resp, http.StatusInternalServerError, host, "")
+}
+
+func TestSecondaryReadPolicyPrimaryTimeoutExcludesBody(t *testing.T) {
+	// the primary returns headers immediately and the body after PrimaryTimeout has elapsed
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("primary"))
+	}))
+	defer primary.Close()
+	_, secondary, host := newSecondaryReadTestServers(t)
+	secondary.SetResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte("secondary")))
+	o := &policy.SecondaryReadOptions{Host: host, PrimaryTimeout: 50 * time.Millisecond}
+	tp := &hostCountingTransport{}
+	resp := doSecondaryRead(t, tp, o, http.MethodGet, primary.URL)
+	u, _ := url.Parse(primary.URL)
+	requireSecondaryReadResponse(t, resp, http.StatusOK, u.Host, "primary")
+	if tp.requests(host) != 0 {
+		t.Fatal("unexpected request to the secondary")
+	}
+
+	// the caller reads the body of a response whose download is skipped
+	req, err := NewRequest(context.Background(), http.MethodGet, primary.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SkipBodyDownload()
+	resp, err = NewPipeline(tp, NewSecondaryReadPolicy(o)).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "primary" {
+		t.Fatalf("unexpected body %q", string(b))
+	}
+}
+
+func TestSecondaryReadPolicyHedged(t *testing.T) {
+	primary, secondary, host := newSecondaryReadTestServers(t)
+	primary.SetResponse(mock.WithSlowResponse(time.Second))
+	secondary.SetResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte("secondary")))
+	start := time.Now()
+	resp := doSecondaryRead(t, nil, &policy.SecondaryReadOptions{Host: host, HedgeDelay: 20 * time.Millisecond}, http.MethodGet, primary.URL())
+	if time.Since(start) >= time.Second {
+		t.Fatal("the read waited for the primary")
+	}
+	requireSecondaryReadResponse(t, resp, http.StatusOK, host, "secondary")
+}
+
+func TestSecondaryReadPolicyHedgePrimaryWins(t *testing.T) {
+	primary, _, host := newSecondaryReadTestServers(t)
+	primary.SetResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte("primary")))
+	tp := &hostCountingTransport{}
+	resp := doSecondaryRead(t, tp, &policy.SecondaryReadOptions{Host: host, HedgeDelay: time.Second}, http.MethodGet, primary.URL())
+	u, _ := url.Parse(primary.URL())
+	requireSecondaryReadResponse(t, resp, http.StatusOK, u.Host, "primary")
+	if tp.requests(host) != 0 {
+		t.Fatal("unexpected request to the secondary")
+	}
+}
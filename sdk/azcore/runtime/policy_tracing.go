@@ -0,0 +1,125 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/tracing"
+)
+
+const (
+	attrHTTPMethod         = "http.method"
+	attrHTTPURL            = "http.url"
+	attrHTTPStatusCode     = "http.status_code"
+	attrHTTPResendCount    = "http.resend_count"
+	attrAzClientRequestID  = "az.client_request_id"
+	attrAzServiceRequestID = "az.service_request_id"
+)
+
+type tracingPolicy struct {
+	tracer tracing.Tracer
+}
+
+// tracingPolicyOpValues is the struct containing the per-operation values
+type tracingPolicyOpValues struct {
+	tracer tracing.Tracer
+	try    int32
+}
+
+// NewTracingPolicy creates a policy object that creates a span for each API call.
+// A child span is created for each try of the HTTP request and the W3C traceparent header is
+// set to propagate the trace to the service.  Place this policy before the retry policy.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+// When no Tracer is specified the policy is a no-op.
+func NewTracingPolicy(o *policy.TracingOptions) policy.Policy {
+	if o == nil {
+		o = &policy.TracingOptions{}
+	}
+	return &tracingPolicy{tracer: o.Tracer}
+}
+
+func (p *tracingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if p.tracer == nil {
+		return req.Next()
+	}
+	spanName, ok := req.Raw().Context().Value(shared.CtxWithOperationNameKey{}).(string)
+	if !ok || spanName == "" {
+		spanName = "HTTP " + req.Raw().Method
+	}
+	ctx, span := p.tracer.Start(req.Raw().Context(), spanName, &tracing.SpanOptions{
+		Kind: tracing.SpanKindClient,
+		Attributes: []tracing.Attribute{
+			{Key: attrHTTPMethod, Value: req.Raw().Method},
+			{Key: attrHTTPURL, Value: tracingURL(req)},
+		},
+	})
+	defer span.End()
+	// the per-try spans are created by tracingTryPolicy using the same tracer
+	req.SetOperationValue(tracingPolicyOpValues{tracer: p.tracer})
+	resp, err := req.Clone(ctx).Next()
+	endSpan(span, resp, err)
+	return resp, err
+}
+
+// tracingTryPolicy creates a child span for each try of an HTTP request.
+// It's a no-op unless the request passed through the tracing policy.
+func tracingTryPolicy(req *policy.Request) (*http.Response, error) {
+	var opValues tracingPolicyOpValues
+	if req.OperationValue(&opValues); opValues.tracer == nil {
+		return req.Next()
+	}
+	opValues.try++
+	req.SetOperationValue(opValues)
+	attrs := []tracing.Attribute{
+		{Key: attrHTTPMethod, Value: req.Raw().Method},
+		{Key: attrHTTPURL, Value: tracingURL(req)},
+	}
+	if opValues.try > 1 {
+		attrs = append(attrs, tracing.Attribute{Key: attrHTTPResendCount, Value: int(opValues.try - 1)})
+	}
+	if id := req.Raw().Header.Get(shared.HeaderXMSClientRequestID); id != "" {
+		attrs = append(attrs, tracing.Attribute{Key: attrAzClientRequestID, Value: id})
+	}
+	ctx, span := opValues.tracer.Start(req.Raw().Context(), "HTTP "+req.Raw().Method, &tracing.SpanOptions{
+		Kind:       tracing.SpanKindClient,
+		Attributes: attrs,
+	})
+	defer span.End()
+	req = req.Clone(ctx)
+	if tp := span.SpanContext().TraceParent(); tp != "" {
+		req.Raw().Header.Set(shared.HeaderTraceParent, tp)
+	}
+	resp, err := req.Next()
+	endSpan(span, resp, err)
+	return resp, err
+}
+
+// endSpan records the outcome of the HTTP request on the span.
+func endSpan(span tracing.Span, resp *http.Response, err error) {
+	if err != nil {
+		span.SetStatus(tracing.SpanStatusError, err.Error())
+		return
+	}
+	span.SetAttributes(tracing.Attribute{Key: attrHTTPStatusCode, Value: resp.StatusCode})
+	if id := resp.Header.Get(shared.HeaderXMSRequestID); id != "" {
+		span.SetAttributes(tracing.Attribute{Key: attrAzServiceRequestID, Value: id})
+	}
+	if resp.StatusCode >= 400 {
+		span.SetStatus(tracing.SpanStatusError, resp.Status)
+	}
+}
+
+// tracingURL returns the request URL without any query parameters as they can contain secrets.
+func tracingURL(req *policy.Request) string {
+	u := *req.Raw().URL
+	u.RawQuery = ""
+	u.User = nil
+	return u.String()
+}
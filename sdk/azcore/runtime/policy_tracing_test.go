@@ -0,0 +1,170 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/tracing"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent *testSpan
+	sc     tracing.SpanContext
+	attrs  map[string]interface{}
+	status tracing.SpanStatus
+	ended  bool
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+func (s *testSpan) SetAttributes(attrs ...tracing.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *testSpan) SetStatus(code tracing.SpanStatus, desc string) {
+	s.status = code
+}
+
+func (s *testSpan) SpanContext() tracing.SpanContext {
+	return s.sc
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (tr *testTracer) Start(ctx context.Context, name string, o *tracing.SpanOptions) (context.Context, tracing.Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	span := &testSpan{name: name, attrs: map[string]interface{}{}}
+	span.sc.TraceID[0] = 1
+	span.sc.SpanID[0] = byte(len(tr.spans) + 1)
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.parent = parent
+		span.sc.TraceID = parent.sc.TraceID
+	}
+	if o != nil {
+		span.SetAttributes(o.Attributes...)
+	}
+	tr.spans = append(tr.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func TestTracingPolicyNoTracer(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse()
+	pl := NewPipeline(srv, NewTracingPolicy(nil))
+	req, err := NewRequest(context.Background(), http.MethodGet, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp := resp.Request.Header.Get("traceparent"); tp != "" {
+		t.Fatalf("unexpected traceparent %s", tp)
+	}
+}
+
+func TestTracingPolicyWithRetries(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusServiceUnavailable))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK), mock.WithHeader("x-ms-request-id", "abc123"))
+	tracer := &testTracer{}
+	pl := NewPipeline(srv, NewTracingPolicy(&policy.TracingOptions{Tracer: tracer}), NewRetryPolicy(testRetryOptions()))
+	req, err := NewRequest(policy.WithOperationName(context.Background(), "Widgets.Get"), http.MethodGet, srv.URL()+"?sig=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracer.spans) != 3 {
+		t.Fatalf("unexpected span count %d", len(tracer.spans))
+	}
+	call := tracer.spans[0]
+	if call.name != "Widgets.Get" {
+		t.Fatalf("unexpected span name %s", call.name)
+	}
+	if call.attrs[attrHTTPURL] != srv.URL() {
+		t.Fatalf("unexpected URL %v", call.attrs[attrHTTPURL])
+	}
+	if call.attrs[attrHTTPStatusCode] != http.StatusOK {
+		t.Fatalf("unexpected status code %v", call.attrs[attrHTTPStatusCode])
+	}
+	for i, try := range tracer.spans[1:] {
+		if try.parent != call {
+			t.Fatalf("try %d isn't a child of the call span", i+1)
+		}
+		if !try.ended {
+			t.Fatalf("try %d span wasn't ended", i+1)
+		}
+	}
+	if tracer.spans[1].status != tracing.SpanStatusError {
+		t.Fatalf("unexpected status %v", tracer.spans[1].status)
+	}
+	if _, ok := tracer.spans[1].attrs[attrHTTPResendCount]; ok {
+		t.Fatal("unexpected resend count on first try")
+	}
+	if rc := tracer.spans[2].attrs[attrHTTPResendCount]; rc != 1 {
+		t.Fatalf("unexpected resend count %v", rc)
+	}
+	if id := tracer.spans[2].attrs[attrAzServiceRequestID]; id != "abc123" {
+		t.Fatalf("unexpected request ID %v", id)
+	}
+	if !call.ended {
+		t.Fatal("call span wasn't ended")
+	}
+	if tp := resp.Request.Header.Get("traceparent"); tp != tracer.spans[2].sc.TraceParent() {
+		t.Fatalf("unexpected traceparent %s", tp)
+	}
+}
+
+func TestTracingPolicyError(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetError(errors.New("failed"))
+	tracer := &testTracer{}
+	pl := NewPipeline(srv, NewTracingPolicy(&policy.TracingOptions{Tracer: tracer}))
+	req, err := NewRequest(context.Background(), http.MethodPut, srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pl.Do(req); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+	if len(tracer.spans) != 2 {
+		t.Fatalf("unexpected span count %d", len(tracer.spans))
+	}
+	if name := tracer.spans[0].name; name != "HTTP PUT" {
+		t.Fatalf("unexpected span name %s", name)
+	}
+	for _, span := range tracer.spans {
+		if span.status != tracing.SpanStatusError {
+			t.Fatalf("unexpected status %v", span.status)
+		}
+	}
+}
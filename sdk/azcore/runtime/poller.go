@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pipeline"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pollers"
@@ -19,9 +20,61 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
 )
 
+// PollUntilDoneOptions contains the optional values for Poller.PollUntilDoneWithOptions.
+type PollUntilDoneOptions = pollers.PollUntilDoneOptions
+
+// PollingProgress describes the progress of a long-running operation.
+// It's passed to PollUntilDoneOptions.Progress after each poll.
+type PollingProgress = pollers.Progress
+
+// PollingTimeoutError is returned by Poller.PollUntilDoneWithOptions when PollUntilDoneOptions.Timeout
+// elapses before the LRO reaches a terminal state.  It contains a resume token for resuming polling later.
+type PollingTimeoutError = pollers.TimeoutError
+
+// PollingHandler tracks the state of a long-running operation polled by a PollingStrategy.
+// The handler is marshalled to JSON when creating a resume token, so its state must be in exported fields.
+// A handler may also implement StatusCodeValid(*http.Response) bool to accept polling responses with status
+// codes other than 200, 201, 202 and 204, e.g. a 404 when polling until a resource has been deleted.
+type PollingHandler interface {
+	// Done returns true if the LRO has reached a terminal state.
+	Done() bool
+
+	// Update updates the handler's state from the response to a polling request.
+	Update(resp *http.Response) error
+
+	// URL returns the URL to poll.
+	URL() string
+
+	// FinalGetURL returns the URL from which to GET the LRO's result.
+	// Return the empty string when the last polling response contains the result.
+	FinalGetURL() string
+
+	// Status returns the LRO's status, e.g. "InProgress" or "Succeeded".
+	// The statuses "Failed" and "Canceled" indicate the LRO has failed.
+	Status() string
+}
+
+// PollingStrategy creates PollingHandlers for a pattern of long-running operation.
+// Pass strategies to NewPoller and NewPollerFromResumeToken to poll LROs the built-in strategies don't understand.
+type PollingStrategy interface {
+	// Kind identifies the strategy in resume tokens.  It must be unique among the strategies
+	// passed to NewPoller and not contain a semicolon.
+	Kind() string
+
+	// Applicable returns true if the strategy can poll the LRO started by the initial response.
+	Applicable(resp *http.Response) bool
+
+	// New creates a PollingHandler for the LRO started by the initial response.
+	New(resp *http.Response) (PollingHandler, error)
+
+	// Resume creates a PollingHandler from its state, as marshalled into a resume token.
+	Resume(state []byte) (PollingHandler, error)
+}
+
 // NewPoller creates a Poller based on the provided initial response.
 // pollerID - a unique identifier for an LRO, it's usually the client.Method string.
-func NewPoller(pollerID string, resp *http.Response, pl pipeline.Pipeline, eu func(*http.Response) error) (*pollers.Poller, error) {
+// strategies - optional polling strategies, consulted in order before the built-in Operation-Location and Location strategies.
+func NewPoller(pollerID string, resp *http.Response, pl pipeline.Pipeline, eu func(*http.Response) error, strategies ...PollingStrategy) (*pollers.Poller, error) {
 	defer resp.Body.Close()
 	// this is a back-stop in case the swagger is incorrect (i.e. missing one or more status codes for success).
 	// ideally the codegen should return an error if the initial response failed and not even create a poller.
@@ -31,6 +84,16 @@ func NewPoller(pollerID string, resp *http.Response, pl pipeline.Pipeline, eu fu
 	// determine the polling method
 	var lro pollers.Operation
 	var err error
+	for _, s := range strategies {
+		if s.Applicable(resp) {
+			log.Writef(log.LongRunningOperation, "Using %s poller.", s.Kind())
+			lro, err = newStrategyOperation(pollerID, s, resp)
+			if err != nil {
+				return nil, err
+			}
+			return pollers.NewPoller(lro, resp, pl, eu), nil
+		}
+	}
 	// op poller must be checked first as it can also have a location header
 	if op.Applicable(resp) {
 		lro, err = op.New(resp, pollerID)
@@ -47,11 +110,22 @@ func NewPoller(pollerID string, resp *http.Response, pl pipeline.Pipeline, eu fu
 
 // NewPollerFromResumeToken creates a Poller from a resume token string.
 // pollerID - a unique identifier for an LRO, it's usually the client.Method string.
-func NewPollerFromResumeToken(pollerID string, token string, pl pipeline.Pipeline, eu func(*http.Response) error) (*pollers.Poller, error) {
+// strategies - the polling strategies passed to NewPoller when creating the poller that returned the token.
+func NewPollerFromResumeToken(pollerID string, token string, pl pipeline.Pipeline, eu func(*http.Response) error, strategies ...PollingStrategy) (*pollers.Poller, error) {
 	kind, err := pollers.KindFromToken(pollerID, token)
 	if err != nil {
 		return nil, err
 	}
+	for _, s := range strategies {
+		if s.Kind() == kind {
+			log.Writef(log.LongRunningOperation, "Resuming %s poller.", kind)
+			lro, err := resumeStrategyOperation(s, token)
+			if err != nil {
+				return nil, err
+			}
+			return pollers.NewPoller(lro, nil, pl, eu), nil
+		}
+	}
 	// now rehydrate the poller based on the encoded poller type
 	var lro pollers.Operation
 	switch kind {
@@ -69,3 +143,160 @@ func NewPollerFromResumeToken(pollerID string, token string, pl pipeline.Pipelin
 	}
 	return pollers.NewPoller(lro, nil, pl, eu), nil
 }
+
+// strategyOperation adapts a PollingHandler to a pollers.Operation.
+// Its resume token contains the poller type and the handler's state.
+type strategyOperation struct {
+	Type    string          `json:"type"`
+	State   json.RawMessage `json:"state"`
+	handler PollingHandler
+}
+
+func newStrategyOperation(pollerID string, s PollingStrategy, resp *http.Response) (*strategyOperation, error) {
+	if s.Kind() == "" || strings.Contains(s.Kind(), ";") {
+		return nil, fmt.Errorf("invalid polling strategy kind %q", s.Kind())
+	}
+	h, err := s.New(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &strategyOperation{Type: pollers.MakeID(pollerID, s.Kind()), handler: h}, nil
+}
+
+func resumeStrategyOperation(s PollingStrategy, token string) (*strategyOperation, error) {
+	so := &strategyOperation{}
+	if err := json.Unmarshal([]byte(token), so); err != nil {
+		return nil, err
+	}
+	h, err := s.Resume(so.State)
+	if err != nil {
+		return nil, err
+	}
+	so.handler = h
+	return so, nil
+}
+
+// MarshalJSON implements the json.Marshaller interface for type strategyOperation.
+func (s *strategyOperation) MarshalJSON() ([]byte, error) {
+	state, err := json.Marshal(s.handler)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Type  string          `json:"type"`
+		State json.RawMessage `json:"state"`
+	}{Type: s.Type, State: state})
+}
+
+func (s *strategyOperation) Done() bool {
+	return s.handler.Done()
+}
+
+func (s *strategyOperation) Update(resp *http.Response) error {
+	return s.handler.Update(resp)
+}
+
+func (s *strategyOperation) URL() string {
+	return s.handler.URL()
+}
+
+func (s *strategyOperation) FinalGetURL() string {
+	return s.handler.FinalGetURL()
+}
+
+func (s *strategyOperation) Status() string {
+	return s.handler.Status()
+}
+
+func (s *strategyOperation) StatusCodeValid(resp *http.Response) bool {
+	if v, ok := s.handler.(pollers.StatusCodeValidator); ok {
+		return v.StatusCodeValid(resp)
+	}
+	return pollers.StatusCodeValid(resp)
+}
+
+// ResourcePollingStrategy is a PollingStrategy that polls a resource with GET requests until
+// it exists or, when UntilDeleted is true, until it has been deleted.  It's applicable to
+// any initial response, so the LRO's result is the final polling response.
+// When polling until deletion the final response has no body.
+type ResourcePollingStrategy struct {
+	// URL is the resource's URL.
+	URL string
+
+	// UntilDeleted specifies polling until the resource returns 404 rather than until it exists.
+	UntilDeleted bool
+}
+
+// Kind implements the PollingStrategy interface for type ResourcePollingStrategy.
+func (ResourcePollingStrategy) Kind() string {
+	return resourceKind
+}
+
+// Applicable implements the PollingStrategy interface for type ResourcePollingStrategy.
+func (ResourcePollingStrategy) Applicable(*http.Response) bool {
+	return true
+}
+
+// New implements the PollingStrategy interface for type ResourcePollingStrategy.
+func (r ResourcePollingStrategy) New(*http.Response) (PollingHandler, error) {
+	if !pollers.IsValidURL(r.URL) {
+		return nil, fmt.Errorf("invalid polling URL %s", r.URL)
+	}
+	return &resourceHandler{PollURL: r.URL, UntilDeleted: r.UntilDeleted, CurState: pollers.StatusInProgress}, nil
+}
+
+// Resume implements the PollingStrategy interface for type ResourcePollingStrategy.
+func (ResourcePollingStrategy) Resume(state []byte) (PollingHandler, error) {
+	h := &resourceHandler{}
+	if err := json.Unmarshal(state, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+const resourceKind = "Resource"
+
+type resourceHandler struct {
+	PollURL      string `json:"pollURL"`
+	UntilDeleted bool   `json:"untilDeleted"`
+	CurState     string `json:"state"`
+}
+
+func (h *resourceHandler) Done() bool {
+	return pollers.IsTerminalState(h.CurState)
+}
+
+func (h *resourceHandler) Update(resp *http.Response) error {
+	exists := resp.StatusCode >= 200 && resp.StatusCode < 300
+	switch {
+	case resp.StatusCode == http.StatusNotFound && h.UntilDeleted:
+		h.CurState = pollers.StatusSucceeded
+		// the deleted resource has no result
+		resp.Body = http.NoBody
+		resp.ContentLength = 0
+	case resp.StatusCode == http.StatusNotFound || (exists && h.UntilDeleted):
+		h.CurState = pollers.StatusInProgress
+	case exists:
+		h.CurState = pollers.StatusSucceeded
+	default:
+		h.CurState = pollers.StatusFailed
+	}
+	return nil
+}
+
+func (h *resourceHandler) URL() string {
+	return h.PollURL
+}
+
+func (*resourceHandler) FinalGetURL() string {
+	return ""
+}
+
+func (h *resourceHandler) Status() string {
+	return h.CurState
+}
+
+// StatusCodeValid accepts 404s as they indicate the resource doesn't exist (yet).
+func (*resourceHandler) StatusCodeValid(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusNotFound || pollers.StatusCodeValid(resp)
+}
@@ -0,0 +1,73 @@
+//go:build go1.18
+// +build go1.18
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/pollers"
+)
+
+// Poller encapsulates a long-running operation whose final result is of type T.
+type Poller[T any] struct {
+	pt *pollers.Poller
+}
+
+// AsPoller returns a Poller[T] that wraps the specified poller.
+// The final result of the long-running operation is unmarshalled into a T.
+// Use this with pollers created by NewPoller and NewPollerFromResumeToken (or their ARM equivalents).
+func AsPoller[T any](p *pollers.Poller) *Poller[T] {
+	return &Poller[T]{pt: p}
+}
+
+// Done returns true if the LRO has reached a terminal state.
+func (p *Poller[T]) Done() bool {
+	return p.pt.Done()
+}
+
+// Poll sends a polling request to the polling endpoint and returns the response or error.
+func (p *Poller[T]) Poll(ctx context.Context) (*http.Response, error) {
+	return p.pt.Poll(ctx)
+}
+
+// Result returns the result of the LRO.  It's only valid to call this once the LRO has reached a terminal state.
+func (p *Poller[T]) Result(ctx context.Context) (T, error) {
+	var result T
+	if _, err := p.pt.FinalResponse(ctx, &result); err != nil {
+		return *new(T), err
+	}
+	return result, nil
+}
+
+// PollUntilDone polls the LRO until a terminal state is reached then returns its result.
+// freq - the time to wait between polling intervals if the endpoint doesn't send a Retry-After header.
+//        A good starting value is 30 seconds.  Note that some resources might benefit from a different value.
+func (p *Poller[T]) PollUntilDone(ctx context.Context, freq time.Duration) (T, error) {
+	var result T
+	if _, err := p.pt.PollUntilDone(ctx, freq, &result); err != nil {
+		return *new(T), err
+	}
+	return result, nil
+}
+
+// PollUntilDoneWithOptions is like PollUntilDone, with optional progress reporting, exponential
+// backoff and an overall timeout.  Pass nil to accept the default values.
+// When the timeout elapses, the returned error is a *PollingTimeoutError containing a resume token.
+func (p *Poller[T]) PollUntilDoneWithOptions(ctx context.Context, options *PollUntilDoneOptions) (T, error) {
+	var result T
+	if _, err := p.pt.PollUntilDoneWithOptions(ctx, options, &result); err != nil {
+		return *new(T), err
+	}
+	return result, nil
+}
+
+// ResumeToken returns a token string that can be used to resume a poller that has not yet reached a terminal state.
+func (p *Poller[T]) ResumeToken() (string, error) {
+	return p.pt.ResumeToken()
+}
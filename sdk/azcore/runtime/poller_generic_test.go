@@ -0,0 +1,90 @@
+//go:build go1.18
+// +build go1.18
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+func TestGenericPollerPollUntilDone(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusAccepted))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte(`{"size": 3}`)))
+	firstResp := &http.Response{
+		StatusCode: http.StatusAccepted,
+		Header: http.Header{
+			"Location": []string{srv.URL()},
+		},
+		Body: http.NoBody,
+	}
+	lro, err := NewPoller("fake.poller", firstResp, NewPipeline(srv), errUnmarshall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poller := AsPoller[widget](lro)
+	if poller.Done() {
+		t.Fatal("poller shouldn't be done yet")
+	}
+	tk, err := poller.ResumeToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk == "" {
+		t.Fatal("unexpected empty resume token")
+	}
+	w, err := poller.PollUntilDone(context.Background(), 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Size != 3 {
+		t.Fatalf("unexpected widget size %d", w.Size)
+	}
+	if !poller.Done() {
+		t.Fatal("poller should be done")
+	}
+}
+
+func TestGenericPollerResult(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte(`{"size": 2}`)))
+	firstResp := &http.Response{
+		StatusCode: http.StatusAccepted,
+		Header: http.Header{
+			"Location": []string{srv.URL()},
+		},
+		Body: http.NoBody,
+	}
+	lro, err := NewPoller("fake.poller", firstResp, NewPipeline(srv), errUnmarshall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poller := AsPoller[widget](lro)
+	if _, err := poller.Result(context.Background()); err == nil {
+		t.Fatal("unexpected nil error for non-terminal poller")
+	}
+	resp, err := poller.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	w, err := poller.Result(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Size != 2 {
+		t.Fatalf("unexpected widget size %d", w.Size)
+	}
+}
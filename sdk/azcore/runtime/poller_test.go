@@ -8,6 +8,7 @@ package runtime
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -670,3 +671,247 @@ func TestNopPoller(t *testing.T) {
 		t.Fatal("expected empty token")
 	}
 }
+
+// countingHandler is done after a fixed number of polls
+type countingHandler struct {
+	PollURL string `json:"pollURL"`
+	Polls   int    `json:"polls"`
+}
+
+func (h *countingHandler) Done() bool {
+	return h.Polls >= 2
+}
+
+func (h *countingHandler) Update(*http.Response) error {
+	h.Polls++
+	return nil
+}
+
+func (h *countingHandler) URL() string {
+	return h.PollURL
+}
+
+func (*countingHandler) FinalGetURL() string {
+	return ""
+}
+
+func (h *countingHandler) Status() string {
+	if h.Done() {
+		return pollers.StatusSucceeded
+	}
+	return pollers.StatusInProgress
+}
+
+type countingStrategy struct {
+	url string
+}
+
+func (countingStrategy) Kind() string {
+	return "Counting"
+}
+
+func (countingStrategy) Applicable(resp *http.Response) bool {
+	return resp.Header.Get("x-counting") != ""
+}
+
+func (c countingStrategy) New(*http.Response) (PollingHandler, error) {
+	return &countingHandler{PollURL: c.url}, nil
+}
+
+func (countingStrategy) Resume(state []byte) (PollingHandler, error) {
+	h := &countingHandler{}
+	err := json.Unmarshal(state, h)
+	return h, err
+}
+
+func TestPollerWithStrategy(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte(`{"size": 3}`)))
+	pl := NewPipeline(srv)
+	firstResp := &http.Response{
+		StatusCode: http.StatusAccepted,
+		// the custom strategy takes precedence over the built-in Location strategy
+		Header: http.Header{"X-Counting": []string{"true"}, "Location": []string{"https://contoso.invalid"}},
+		Body:   http.NoBody,
+	}
+	lro, err := NewPoller("fake.poller", firstResp, pl, errUnmarshall, countingStrategy{url: srv.URL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt := pollers.PollerType(lro); pt != reflect.TypeOf(&strategyOperation{}) {
+		t.Fatalf("unexpected poller type %s", pt.String())
+	}
+	if _, err = lro.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tk, err := lro.ResumeToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewPollerFromResumeToken("fake.poller", tk, pl, errUnmarshall); err == nil {
+		t.Fatal("expected an error resuming without the strategy")
+	}
+	lro, err = NewPollerFromResumeToken("fake.poller", tk, pl, errUnmarshall, countingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w widget
+	if _, err = lro.PollUntilDone(context.Background(), time.Millisecond, &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Size != 3 {
+		t.Fatalf("unexpected widget size %d", w.Size)
+	}
+	if r := srv.Requests(); r != 2 {
+		t.Fatalf("unexpected request count %d", r)
+	}
+}
+
+func TestResourcePollingStrategy(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusNotFound), mock.WithBody([]byte(`{"error": "not found"}`)))
+	srv.AppendResponse(mock.WithBody([]byte(`{"size": 3}`)))
+	pl := NewPipeline(srv)
+	lro, err := NewPoller("fake.poller", &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, pl, errUnmarshall, ResourcePollingStrategy{URL: srv.URL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w widget
+	if _, err = lro.PollUntilDone(context.Background(), time.Millisecond, &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Size != 3 {
+		t.Fatalf("unexpected widget size %d", w.Size)
+	}
+}
+
+func TestResourcePollingStrategyUntilDeleted(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithBody([]byte(`{"size": 3}`)))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusNotFound), mock.WithBody([]byte(`{"error": "not found"}`)))
+	pl := NewPipeline(srv)
+	strategy := ResourcePollingStrategy{URL: srv.URL(), UntilDeleted: true}
+	lro, err := NewPoller("fake.poller", &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, pl, errUnmarshall, strategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lro.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tk, err := lro.ResumeToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lro, err = NewPollerFromResumeToken("fake.poller", tk, pl, errUnmarshall, strategy); err != nil {
+		t.Fatal(err)
+	}
+	var w widget
+	resp, err := lro.PollUntilDone(context.Background(), time.Millisecond, &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound || w.Size != 0 {
+		t.Fatalf("unexpected result %d %v", resp.StatusCode, w)
+	}
+	// other errors fail the LRO
+	srv.AppendResponse(mock.WithStatusCode(http.StatusForbidden), mock.WithBody([]byte(`{"error": "forbidden"}`)))
+	if lro, err = NewPoller("fake.poller", &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, pl, errUnmarshall, strategy); err != nil {
+		t.Fatal(err)
+	}
+	var pe pollerError
+	if _, err = lro.PollUntilDone(context.Background(), time.Millisecond, nil); !errors.As(err, &pe) || pe.Err != "forbidden" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestPollUntilDoneWithOptionsProgress(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusAccepted), mock.WithBody([]byte(`{"percentComplete": 25}`)))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusAccepted), mock.WithBody([]byte(`{"percentComplete": "75.5"}`)))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusOK), mock.WithBody([]byte(`{"size": 3}`)))
+	p, err := NewPoller("fake.poller", &http.Response{
+		StatusCode: http.StatusAccepted,
+		Header:     http.Header{"Location": []string{srv.URL()}},
+		Body:       http.NoBody,
+	}, NewPipeline(srv), errUnmarshall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progress []PollingProgress
+	start := time.Now()
+	var w widget
+	_, err = p.PollUntilDoneWithOptions(context.Background(), &PollUntilDoneOptions{
+		Frequency:    10 * time.Millisecond,
+		MaxFrequency: 20 * time.Millisecond,
+		Progress: func(p PollingProgress) {
+			progress = append(progress, p)
+		},
+	}, &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Size != 3 {
+		t.Fatalf("unexpected size %d", w.Size)
+	}
+	// delays of 10ms then 20ms
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Fatalf("unexpected polling time %s", d)
+	}
+	if len(progress) != 3 {
+		t.Fatalf("unexpected progress %v", progress)
+	}
+	if pc := progress[0].PercentComplete; pc == nil || *pc != 25 || progress[0].Status != pollers.StatusInProgress {
+		t.Fatalf("unexpected progress %+v", progress[0])
+	}
+	if pc := progress[1].PercentComplete; pc == nil || *pc != 75.5 {
+		t.Fatalf("unexpected progress %+v", progress[1])
+	}
+	if progress[2].PercentComplete != nil || progress[2].Status != pollers.StatusSucceeded || progress[2].Elapsed < progress[1].Elapsed {
+		t.Fatalf("unexpected progress %+v", progress[2])
+	}
+}
+
+func TestPollUntilDoneWithOptionsTimeout(t *testing.T) {
+	srv, close := mock.NewServer()
+	defer close()
+	srv.SetResponse(mock.WithStatusCode(http.StatusAccepted))
+	p, err := NewPoller("fake.poller", &http.Response{
+		StatusCode: http.StatusAccepted,
+		Header:     http.Header{"Location": []string{srv.URL()}},
+		Body:       http.NoBody,
+	}, NewPipeline(srv), errUnmarshall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.PollUntilDoneWithOptions(context.Background(), &PollUntilDoneOptions{
+		Frequency: 10 * time.Millisecond,
+		Timeout:   50 * time.Millisecond,
+	}, nil)
+	var timeoutErr *PollingTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected error to wrap context.DeadlineExceeded")
+	}
+	if timeoutErr.ResumeToken == "" || timeoutErr.Elapsed < 50*time.Millisecond {
+		t.Fatalf("unexpected error %+v", timeoutErr)
+	}
+	if _, err = NewPollerFromResumeToken("fake.poller", timeoutErr.ResumeToken, NewPipeline(srv), errUnmarshall); err != nil {
+		t.Fatal(err)
+	}
+	// cancelling the caller's context isn't a timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.PollUntilDoneWithOptions(ctx, &PollUntilDoneOptions{
+		Frequency: 10 * time.Millisecond,
+		Timeout:   time.Hour,
+	}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.As(err, &timeoutErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -52,7 +52,7 @@ func NewPipeline(transport pipeline.Transporter, policies ...pipeline.Policy) pi
 		transport = defaultHTTPClient
 	}
 	// transport policy must always be the last in the slice
-	policies = append(policies, pipeline.PolicyFunc(httpHeaderPolicy), pipeline.PolicyFunc(bodyDownloadPolicy))
+	policies = append(policies, pipeline.PolicyFunc(tracingTryPolicy), pipeline.PolicyFunc(metricsTryPolicy), pipeline.PolicyFunc(httpHeaderPolicy), pipeline.PolicyFunc(bodyDownloadPolicy))
 	return pipeline.NewPipeline(transport, policies...)
 }
 
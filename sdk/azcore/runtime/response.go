@@ -15,11 +15,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"sort"
-	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/internal/shared"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 )
 
 // Payload reads and returns the response body or an error.
@@ -146,42 +143,3 @@ func DecodeByteArray(s string, v *[]byte, format Base64Encoding) error {
 		return fmt.Errorf("unrecognized byte array format: %d", format)
 	}
 }
-
-// writeRequestWithResponse appends a formatted HTTP request into a Buffer. If request and/or err are
-// not nil, then these are also written into the Buffer.
-func writeRequestWithResponse(b *bytes.Buffer, req *policy.Request, resp *http.Response, err error) {
-	// Write the request into the buffer.
-	fmt.Fprint(b, "   "+req.Raw().Method+" "+req.Raw().URL.String()+"\n")
-	writeHeader(b, req.Raw().Header)
-	if resp != nil {
-		fmt.Fprintln(b, "   --------------------------------------------------------------------------------")
-		fmt.Fprint(b, "   RESPONSE Status: "+resp.Status+"\n")
-		writeHeader(b, resp.Header)
-	}
-	if err != nil {
-		fmt.Fprintln(b, "   --------------------------------------------------------------------------------")
-		fmt.Fprint(b, "   ERROR:\n"+err.Error()+"\n")
-	}
-}
-
-// formatHeaders appends an HTTP request's or response's header into a Buffer.
-func writeHeader(b *bytes.Buffer, header http.Header) {
-	if len(header) == 0 {
-		b.WriteString("   (no headers)\n")
-		return
-	}
-	keys := make([]string, 0, len(header))
-	// Alphabetize the headers
-	for k := range header {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		// Redact the value of any Authorization header to prevent security information from persisting in logs
-		value := interface{}("REDACTED")
-		if !strings.EqualFold(k, "Authorization") {
-			value = header[k]
-		}
-		fmt.Fprintf(b, "   %s: %+v\n", k, value)
-	}
-}
@@ -10,6 +10,7 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -18,24 +19,71 @@ import (
 var defaultHTTPClient *http.Client
 
 func init() {
-	defaultTransport := &http.Transport{
+	defaultHTTPClient = &http.Client{
+		Transport: newHTTPTransport(&policy.TransportOptions{}),
+	}
+}
+
+// NewTransport creates a policy.Transporter that sends requests with an *http.Client.  The client's
+// transport starts from the SDK's defaults, which are then overridden by the non-zero values in o.
+// Pass nil to accept the default values; this is the same as passing a zero-value options.
+// Share the returned Transporter among clients so that they share connections.
+func NewTransport(o *policy.TransportOptions) policy.Transporter {
+	if o == nil {
+		o = &policy.TransportOptions{}
+	}
+	return &http.Client{
+		Transport: newHTTPTransport(o),
+	}
+}
+
+func newHTTPTransport(o *policy.TransportOptions) *http.Transport {
+	durationOrDefault := func(d, def time.Duration) time.Duration {
+		if d == 0 {
+			return def
+		}
+		return d
+	}
+	t := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
+			Timeout:   durationOrDefault(o.DialTimeout, 30*time.Second),
+			KeepAlive: durationOrDefault(o.KeepAlive, 30*time.Second),
 		}).DialContext,
-		ForceAttemptHTTP2:     true,
+		ForceAttemptHTTP2:     !o.DisableHTTP2,
+		MaxConnsPerHost:       o.MaxConnsPerHost,
 		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
+		MaxIdleConnsPerHost:   o.MaxIdleConnsPerHost,
+		IdleConnTimeout:       durationOrDefault(o.IdleConnTimeout, 90*time.Second),
+		TLSHandshakeTimeout:   durationOrDefault(o.TLSHandshakeTimeout, 10*time.Second),
 		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: o.ResponseHeaderTimeout,
 		TLSClientConfig: &tls.Config{
-			MinVersion: tls.VersionTLS12,
+			MinVersion:   tls.VersionTLS12,
+			RootCAs:      o.RootCAs,
+			Certificates: o.ClientCertificates,
 		},
 	}
-	defaultHTTPClient = &http.Client{
-		Transport: defaultTransport,
+	if o.MinTLSVersion > tls.VersionTLS12 || (o.MinTLSVersion != 0 && o.AllowInsecureTLSVersion) {
+		t.TLSClientConfig.MinVersion = o.MinTLSVersion
+	}
+	if o.MaxIdleConns != 0 {
+		t.MaxIdleConns = o.MaxIdleConns
+	}
+	if o.DisableHTTP2 {
+		// a non-nil, empty map disables the transport's HTTP/2 support
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
 	}
+	if o.Proxy != nil {
+		proxy := *o.Proxy
+		if o.ProxyUsername != "" || o.ProxyPassword != "" {
+			proxy.User = url.UserPassword(o.ProxyUsername, o.ProxyPassword)
+		}
+		t.Proxy = http.ProxyURL(&proxy)
+	} else if o.DisableProxy {
+		t.Proxy = nil
+	}
+	return t
 }
 
 // AuthenticationOptions contains various options used to create a credential policy.
@@ -47,4 +95,24 @@ type AuthenticationOptions struct {
 	// AuxiliaryTenants contains a list of additional tenant IDs to be used to authenticate
 	// in cross-tenant applications.
 	AuxiliaryTenants []string
+	// AuthorizationHandler customizes how requests are authorized and how authentication
+	// challenges are handled.  The default handles claims challenges.
+	AuthorizationHandler policy.AuthorizationHandler
+	// BackgroundRefresh enables refreshing tokens in the background before they expire, so that
+	// requests don't wait for token acquisition.  Tokens are refreshed only while the policy is in use.
+	// The default is nil, which refreshes a token when a request finds it's about to expire.
+	BackgroundRefresh *TokenRefreshOptions
+}
+
+// TokenRefreshOptions configures refreshing tokens in the background.
+type TokenRefreshOptions struct {
+	// Window is how long before a token expires to refresh it.  The default is five minutes.
+	Window time.Duration
+	// Jitter is the maximum random amount by which each refresh is brought forward, which spreads
+	// the refreshes of many clients.  The default is 30 seconds.  Specify a negative value to disable it.
+	Jitter time.Duration
+	// OnError is called when a background refresh fails.  The policy keeps using the cached token
+	// and retries the refresh until the token expires.  Failures are also logged with the
+	// Authentication classification.
+	OnError func(error)
 }
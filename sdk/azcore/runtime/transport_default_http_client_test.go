@@ -0,0 +1,114 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package runtime
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+func TestNewTransportDefaults(t *testing.T) {
+	tr := NewTransport(nil).(*http.Client).Transport.(*http.Transport)
+	def := defaultHTTPClient.Transport.(*http.Transport)
+	if tr == def {
+		t.Fatal("expected a new transport")
+	}
+	if tr.MaxIdleConns != 100 || tr.IdleConnTimeout != 90*time.Second || tr.TLSHandshakeTimeout != 10*time.Second {
+		t.Fatalf("unexpected transport settings %+v", tr)
+	}
+	if !tr.ForceAttemptHTTP2 || tr.TLSNextProto != nil {
+		t.Fatal("expected HTTP/2 to be enabled")
+	}
+	if tr.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("unexpected min TLS version %d", tr.TLSClientConfig.MinVersion)
+	}
+	if tr.Proxy == nil {
+		t.Fatal("expected proxy from environment")
+	}
+}
+
+func TestNewTransportOptions(t *testing.T) {
+	roots := x509.NewCertPool()
+	tr := NewTransport(&policy.TransportOptions{
+		RootCAs:             roots,
+		MinTLSVersion:       tls.VersionTLS13,
+		MaxConnsPerHost:     5,
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     time.Minute,
+		DisableHTTP2:        true,
+		DisableProxy:        true,
+	}).(*http.Client).Transport.(*http.Transport)
+	if tr.TLSClientConfig.RootCAs != roots || tr.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("unexpected TLS config %+v", tr.TLSClientConfig)
+	}
+	if tr.MaxConnsPerHost != 5 || tr.MaxIdleConns != 10 || tr.MaxIdleConnsPerHost != 4 || tr.IdleConnTimeout != time.Minute {
+		t.Fatalf("unexpected connection settings %+v", tr)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil {
+		t.Fatal("expected HTTP/2 to be disabled")
+	}
+	if tr.Proxy != nil {
+		t.Fatal("expected no proxy")
+	}
+}
+
+func TestNewTransportInsecureTLSVersion(t *testing.T) {
+	tr := NewTransport(&policy.TransportOptions{MinTLSVersion: tls.VersionTLS10}).(*http.Client).Transport.(*http.Transport)
+	if tr.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("expected an insecure version to be ignored, got %d", tr.TLSClientConfig.MinVersion)
+	}
+	tr = NewTransport(&policy.TransportOptions{MinTLSVersion: tls.VersionTLS10, AllowInsecureTLSVersion: true}).(*http.Client).Transport.(*http.Transport)
+	if tr.TLSClientConfig.MinVersion != tls.VersionTLS10 {
+		t.Fatalf("unexpected min TLS version %d", tr.TLSClientConfig.MinVersion)
+	}
+}
+
+func TestNewTransportProxy(t *testing.T) {
+	var proxyAuth, target string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		target = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	u, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := NewTransport(&policy.TransportOptions{Proxy: u, ProxyUsername: "user", ProxyPassword: "pass"})
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://contoso.invalid/path", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := tr.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	if target != "http://contoso.invalid/path" {
+		t.Fatalf("unexpected target %s", target)
+	}
+	// base64 of "user:pass"
+	if proxyAuth != "Basic dXNlcjpwYXNz" {
+		t.Fatalf("unexpected Proxy-Authorization %s", proxyAuth)
+	}
+	if u.User != nil {
+		t.Fatal("options' proxy URL was modified")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright 2017 Microsoft Corporation. All rights reserved.
+// Use of this source code is governed by an MIT
+// license that can be found in the LICENSE file.
+
+// Package tracing contains the definitions needed to plug a distributed tracing
+// implementation into the pipeline.  Adapters for tracing libraries such as
+// OpenTelemetry implement the Tracer and Span interfaces.
+package tracing
@@ -0,0 +1,152 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tracer creates spans.
+type Tracer interface {
+	// Start creates a new span.  If ctx contains a span, the new span is a child of it.
+	// The returned context contains the new span and must be used for any child spans.
+	Start(ctx context.Context, spanName string, options *SpanOptions) (context.Context, Span)
+}
+
+// SpanOptions contains optional settings for creating a span.
+type SpanOptions struct {
+	// Kind indicates the kind of Span.
+	Kind SpanKind
+
+	// Attributes contains key-value pairs of attributes for the span.
+	Attributes []Attribute
+}
+
+// Span is a single unit of a trace.
+type Span interface {
+	// End terminates the span.  It must be called exactly once.
+	End()
+
+	// SetAttributes sets the specified attributes on the span.
+	SetAttributes(attrs ...Attribute)
+
+	// SetStatus sets the status on the span along with a description.
+	SetStatus(code SpanStatus, description string)
+
+	// SpanContext returns the identifiers of the span.
+	// They are used to propagate the trace to the service.
+	SpanContext() SpanContext
+}
+
+// Attribute is a key-value pair.
+type Attribute struct {
+	// Key is the name of the attribute.
+	Key string
+
+	// Value is the attribute's value.
+	// Types that are natively supported include int64, float64, int, bool, string.
+	Value interface{}
+}
+
+// SpanKind represents the role of a span within a trace.
+type SpanKind int
+
+const (
+	// SpanKindInternal indicates the span represents an internal operation within an application.
+	SpanKindInternal SpanKind = 1
+
+	// SpanKindServer indicates the span covers server-side handling of a request.
+	SpanKindServer SpanKind = 2
+
+	// SpanKindClient indicates the span describes a request to a remote service.
+	SpanKindClient SpanKind = 3
+
+	// SpanKindProducer indicates the span was created by a messaging producer.
+	SpanKindProducer SpanKind = 4
+
+	// SpanKindConsumer indicates the span was created by a messaging consumer.
+	SpanKindConsumer SpanKind = 5
+)
+
+// SpanStatus represents the status of a span.
+type SpanStatus int
+
+const (
+	// SpanStatusUnset is the default status code.
+	SpanStatusUnset SpanStatus = 0
+
+	// SpanStatusError indicates the operation contains an error.
+	SpanStatusError SpanStatus = 1
+
+	// SpanStatusOK indicates the operation completed successfully.
+	SpanStatusOK SpanStatus = 2
+)
+
+// SpanContext contains the identifying trace information about a span.
+type SpanContext struct {
+	// TraceID is the ID of the trace containing the span.
+	TraceID [16]byte
+
+	// SpanID is the ID of the span.
+	SpanID [8]byte
+
+	// TraceFlags contains the W3C trace flags, e.g. sampled.
+	TraceFlags byte
+}
+
+// IsValid returns true if both the TraceID and SpanID are non-zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent returns the W3C traceparent header value for the span context.
+// An empty string is returned if the span context isn't valid.
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), sc.TraceFlags)
+}
+
+// ParseTraceParent parses the specified W3C traceparent header value into a SpanContext.
+func ParseTraceParent(traceParent string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", traceParent)
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", traceParent)
+	}
+	sc := SpanContext{}
+	if err := decodeHex(parts[1], sc.TraceID[:]); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid trace ID: %w", err)
+	}
+	if err := decodeHex(parts[2], sc.SpanID[:]); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid span ID: %w", err)
+	}
+	var flags [1]byte
+	if err := decodeHex(parts[3], flags[:]); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid trace flags: %w", err)
+	}
+	sc.TraceFlags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", traceParent)
+	}
+	return sc, nil
+}
+
+func decodeHex(s string, dst []byte) error {
+	if len(s) != hex.EncodedLen(len(dst)) {
+		return errors.New("unexpected length")
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
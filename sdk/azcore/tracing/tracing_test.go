@@ -0,0 +1,49 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package tracing
+
+import (
+	"testing"
+)
+
+func TestSpanContextTraceParent(t *testing.T) {
+	if tp := (SpanContext{}).TraceParent(); tp != "" {
+		t.Fatalf("unexpected traceparent %s", tp)
+	}
+	sc := SpanContext{TraceFlags: 1}
+	sc.TraceID[0] = 0x4b
+	sc.TraceID[15] = 0xf9
+	sc.SpanID[7] = 0x2a
+	const expected = "00-4b0000000000000000000000000000f9-000000000000002a-01"
+	if tp := sc.TraceParent(); tp != expected {
+		t.Fatalf("unexpected traceparent %s", tp)
+	}
+	parsed, err := ParseTraceParent(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != sc {
+		t.Fatalf("unexpected span context %v", parsed)
+	}
+}
+
+func TestParseTraceParentFail(t *testing.T) {
+	for _, tp := range []string{
+		"",
+		"00-4b0000000000000000000000000000f9-000000000000002a",
+		"ff-4b0000000000000000000000000000f9-000000000000002a-01",
+		"00-4b0000000000000000000000000000f9-000000000000002a-01-extra",
+		"00-00000000000000000000000000000000-000000000000002a-01",
+		"00-4b0000000000000000000000000000f9-0000000000000000-01",
+		"00-4b00000000000000000000000000zzf9-000000000000002a-01",
+		"00-4b0000000000000000000000000000f9-000000000000002a-1",
+	} {
+		if _, err := ParseTraceParent(tp); err == nil {
+			t.Fatalf("unexpected nil error for %q", tp)
+		}
+	}
+}
@@ -21,12 +21,15 @@ import (
 
 const (
 	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	jwtBearerGrantType  = "urn:ietf:params:oauth:grant-type:jwt-bearer"
 )
 
 const (
+	qpAssertion           = "assertion"
 	qpClientAssertionType = "client_assertion_type"
 	qpClientAssertion     = "client_assertion"
 	qpClientID            = "client_id"
+	qpClaims              = "claims"
 	qpClientSecret        = "client_secret"
 	qpCode                = "code"
 	qpDeviceCode          = "device_code"
@@ -34,6 +37,7 @@ const (
 	qpPassword            = "password"
 	qpRedirectURI         = "redirect_uri"
 	qpRefreshToken        = "refresh_token"
+	qpRequestedTokenUse   = "requested_token_use"
 	qpResID               = "mi_res_id"
 	qpResponseType        = "response_type"
 	qpScope               = "scope"
@@ -86,7 +90,7 @@ func getError(resp *http.Response) error {
 	} else {
 		msg = fmt.Sprintf("authentication failed: %s", authFailed.Message)
 	}
-	return &AuthenticationFailedError{msg: msg, resp: resp}
+	return newAuthenticationFailedError(msg, resp)
 }
 
 // refreshAccessToken creates a refresh token request and returns the resulting Access Token or
@@ -96,8 +100,9 @@ func getError(resp *http.Response) error {
 // clientID: The client (application) ID of the service principal
 // clientSecret: A client secret that was generated for the App Registration used to authenticate the client
 // scopes: The scopes for the given access token
-func (c *aadIdentityClient) refreshAccessToken(ctx context.Context, tenantID string, clientID string, clientSecret string, refreshToken string, scopes []string) (*tokenResponse, error) {
-	req, err := c.createRefreshTokenRequest(ctx, tenantID, clientID, clientSecret, refreshToken, scopes)
+// claims: Additional claims the token must contain, such as those requested by a claims challenge
+func (c *aadIdentityClient) refreshAccessToken(ctx context.Context, tenantID string, clientID string, clientSecret string, refreshToken string, scopes []string, claims string) (*tokenResponse, error) {
+	req, err := c.createRefreshTokenRequest(ctx, tenantID, clientID, clientSecret, refreshToken, scopes, claims)
 	if err != nil {
 		return nil, err
 	}
@@ -121,8 +126,9 @@ func (c *aadIdentityClient) refreshAccessToken(ctx context.Context, tenantID str
 // clientID: The client (application) ID of the service principal
 // clientSecret: A client secret that was generated for the App Registration used to authenticate the client
 // scopes: The scopes required for the token
-func (c *aadIdentityClient) authenticate(ctx context.Context, tenantID string, clientID string, clientSecret string, scopes []string) (*azcore.AccessToken, error) {
-	req, err := c.createClientSecretAuthRequest(ctx, tenantID, clientID, clientSecret, scopes)
+// claims: Additional claims the token must contain, such as those requested by a claims challenge
+func (c *aadIdentityClient) authenticate(ctx context.Context, tenantID string, clientID string, clientSecret string, scopes []string, claims string) (*azcore.AccessToken, error) {
+	req, err := c.createClientSecretAuthRequest(ctx, tenantID, clientID, clientSecret, scopes, claims)
 	if err != nil {
 		return nil, err
 	}
@@ -146,8 +152,9 @@ func (c *aadIdentityClient) authenticate(ctx context.Context, tenantID string, c
 // clientID: The client (application) ID of the service principal
 // clientCertificatePath: The path to the client certificate PEM file
 // scopes: The scopes required for the token
-func (c *aadIdentityClient) authenticateCertificate(ctx context.Context, tenantID string, clientID string, cert *certContents, sendCertificateChain bool, scopes []string) (*azcore.AccessToken, error) {
-	req, err := c.createClientCertificateAuthRequest(ctx, tenantID, clientID, cert, sendCertificateChain, scopes)
+// claims: Additional claims the token must contain, such as those requested by a claims challenge
+func (c *aadIdentityClient) authenticateCertificate(ctx context.Context, tenantID string, clientID string, cert *certContents, sendCertificateChain bool, scopes []string, claims string) (*azcore.AccessToken, error) {
+	req, err := c.createClientCertificateAuthRequest(ctx, tenantID, clientID, cert, sendCertificateChain, scopes, claims)
 	if err != nil {
 		return nil, err
 	}
@@ -189,6 +196,7 @@ func (c *aadIdentityClient) createRefreshAccessToken(res *http.Response) (*token
 	value := struct {
 		Token        string      `json:"access_token"`
 		RefreshToken string      `json:"refresh_token"`
+		IDToken      string      `json:"id_token"`
 		ExpiresIn    json.Number `json:"expires_in"`
 		ExpiresOn    string      `json:"expires_on"`
 	}{}
@@ -203,10 +211,10 @@ func (c *aadIdentityClient) createRefreshAccessToken(res *http.Response) (*token
 		Token:     value.Token,
 		ExpiresOn: time.Now().Add(time.Second * time.Duration(t)).UTC(),
 	}
-	return &tokenResponse{token: accessToken, refreshToken: value.RefreshToken}, nil
+	return &tokenResponse{token: accessToken, refreshToken: value.RefreshToken, idToken: value.IDToken}, nil
 }
 
-func (c *aadIdentityClient) createRefreshTokenRequest(ctx context.Context, tenantID, clientID, clientSecret, refreshToken string, scopes []string) (*policy.Request, error) {
+func (c *aadIdentityClient) createRefreshTokenRequest(ctx context.Context, tenantID, clientID, clientSecret, refreshToken string, scopes []string, claims string) (*policy.Request, error) {
 	data := url.Values{}
 	data.Set(qpGrantType, "refresh_token")
 	data.Set(qpClientID, clientID)
@@ -216,6 +224,9 @@ func (c *aadIdentityClient) createRefreshTokenRequest(ctx context.Context, tenan
 	}
 	data.Set(qpRefreshToken, refreshToken)
 	data.Set(qpScope, strings.Join(scopes, " "))
+	if claims != "" {
+		data.Set(qpClaims, claims)
+	}
 	dataEncoded := data.Encode()
 	body := streaming.NopCloser(strings.NewReader(dataEncoded))
 	req, err := runtime.NewRequest(ctx, http.MethodPost, runtime.JoinPaths(string(c.authorityHost), tenantID, tokenEndpoint(oauthPath(tenantID))))
@@ -228,12 +239,15 @@ func (c *aadIdentityClient) createRefreshTokenRequest(ctx context.Context, tenan
 	return req, nil
 }
 
-func (c *aadIdentityClient) createClientSecretAuthRequest(ctx context.Context, tenantID string, clientID string, clientSecret string, scopes []string) (*policy.Request, error) {
+func (c *aadIdentityClient) createClientSecretAuthRequest(ctx context.Context, tenantID string, clientID string, clientSecret string, scopes []string, claims string) (*policy.Request, error) {
 	data := url.Values{}
 	data.Set(qpGrantType, "client_credentials")
 	data.Set(qpClientID, clientID)
 	data.Set(qpClientSecret, clientSecret)
 	data.Set(qpScope, strings.Join(scopes, " "))
+	if claims != "" {
+		data.Set(qpClaims, claims)
+	}
 	dataEncoded := data.Encode()
 	body := streaming.NopCloser(strings.NewReader(dataEncoded))
 	req, err := runtime.NewRequest(ctx, http.MethodPost, runtime.JoinPaths(string(c.authorityHost), tenantID, tokenEndpoint(oauthPath(tenantID))))
@@ -247,7 +261,7 @@ func (c *aadIdentityClient) createClientSecretAuthRequest(ctx context.Context, t
 	return req, nil
 }
 
-func (c *aadIdentityClient) createClientCertificateAuthRequest(ctx context.Context, tenantID string, clientID string, cert *certContents, sendCertificateChain bool, scopes []string) (*policy.Request, error) {
+func (c *aadIdentityClient) createClientCertificateAuthRequest(ctx context.Context, tenantID string, clientID string, cert *certContents, sendCertificateChain bool, scopes []string, claims string) (*policy.Request, error) {
 	u := runtime.JoinPaths(string(c.authorityHost), tenantID, tokenEndpoint(oauthPath(tenantID)))
 	clientAssertion, err := createClientAssertionJWT(clientID, u, cert, sendCertificateChain)
 	if err != nil {
@@ -260,6 +274,116 @@ func (c *aadIdentityClient) createClientCertificateAuthRequest(ctx context.Conte
 	data.Set(qpClientAssertionType, clientAssertionType)
 	data.Set(qpClientAssertion, clientAssertion)
 	data.Set(qpScope, strings.Join(scopes, " "))
+	if claims != "" {
+		data.Set(qpClaims, claims)
+	}
+	dataEncoded := data.Encode()
+	body := streaming.NopCloser(strings.NewReader(dataEncoded))
+	req, err := runtime.NewRequest(ctx, http.MethodPost, u)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.SetBody(body, headerURLEncoded); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// authenticateAssertion creates a client assertion authentication request and returns an Access Token or
+// an error.
+// ctx: The current request context
+// tenantID: The Azure Active Directory tenant (directory) ID of the service principal
+// clientID: The client (application) ID of the service principal
+// assertion: A signed JWT asserting the service principal's identity, such as a federated token
+// scopes: The scopes required for the token
+// claims: Additional claims the token must contain, such as those requested by a claims challenge
+func (c *aadIdentityClient) authenticateAssertion(ctx context.Context, tenantID string, clientID string, assertion string, scopes []string, claims string) (*azcore.AccessToken, error) {
+	req, err := c.createClientAssertionAuthRequest(ctx, tenantID, clientID, assertion, scopes, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.pipeline.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.HasStatusCode(resp, successStatusCodes[:]...) {
+		return c.createAccessToken(resp)
+	}
+
+	return nil, getError(resp)
+}
+
+func (c *aadIdentityClient) createClientAssertionAuthRequest(ctx context.Context, tenantID string, clientID string, assertion string, scopes []string, claims string) (*policy.Request, error) {
+	data := url.Values{}
+	data.Set(qpGrantType, "client_credentials")
+	data.Set(qpClientID, clientID)
+	data.Set(qpClientAssertionType, clientAssertionType)
+	data.Set(qpClientAssertion, assertion)
+	data.Set(qpScope, strings.Join(scopes, " "))
+	if claims != "" {
+		data.Set(qpClaims, claims)
+	}
+	dataEncoded := data.Encode()
+	body := streaming.NopCloser(strings.NewReader(dataEncoded))
+	req, err := runtime.NewRequest(ctx, http.MethodPost, runtime.JoinPaths(string(c.authorityHost), tenantID, tokenEndpoint(oauthPath(tenantID))))
+	if err != nil {
+		return nil, err
+	}
+	if err := req.SetBody(body, headerURLEncoded); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// authenticateOnBehalfOf exchanges a user's access token for a token to a downstream API with the on-behalf-of flow.
+// The service principal authenticates with its client secret or, when cert isn't nil, its certificate.
+// ctx: The current request context
+// tenantID: The Azure Active Directory tenant (directory) ID of the service principal
+// clientID: The client (application) ID of the service principal
+// userAssertion: The access token the user sent to the service principal
+// scopes: The scopes required for the token
+// claims: Additional claims the token must contain, such as those requested by a claims challenge
+func (c *aadIdentityClient) authenticateOnBehalfOf(ctx context.Context, tenantID string, clientID string, userAssertion string, clientSecret string, cert *certContents, sendCertificateChain bool, scopes []string, claims string) (*azcore.AccessToken, error) {
+	req, err := c.createOnBehalfOfAuthRequest(ctx, tenantID, clientID, userAssertion, clientSecret, cert, sendCertificateChain, scopes, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.pipeline.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.HasStatusCode(resp, successStatusCodes[:]...) {
+		return c.createAccessToken(resp)
+	}
+
+	return nil, getError(resp)
+}
+
+func (c *aadIdentityClient) createOnBehalfOfAuthRequest(ctx context.Context, tenantID string, clientID string, userAssertion string, clientSecret string, cert *certContents, sendCertificateChain bool, scopes []string, claims string) (*policy.Request, error) {
+	u := runtime.JoinPaths(string(c.authorityHost), tenantID, tokenEndpoint(oauthPath(tenantID)))
+	data := url.Values{}
+	data.Set(qpGrantType, jwtBearerGrantType)
+	data.Set(qpClientID, clientID)
+	if cert != nil {
+		clientAssertion, err := createClientAssertionJWT(clientID, u, cert, sendCertificateChain)
+		if err != nil {
+			return nil, err
+		}
+		data.Set(qpClientAssertionType, clientAssertionType)
+		data.Set(qpClientAssertion, clientAssertion)
+	} else {
+		data.Set(qpClientSecret, clientSecret)
+	}
+	data.Set(qpAssertion, userAssertion)
+	data.Set(qpRequestedTokenUse, "on_behalf_of")
+	data.Set(qpScope, strings.Join(scopes, " "))
+	if claims != "" {
+		data.Set(qpClaims, claims)
+	}
 	dataEncoded := data.Encode()
 	body := streaming.NopCloser(strings.NewReader(dataEncoded))
 	req, err := runtime.NewRequest(ctx, http.MethodPost, u)
@@ -405,12 +529,26 @@ func (c *aadIdentityClient) createDeviceCodeNumberRequest(ctx context.Context, t
 
 // authenticateInteractiveBrowser opens an interactive browser window, gets the authorization code and requests an Access Token with the
 // authorization code and returns the token or an error in case of authentication failure.
-func (c *aadIdentityClient) authenticateInteractiveBrowser(ctx context.Context, opts *InteractiveBrowserCredentialOptions, scopes []string) (*azcore.AccessToken, error) {
+func (c *aadIdentityClient) authenticateInteractiveBrowser(ctx context.Context, opts *InteractiveBrowserCredentialOptions, scopes []string) (*tokenResponse, error) {
 	cfg, err := authCodeReceiver(ctx, string(c.authorityHost), opts, scopes)
 	if err != nil {
 		return nil, err
 	}
-	return c.authenticateAuthCode(ctx, opts.TenantID, opts.ClientID, cfg.authCode, "", cfg.codeVerifier, cfg.redirectURI, scopes)
+	req, err := c.createAuthorizationCodeAuthRequest(ctx, opts.TenantID, opts.ClientID, cfg.authCode, "", cfg.codeVerifier, cfg.redirectURI, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.pipeline.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.HasStatusCode(resp, successStatusCodes[:]...) {
+		return c.createRefreshAccessToken(resp)
+	}
+
+	return nil, getError(resp)
 }
 
 // authenticateAuthCode requests an Access Token with the authorization code and returns the token or an error in case of authentication failure.
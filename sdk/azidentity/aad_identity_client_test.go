@@ -5,11 +5,13 @@ package azidentity
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
 )
@@ -96,3 +98,95 @@ func TestTelemetryCustom(t *testing.T) {
 		t.Fatalf("unexpected User-Agent %s", ua)
 	}
 }
+
+func TestAADIdentityClient_Claims(t *testing.T) {
+	cred, err := NewClientCertificateCredential(tenantID, clientID, pemCert, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, cert := cred.client, cred.cert
+	const claims = `{"access_token":{"acrs":{"essential":true,"value":"c1"}}}`
+	for _, test := range []struct {
+		name   string
+		create func(claims string) (*policy.Request, error)
+	}{
+		{"client secret", func(claims string) (*policy.Request, error) {
+			return c.createClientSecretAuthRequest(context.Background(), tenantID, clientID, secret, []string{scope}, claims)
+		}},
+		{"client certificate", func(claims string) (*policy.Request, error) {
+			return c.createClientCertificateAuthRequest(context.Background(), tenantID, clientID, cert, false, []string{scope}, claims)
+		}},
+		{"client assertion", func(claims string) (*policy.Request, error) {
+			return c.createClientAssertionAuthRequest(context.Background(), tenantID, clientID, "assertion", []string{scope}, claims)
+		}},
+		{"refresh token", func(claims string) (*policy.Request, error) {
+			return c.createRefreshTokenRequest(context.Background(), tenantID, clientID, "", "refresh-token", []string{scope}, claims)
+		}},
+		{"on behalf of", func(claims string) (*policy.Request, error) {
+			return c.createOnBehalfOfAuthRequest(context.Background(), tenantID, clientID, "user-assertion", secret, nil, false, []string{scope}, claims)
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, expected := range []string{claims, ""} {
+				req, err := test.create(expected)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, err := ioutil.ReadAll(req.Raw().Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				params, err := url.ParseQuery(string(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := params[qpClaims]; ok != (expected != "") || params.Get(qpClaims) != expected {
+					t.Fatalf("unexpected claims %q", params.Get(qpClaims))
+				}
+			}
+		})
+	}
+}
+
+// bodyCapturingTransport records the body of each request before sending it
+type bodyCapturingTransport struct {
+	next   policy.Transporter
+	bodies []string
+}
+
+func (b *bodyCapturingTransport) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		b.bodies = append(b.bodies, string(body))
+		req.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+	}
+	return b.next.Do(req)
+}
+
+func TestClientSecretCredential_GetTokenClaims(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	tp := &bodyCapturingTransport{next: srv}
+	cred, err := NewClientSecretCredential(tenantID, clientID, secret, &ClientSecretCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: tp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const claims = `{"access_token":{"nbf":{"essential":true,"value":"1"}}}`
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}, Claims: claims}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tp.bodies) != 1 {
+		t.Fatalf("expected 1 token request, got %d", len(tp.bodies))
+	}
+	params, err := url.ParseQuery(tp.bodies[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Get(qpClaims) != claims {
+		t.Fatalf("unexpected claims %q", params.Get(qpClaims))
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
+)
+
+// AuthenticationRecord identifies a user account authenticated by DeviceCodeCredential or InteractiveBrowserCredential.
+// It contains no secrets. Applications can save it, for example as JSON, and set it in a credential's options to
+// authenticate the account silently, from refresh tokens in the credential's TokenCachePersistence.
+type AuthenticationRecord struct {
+	// Authority is the authority host which authenticated the account.
+	Authority string `json:"authority"`
+	// ClientID is the ID of the application the account signed in to.
+	ClientID string `json:"clientId"`
+	// HomeAccountID uniquely identifies the account.
+	HomeAccountID string `json:"homeAccountId"`
+	// TenantID is the ID of the account's home tenant.
+	TenantID string `json:"tenantId"`
+	// Username is the account's user principal name.
+	Username string `json:"username"`
+}
+
+// validate returns an error when r identifies an account of another application or authority than the
+// credential's. A zero value record is valid.
+func (r AuthenticationRecord) validate(authorityHost, clientID string) error {
+	if r == (AuthenticationRecord{}) {
+		return nil
+	}
+	if r.ClientID != clientID {
+		return fmt.Errorf("the AuthenticationRecord is for client ID %q, not the credential's client ID %q", r.ClientID, clientID)
+	}
+	if normalizeAuthority(r.Authority) != normalizeAuthority(authorityHost) {
+		return fmt.Errorf("the AuthenticationRecord is for authority %q, not the credential's authority %q", r.Authority, authorityHost)
+	}
+	return nil
+}
+
+func normalizeAuthority(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), "/")
+}
+
+// newAuthenticationRecord returns a record of the account which received tk, identified by the claims of its ID token.
+// The record doesn't have a HomeAccountID when tk has no ID token.
+func newAuthenticationRecord(authorityHost, clientID, tenantID string, tk *tokenResponse) AuthenticationRecord {
+	r := AuthenticationRecord{Authority: authorityHost, ClientID: clientID, TenantID: tenantID}
+	parts := strings.Split(tk.idToken, ".")
+	if len(parts) != 3 {
+		return r
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return r
+	}
+	claims := struct {
+		OID               string `json:"oid"`
+		TID               string `json:"tid"`
+		PreferredUsername string `json:"preferred_username"`
+	}{}
+	if json.Unmarshal(payload, &claims) != nil {
+		return r
+	}
+	if claims.TID != "" {
+		r.TenantID = claims.TID
+	}
+	if claims.OID != "" {
+		r.HomeAccountID = claims.OID + "." + r.TenantID
+	}
+	r.Username = claims.PreferredUsername
+	return r
+}
+
+// persistedAccounts is the data of a persistent token cache
+type persistedAccounts struct {
+	Accounts map[string]persistedAccount `json:"accounts"`
+}
+
+type persistedAccount struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// accountCache persists the refresh tokens of an interactive credential's account. Its methods are no-ops
+// when the credential has no TokenCachePersistence. Errors are logged rather than returned because the
+// credential can authenticate interactively when the cache isn't working.
+type accountCache struct {
+	credName      string
+	persistence   TokenCachePersistence
+	authorityHost string
+	clientID      string
+
+	mu     sync.Mutex
+	record AuthenticationRecord
+}
+
+func newAccountCache(credName string, persistence TokenCachePersistence, authorityHost, clientID string, record AuthenticationRecord) *accountCache {
+	return &accountCache{credName: credName, persistence: persistence, authorityHost: authorityHost, clientID: clientID, record: record}
+}
+
+// key returns the cache key of the account
+func (a *accountCache) key() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return cacheKey(a.authorityHost, a.clientID, a.record.HomeAccountID)
+}
+
+// refreshToken returns the persisted refresh token of the account, or "" when there isn't one
+func (a *accountCache) refreshToken(ctx context.Context) string {
+	if a.persistence == nil {
+		return ""
+	}
+	data, err := a.persistence.Load(ctx)
+	if err != nil {
+		logCredentialError(a.credName, err)
+		return ""
+	}
+	accounts := persistedAccounts{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &accounts); err != nil {
+			logCredentialError(a.credName, err)
+			return ""
+		}
+	}
+	if rt := accounts.Accounts[a.key()].RefreshToken; rt != "" {
+		log.Writef(LogCredential, "Azure Identity => %s found a persisted refresh token", a.credName)
+		return rt
+	}
+	return ""
+}
+
+// authenticationRecord returns the record of the account whose tokens the cache persists
+func (a *accountCache) authenticationRecord() AuthenticationRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.record
+}
+
+// setRecord identifies the account whose tokens the cache persists
+func (a *accountCache) setRecord(r AuthenticationRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.record = r
+}
+
+// storeRefreshToken persists the account's refresh token
+func (a *accountCache) storeRefreshToken(ctx context.Context, refreshToken string) {
+	if a.persistence == nil || refreshToken == "" {
+		return
+	}
+	key := a.key()
+	err := a.persistence.Update(ctx, func(data []byte) ([]byte, error) {
+		accounts := persistedAccounts{}
+		if len(data) > 0 {
+			// start over when the data is corrupt; it's only a cache
+			_ = json.Unmarshal(data, &accounts)
+		}
+		if accounts.Accounts == nil {
+			accounts.Accounts = map[string]persistedAccount{}
+		}
+		accounts.Accounts[key] = persistedAccount{RefreshToken: refreshToken}
+		return json.Marshal(accounts)
+	})
+	if err != nil {
+		logCredentialError(a.credName, err)
+	}
+}
+
+// removeRefreshToken deletes the account's persisted refresh token, e.g. after the identity service rejected it
+func (a *accountCache) removeRefreshToken(ctx context.Context) {
+	if a.persistence == nil {
+		return
+	}
+	key := a.key()
+	err := a.persistence.Update(ctx, func(data []byte) ([]byte, error) {
+		accounts := persistedAccounts{}
+		if len(data) > 0 {
+			_ = json.Unmarshal(data, &accounts)
+		}
+		delete(accounts.Accounts, key)
+		return json.Marshal(accounts)
+	})
+	if err != nil {
+		logCredentialError(a.credName, err)
+	}
+}
+
+// isInvalidGrant returns true when err is the identity service's rejection of a refresh token,
+// for example because the token expired or was revoked
+func isInvalidGrant(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.ErrorCode == "invalid_grant"
+}
+
+// withUserScopes returns scopes with the scopes required for a refresh token and an ID token
+func withUserScopes(scopes []string) []string {
+	scopes = append([]string{}, scopes...)
+	for _, required := range []string{"offline_access", "openid", "profile"} {
+		found := false
+		for _, s := range scopes {
+			if s == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			scopes = append(scopes, required)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,358 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+const (
+	testOID      = "test-object-id"
+	testTID      = "test-tenant-id"
+	testUsername = "user@contoso.com"
+)
+
+var (
+	testIDToken = "header." + base64.RawURLEncoding.EncodeToString([]byte(`{"oid":"`+testOID+`","tid":"`+testTID+`","preferred_username":"`+testUsername+`"}`)) + ".signature"
+	// userTokenResp is a token response containing a refresh token and an ID token
+	userTokenResp = `{"access_token":"` + tokenValue + `","refresh_token":"first_refresh_token","id_token":"` + testIDToken + `","expires_in":3600}`
+)
+
+// formRecorder records the form bodies of requests sent to the token endpoint
+type formRecorder struct {
+	srv   *mock.Server
+	forms []url.Values
+}
+
+func (f *formRecorder) Do(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	f.forms = append(f.forms, values)
+	return f.srv.Do(req)
+}
+
+func TestNewAuthenticationRecord(t *testing.T) {
+	r := newAuthenticationRecord("https://login.contoso.com/", clientID, organizationsTenantID, &tokenResponse{idToken: testIDToken})
+	expected := AuthenticationRecord{
+		Authority:     "https://login.contoso.com/",
+		ClientID:      clientID,
+		HomeAccountID: testOID + "." + testTID,
+		TenantID:      testTID,
+		Username:      testUsername,
+	}
+	if r != expected {
+		t.Fatalf("expected %+v, got %+v", expected, r)
+	}
+	r = newAuthenticationRecord("https://login.contoso.com/", clientID, tenantID, &tokenResponse{})
+	if r.HomeAccountID != "" || r.TenantID != tenantID {
+		t.Fatalf("unexpected record without an ID token: %+v", r)
+	}
+}
+
+func TestDeviceCodeCredential_AuthenticateWithPersistence(t *testing.T) {
+	cache, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithBody([]byte(deviceCodeResponse)))
+	srv.AppendResponse(mock.WithBody([]byte(userTokenResp)))
+	options := DeviceCodeCredentialOptions{
+		AuthorityHost:         AuthorityHost(srv.URL()),
+		HTTPClient:            srv,
+		TokenCachePersistence: cache,
+		UserPrompt:            func(DeviceCodeMessage) {},
+	}
+	cred, err := NewDeviceCodeCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	record, err := cred.Authenticate(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.HomeAccountID != testOID+"."+testTID || record.Username != testUsername {
+		t.Fatalf("unexpected record %+v", record)
+	}
+
+	// a new credential with the saved record authenticates silently from the persistent cache
+	b, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored AuthenticationRecord
+	if err = json.Unmarshal(b, &restored); err != nil {
+		t.Fatal(err)
+	}
+	srv.AppendResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	recorder := &formRecorder{srv: srv}
+	options.AuthenticationRecord = restored
+	options.HTTPClient = recorder
+	options.UserPrompt = func(DeviceCodeMessage) { t.Fatal("unexpected user prompt") }
+	cred, err = NewDeviceCodeCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	tk, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk.Token != tokenValue {
+		t.Fatalf("unexpected token %q", tk.Token)
+	}
+	if len(recorder.forms) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(recorder.forms))
+	}
+	if grant := recorder.forms[0].Get(qpGrantType); grant != "refresh_token" {
+		t.Fatalf("unexpected grant type %q", grant)
+	}
+	if rt := recorder.forms[0].Get("refresh_token"); rt != "first_refresh_token" {
+		t.Fatalf("unexpected refresh token %q", rt)
+	}
+}
+
+func TestDeviceCodeCredential_PersistenceWithoutRecord(t *testing.T) {
+	cache, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithBody([]byte(deviceCodeResponse)))
+	srv.AppendResponse(mock.WithBody([]byte(userTokenResp)))
+	options := DeviceCodeCredentialOptions{
+		AuthorityHost:         AuthorityHost(srv.URL()),
+		HTTPClient:            srv,
+		TokenCachePersistence: cache,
+		UserPrompt:            func(DeviceCodeMessage) {},
+	}
+	cred, err := NewDeviceCodeCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	// without the record, a new credential can't identify the account and prompts the user
+	prompted := false
+	srv.AppendResponse(mock.WithBody([]byte(deviceCodeResponse)))
+	srv.AppendResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	options.UserPrompt = func(DeviceCodeMessage) { prompted = true }
+	cred, err = NewDeviceCodeCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	if !prompted {
+		t.Fatal("expected the credential to prompt the user")
+	}
+}
+
+func TestInteractiveBrowserCredential_AuthenticateWithPersistence(t *testing.T) {
+	cache, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithBody([]byte(userTokenResp)))
+	srv.AppendResponse(mock.WithBody([]byte(`{"access_token":"` + tokenValue + `","refresh_token":"second_refresh_token","expires_in":3600}`)))
+	srv.AppendResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	recorder := &formRecorder{srv: srv}
+	logins := 0
+	authCodeReceiver = func(ctx context.Context, authorityHost string, opts *InteractiveBrowserCredentialOptions, scopes []string) (*interactiveConfig, error) {
+		logins++
+		return &interactiveConfig{authCode: "12345", redirectURI: srv.URL()}, nil
+	}
+	options := InteractiveBrowserCredentialOptions{
+		AuthorityHost:         AuthorityHost(srv.URL()),
+		HTTPClient:            recorder,
+		TokenCachePersistence: cache,
+	}
+	cred, err := NewInteractiveBrowserCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	record, err := cred.Authenticate(context.Background(), &policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.HomeAccountID != testOID+"."+testTID {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	// the credential uses the refresh token it received instead of opening a browser again
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	// so does a new credential with the record, using the rotated refresh token
+	options.AuthenticationRecord = record
+	cred, err = NewInteractiveBrowserCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	if logins != 1 {
+		t.Fatalf("expected 1 interactive login, got %d", logins)
+	}
+	if len(recorder.forms) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(recorder.forms))
+	}
+	if rt := recorder.forms[2].Get("refresh_token"); rt != "second_refresh_token" {
+		t.Fatalf("unexpected refresh token %q", rt)
+	}
+}
+
+const invalidGrantResp = `{"error":"invalid_grant","error_description":"AADSTS700082: The refresh token has expired due to inactivity."}`
+
+func TestDeviceCodeCredential_RefreshTokenRejected(t *testing.T) {
+	cache, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithBody([]byte(deviceCodeResponse)))
+	srv.AppendResponse(mock.WithBody([]byte(userTokenResp)))
+	prompts := 0
+	options := DeviceCodeCredentialOptions{
+		AuthorityHost:         AuthorityHost(srv.URL()),
+		HTTPClient:            srv,
+		TokenCachePersistence: cache,
+		UserPrompt:            func(DeviceCodeMessage) { prompts++ },
+	}
+	cred, err := NewDeviceCodeCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	record, err := cred.Authenticate(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the service rejects the persisted refresh token, so the credential starts the device code flow again
+	srv.AppendResponse(mock.WithStatusCode(http.StatusBadRequest), mock.WithBody([]byte(invalidGrantResp)))
+	srv.AppendResponse(mock.WithBody([]byte(deviceCodeResponse)))
+	srv.AppendResponse(mock.WithBody([]byte(`{"access_token":"` + tokenValue + `","refresh_token":"second_refresh_token","expires_in":3600}`)))
+	options.AuthenticationRecord = record
+	cred, err = NewDeviceCodeCredential(&options)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	tk, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tk.Token != tokenValue || prompts != 2 {
+		t.Fatalf("unexpected token %q after %d prompts", tk.Token, prompts)
+	}
+	if rt := cred.account.refreshToken(context.Background()); rt != "second_refresh_token" {
+		t.Fatalf("unexpected persisted refresh token %q", rt)
+	}
+	// other errors are returned
+	srv.AppendResponse(mock.WithStatusCode(http.StatusBadRequest), mock.WithBody([]byte(accessTokenRespError)))
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if prompts != 2 {
+		t.Fatalf("unexpected prompt")
+	}
+}
+
+func TestInteractiveBrowserCredential_RefreshTokenRejected(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithBody([]byte(userTokenResp)))
+	srv.AppendResponse(mock.WithStatusCode(http.StatusBadRequest), mock.WithBody([]byte(invalidGrantResp)))
+	srv.AppendResponse(mock.WithBody([]byte(userTokenResp)))
+	logins := 0
+	authCodeReceiver = func(ctx context.Context, authorityHost string, opts *InteractiveBrowserCredentialOptions, scopes []string) (*interactiveConfig, error) {
+		logins++
+		return &interactiveConfig{authCode: "12345", redirectURI: srv.URL()}, nil
+	}
+	cred, err := NewInteractiveBrowserCredential(&InteractiveBrowserCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: srv})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the second call's refresh token was rejected, so the user logged in again
+	if logins != 2 {
+		t.Fatalf("expected 2 interactive logins, got %d", logins)
+	}
+}
+
+func TestAuthenticationRecordValidation(t *testing.T) {
+	record := AuthenticationRecord{Authority: "https://login.contoso.com/", ClientID: clientID, HomeAccountID: testOID + "." + testTID, TenantID: testTID, Username: testUsername}
+	for _, test := range []struct {
+		desc   string
+		record func() AuthenticationRecord
+		valid  bool
+	}{
+		{desc: "matching record", record: func() AuthenticationRecord { return record }, valid: true},
+		{desc: "authority differing by a trailing slash", record: func() AuthenticationRecord {
+			r := record
+			r.Authority = "https://login.contoso.com"
+			return r
+		}, valid: true},
+		{desc: "another client", record: func() AuthenticationRecord {
+			r := record
+			r.ClientID = "other-client"
+			return r
+		}},
+		{desc: "another authority", record: func() AuthenticationRecord {
+			r := record
+			r.Authority = "https://login.microsoftonline.us/"
+			return r
+		}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			dc, err := NewDeviceCodeCredential(&DeviceCodeCredentialOptions{AuthorityHost: "https://login.contoso.com/", ClientID: clientID, AuthenticationRecord: test.record()})
+			if test.valid != (err == nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if test.valid && dc.tenantID != testTID {
+				t.Fatalf("expected the record's tenant, got %q", dc.tenantID)
+			}
+			ib, err := NewInteractiveBrowserCredential(&InteractiveBrowserCredentialOptions{AuthorityHost: "https://login.contoso.com/", ClientID: clientID, AuthenticationRecord: test.record()})
+			if test.valid != (err == nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if test.valid && ib.options.TenantID != testTID {
+				t.Fatalf("expected the record's tenant, got %q", ib.options.TenantID)
+			}
+		})
+	}
+	// an explicit tenant takes precedence over the record's
+	dc, err := NewDeviceCodeCredential(&DeviceCodeCredentialOptions{AuthorityHost: "https://login.contoso.com/", ClientID: clientID, TenantID: tenantID, AuthenticationRecord: record})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dc.tenantID != tenantID {
+		t.Fatalf("expected tenant %q, got %q", tenantID, dc.tenantID)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 )
 
@@ -18,6 +19,8 @@ type AuthorizationCodeCredentialOptions struct {
 	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
 	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
 	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
 	// HTTPClient sets the transport for making HTTP requests
 	// Leave this as nil to use the default HTTP transport
 	HTTPClient policy.Transporter
@@ -54,7 +57,7 @@ func NewAuthorizationCodeCredential(tenantID string, clientID string, authCode s
 	if options == nil {
 		options = &AuthorizationCodeCredentialOptions{}
 	}
-	authorityHost, err := setAuthorityHost(options.AuthorityHost)
+	authorityHost, err := setAuthorityHost(authorityHostFor(options.AuthorityHost, options.Cloud))
 	if err != nil {
 		return nil, err
 	}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/errorinfo"
@@ -54,6 +55,7 @@ var (
 type tokenResponse struct {
 	token        *azcore.AccessToken
 	refreshToken string
+	idToken      string
 }
 
 // AuthenticationFailedError is returned when the authentication request has failed.
@@ -82,6 +84,12 @@ func (e *AuthenticationFailedError) RawResponse() *http.Response {
 	return e.resp
 }
 
+// newAuthenticationFailedError returns an AuthenticationFailedError for the non-success response.
+// It wraps an *azcore.ResponseError exposing the response's status code and error code.
+func newAuthenticationFailedError(msg string, resp *http.Response) error {
+	return &AuthenticationFailedError{inner: runtime.NewResponseError(nil, resp), msg: msg, resp: resp}
+}
+
 var _ azcore.HTTPResponse = (*AuthenticationFailedError)(nil)
 var _ errorinfo.NonRetriable = (*AuthenticationFailedError)(nil)
 
@@ -92,12 +100,19 @@ type CredentialUnavailableError struct {
 	credentialType string
 	// Message contains the reason why the credential is unavailable
 	message string
+	// inner is the error which made the credential unavailable, if any
+	inner error
 }
 
 func (e *CredentialUnavailableError) Error() string {
 	return e.credentialType + ": " + e.message
 }
 
+// Unwrap method on CredentialUnavailableError provides access to the inner error if available.
+func (e *CredentialUnavailableError) Unwrap() error {
+	return e.inner
+}
+
 // NonRetriable indicates that this error should not be retried.
 func (e *CredentialUnavailableError) NonRetriable() {
 	// marker method
@@ -121,6 +136,14 @@ type pipelineOptions struct {
 	Logging policy.LogOptions
 }
 
+// authorityHostFor returns authorityHost, or the authority host of cfg when authorityHost is empty.
+func authorityHostFor(authorityHost AuthorityHost, cfg cloud.Configuration) AuthorityHost {
+	if authorityHost == "" {
+		return AuthorityHost(cfg.ActiveDirectoryAuthorityHost)
+	}
+	return authorityHost
+}
+
 // setAuthorityHost initializes the authority host for credentials.
 func setAuthorityHost(authorityHost AuthorityHost) (string, error) {
 	host := string(authorityHost)
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 )
@@ -89,6 +90,34 @@ func Test_DefaultAuthorityHost(t *testing.T) {
 	}
 }
 
+func Test_CloudAuthorityHost(t *testing.T) {
+	setEnvAuthorityHost(envHostString, t)
+	// the cloud's authority host overrides the env var
+	authorityHost, err := setAuthorityHost(authorityHostFor("", cloud.AzureChina))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorityHost != cloud.AzureChina.ActiveDirectoryAuthorityHost {
+		t.Fatalf("Unexpected host when set Cloud: %v", authorityHost)
+	}
+	// an explicit AuthorityHost overrides the cloud's
+	authorityHost, err = setAuthorityHost(authorityHostFor(customHostString, cloud.AzureChina))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorityHost != customHostString {
+		t.Fatalf("Unexpected host when set AuthorityHost and Cloud: %v", authorityHost)
+	}
+	// a zero-value cloud falls back to the env var
+	authorityHost, err = setAuthorityHost(authorityHostFor("", cloud.Configuration{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorityHost != envHostString {
+		t.Fatalf("Unexpected host for zero-value Cloud: %v", authorityHost)
+	}
+}
+
 func Test_NonHTTPSAuthorityHost(t *testing.T) {
 	setEnvAuthorityHost("", t)
 	authorityHost, err := setAuthorityHost("http://foo.com")
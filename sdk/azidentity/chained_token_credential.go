@@ -6,6 +6,9 @@ package azidentity
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -13,13 +16,24 @@ import (
 
 // ChainedTokenCredentialOptions contains optional parameters for ChainedTokenCredential
 type ChainedTokenCredentialOptions struct {
-	// placeholder for future options
+	// RememberSuccessfulSource configures the credential to call only the first source which provides a token on
+	// later calls to GetToken, instead of trying each source in order on every call. When that source later returns
+	// a CredentialUnavailableError, the credential forgets it and tries the other sources in order. GetToken returns
+	// its other errors.
+	RememberSuccessfulSource bool
 }
 
 // ChainedTokenCredential provides a TokenCredential implementation that chains multiple TokenCredential sources to be tried in order
 // and returns the token from the first successful call to GetToken().
 type ChainedTokenCredential struct {
 	sources []azcore.TokenCredential
+	// timeouts limits the duration of each source's GetToken call. A nil slice or zero value means no limit.
+	timeouts []time.Duration
+	remember bool
+
+	mu sync.Mutex
+	// selected is the index of the source which provided the most recent token, or -1
+	selected int
 }
 
 // NewChainedTokenCredential creates an instance of ChainedTokenCredential with the specified TokenCredential sources.
@@ -38,44 +52,136 @@ func NewChainedTokenCredential(sources []azcore.TokenCredential, options *Chaine
 	}
 	cp := make([]azcore.TokenCredential, len(sources))
 	copy(cp, sources)
-	return &ChainedTokenCredential{sources: cp}, nil
+	remember := options != nil && options.RememberSuccessfulSource
+	return &ChainedTokenCredential{sources: cp, remember: remember, selected: -1}, nil
+}
+
+// SelectedCredential returns the source which provided the most recent token, or nil when no source has provided
+// a token. When the credential remembers its successful source, this is the source used by all calls to GetToken.
+// Applications can learn the source's type with a type switch, for example to log which identity they're using.
+func (c *ChainedTokenCredential) SelectedCredential() azcore.TokenCredential {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.selected < 0 {
+		return nil
+	}
+	return c.sources[c.selected]
 }
 
 // GetToken sequentially calls TokenCredential.GetToken on all the specified sources, returning the token from the first successful call to GetToken().
+// The error returned when no source provides a token wraps a *ChainedTokenCredentialError describing each source's failure.
 func (c *ChainedTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (token *azcore.AccessToken, err error) {
+	remembered := -1
+	c.mu.Lock()
+	if c.remember {
+		remembered = c.selected
+	}
+	c.mu.Unlock()
+	// try the remembered source first; when it's unavailable, try the others in order
+	order := make([]int, 0, len(c.sources))
+	if remembered >= 0 {
+		order = append(order, remembered)
+	}
+	for i := range c.sources {
+		if i != remembered {
+			order = append(order, i)
+		}
+	}
 	var errList []*CredentialUnavailableError
+	var failures []CredentialFailure
 	// loop through all of the credentials provided in sources
-	for _, cred := range c.sources {
+	for _, i := range order {
 		// make a GetToken request for the current credential in the loop
-		token, err = cred.GetToken(ctx, opts)
+		token, err = c.getToken(ctx, i, opts)
 		// check if we received a CredentialUnavailableError
 		var credErr *CredentialUnavailableError
 		if errors.As(err, &credErr) {
 			// if we did receive a CredentialUnavailableError then we append it to our error slice and continue looping for a good credential
 			errList = append(errList, credErr)
+			failures = append(failures, CredentialFailure{Credential: c.sources[i], Err: err})
+			if i == remembered {
+				// forget the source so another can be remembered
+				c.mu.Lock()
+				if c.selected == remembered {
+					c.selected = -1
+				}
+				c.mu.Unlock()
+			}
 		} else if err != nil {
 			// if we receive some other type of error then we must stop looping and process the error accordingly
 			var authenticationFailed *AuthenticationFailedError
 			if errors.As(err, &authenticationFailed) {
+				failures = append(failures, CredentialFailure{Credential: c.sources[i], Err: err})
 				// if the error is an AuthenticationFailedError we return the error related to the invalid credential and append all of the other error messages received prior to this point
-				authErr := &AuthenticationFailedError{msg: "Received an AuthenticationFailedError, there is an invalid credential in the chain. " + createChainedErrorMessage(errList), inner: err}
+				authErr := &AuthenticationFailedError{msg: "Received an AuthenticationFailedError, there is an invalid credential in the chain. " + createChainedErrorMessage(errList), inner: &ChainedTokenCredentialError{Failures: failures}}
 				return nil, authErr
 			}
 			// if we receive some other error type this is unexpected and we simple return the unexpected error
 			return nil, err
 		} else {
+			c.mu.Lock()
+			if !c.remember || c.selected < 0 {
+				c.selected = i
+			}
+			c.mu.Unlock()
 			logGetTokenSuccess(c, opts)
 			// if we did not receive an error then we return the token
 			return token, nil
 		}
 	}
 	// if we reach this point it means that all of the credentials in the chain returned CredentialUnavailableErrors
-	credErr := &CredentialUnavailableError{credentialType: "Chained Token Credential", message: createChainedErrorMessage(errList)}
+	credErr := &CredentialUnavailableError{credentialType: "Chained Token Credential", message: createChainedErrorMessage(errList), inner: &ChainedTokenCredentialError{Failures: failures}}
 	// skip adding the stack trace here as it was already logged by other calls to GetToken()
 	addGetTokenFailureLogs("Chained Token Credential", credErr, false)
 	return nil, credErr
 }
 
+// getToken calls GetToken on the source at index i, within the source's timeout. A source which times out
+// is considered unavailable, so that the chain tries the next source.
+func (c *ChainedTokenCredential) getToken(ctx context.Context, i int, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	if i >= len(c.timeouts) || c.timeouts[i] <= 0 {
+		return c.sources[i].GetToken(ctx, opts)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, c.timeouts[i])
+	defer cancel()
+	tk, err := c.sources[i].GetToken(timeoutCtx, opts)
+	if err != nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, &CredentialUnavailableError{credentialType: "Chained Token Credential", message: fmt.Sprintf("%T didn't provide a token within %s", c.sources[i], c.timeouts[i]), inner: err}
+	}
+	return tk, err
+}
+
+// CredentialFailure describes why one of a ChainedTokenCredential's sources didn't provide a token.
+type CredentialFailure struct {
+	// Credential is the source which failed.
+	Credential azcore.TokenCredential
+	// Err is the error returned by the source.
+	Err error
+}
+
+// ChainedTokenCredentialError describes the failures of the sources a ChainedTokenCredential tried, in the order it
+// tried them. Errors returned by ChainedTokenCredential.GetToken wrap it; get it with errors.As.
+type ChainedTokenCredentialError struct {
+	// Failures contains one entry for each source which failed.
+	Failures []CredentialFailure
+}
+
+func (e *ChainedTokenCredentialError) Error() string {
+	msg := "ChainedTokenCredential: no source provided a token:"
+	for _, f := range e.Failures {
+		msg += fmt.Sprintf("\n\t%T: %s", f.Credential, f.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the error of the last source tried, which ended the chain.
+func (e *ChainedTokenCredentialError) Unwrap() error {
+	if len(e.Failures) == 0 {
+		return nil
+	}
+	return e.Failures[len(e.Failures)-1].Err
+}
+
 // helper function used to chain the error messages of the CredentialUnavailableError slice
 func createChainedErrorMessage(errList []*CredentialUnavailableError) string {
 	msg := ""
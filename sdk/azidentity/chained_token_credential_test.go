@@ -6,8 +6,10 @@ package azidentity
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
@@ -187,3 +189,171 @@ func TestBearerPolicy_ChainedTokenCredential(t *testing.T) {
 		t.Fatalf("Expected an empty error but receive: %v", err)
 	}
 }
+
+// fakeCredential returns the results of its getToken func and counts calls
+type fakeCredential struct {
+	calls    int
+	getToken func(ctx context.Context) (*azcore.AccessToken, error)
+}
+
+func (f *fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	f.calls++
+	return f.getToken(ctx)
+}
+
+func unavailableFakeCredential() *fakeCredential {
+	return &fakeCredential{getToken: func(context.Context) (*azcore.AccessToken, error) {
+		return nil, &CredentialUnavailableError{credentialType: "Fake Credential", message: "unavailable"}
+	}}
+}
+
+func successfulFakeCredential() *fakeCredential {
+	return &fakeCredential{getToken: func(context.Context) (*azcore.AccessToken, error) {
+		return &azcore.AccessToken{Token: tokenValue, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}}
+}
+
+func TestChainedTokenCredential_RememberSuccessfulSource(t *testing.T) {
+	for _, remember := range []bool{false, true} {
+		t.Run(fmt.Sprintf("remember=%v", remember), func(t *testing.T) {
+			unavailable, successful := unavailableFakeCredential(), successfulFakeCredential()
+			cred, err := NewChainedTokenCredential([]azcore.TokenCredential{unavailable, successful}, &ChainedTokenCredentialOptions{RememberSuccessfulSource: remember})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if selected := cred.SelectedCredential(); selected != nil {
+				t.Fatalf("expected no selected credential, got %T", selected)
+			}
+			for i := 0; i < 3; i++ {
+				if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if cred.SelectedCredential() != successful {
+				t.Fatalf("unexpected selected credential %v", cred.SelectedCredential())
+			}
+			expected := 3
+			if remember {
+				expected = 1
+			}
+			if unavailable.calls != expected {
+				t.Fatalf("expected %d calls to the unavailable source, got %d", expected, unavailable.calls)
+			}
+			if successful.calls != 3 {
+				t.Fatalf("expected 3 calls to the successful source, got %d", successful.calls)
+			}
+		})
+	}
+}
+
+func TestChainedTokenCredential_RememberedSourceFails(t *testing.T) {
+	first, second := successfulFakeCredential(), successfulFakeCredential()
+	cred, err := NewChainedTokenCredential([]azcore.TokenCredential{first, second}, &ChainedTokenCredentialOptions{RememberSuccessfulSource: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	// an error other than CredentialUnavailableError from the remembered source is returned
+	first.getToken = func(context.Context) (*azcore.AccessToken, error) {
+		return nil, &AuthenticationFailedError{msg: "authentication failed"}
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if second.calls != 0 {
+		t.Fatalf("expected the chain to call only the remembered source, the second source got %d calls", second.calls)
+	}
+	// when the remembered source becomes unavailable, the chain tries the others and remembers the next successful one
+	first.getToken = unavailableFakeCredential().getToken
+	for i := 0; i < 2; i++ {
+		if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cred.SelectedCredential() != second {
+		t.Fatalf("unexpected selected credential %v", cred.SelectedCredential())
+	}
+	if first.calls != 3 || second.calls != 2 {
+		t.Fatalf("unexpected calls: first %d, second %d", first.calls, second.calls)
+	}
+}
+
+func TestChainedTokenCredential_FailureDetails(t *testing.T) {
+	unavailable1, unavailable2 := unavailableFakeCredential(), unavailableFakeCredential()
+	cred, err := NewChainedTokenCredential([]azcore.TokenCredential{unavailable1, unavailable2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	var credErr *CredentialUnavailableError
+	if !errors.As(err, &credErr) {
+		t.Fatalf("expected a CredentialUnavailableError, got %T", err)
+	}
+	var chainErr *ChainedTokenCredentialError
+	if !errors.As(err, &chainErr) {
+		t.Fatalf("expected the error to wrap a ChainedTokenCredentialError")
+	}
+	if len(chainErr.Failures) != 2 || chainErr.Failures[0].Credential != unavailable1 || chainErr.Failures[1].Credential != unavailable2 {
+		t.Fatalf("unexpected failures %v", chainErr.Failures)
+	}
+	if cred.SelectedCredential() != nil {
+		t.Fatal("expected no selected credential")
+	}
+
+	// an AuthenticationFailedError ends the chain and keeps its response error reachable
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithStatusCode(http.StatusUnauthorized))
+	secCred, err := NewClientSecretCredential(tenantID, clientID, wrongSecret, &ClientSecretCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: srv})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	cred, err = NewChainedTokenCredential([]azcore.TokenCredential{unavailable1, secCred, successfulFakeCredential()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	if !errors.As(err, &chainErr) {
+		t.Fatalf("expected the error to wrap a ChainedTokenCredentialError")
+	}
+	if len(chainErr.Failures) != 2 || chainErr.Failures[1].Credential != secCred {
+		t.Fatalf("unexpected failures %v", chainErr.Failures)
+	}
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the error to wrap the source's response error")
+	}
+}
+
+func TestChainedTokenCredential_Timeouts(t *testing.T) {
+	slow := &fakeCredential{getToken: func(ctx context.Context) (*azcore.AccessToken, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	successful := successfulFakeCredential()
+	cred, err := NewChainedTokenCredential([]azcore.TokenCredential{slow, successful}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred.timeouts = []time.Duration{10 * time.Millisecond}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	if cred.SelectedCredential() != successful {
+		t.Fatal("expected the chain to try the next source after a timeout")
+	}
+
+	// the caller's deadline isn't a source timeout
+	cred, err = NewChainedTokenCredential([]azcore.TokenCredential{slow, successful}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred.timeouts = []time.Duration{time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{scope}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context's error, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// ClientAssertionCredentialOptions configures the ClientAssertionCredential with optional parameters.
+// All zero-value fields will be initialized with their default values.
+type ClientAssertionCredentialOptions struct {
+	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
+	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
+	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
+	// HTTPClient sets the transport for making HTTP requests
+	// Leave this as nil to use the default HTTP transport
+	HTTPClient policy.Transporter
+	// Retry configures the built-in retry policy behavior
+	Retry policy.RetryOptions
+	// Telemetry configures the built-in telemetry policy behavior
+	Telemetry policy.TelemetryOptions
+	// Logging configures the built-in logging policy behavior.
+	Logging policy.LogOptions
+	// DisableTokenCache disables the credential's in-memory token cache, so every call to GetToken
+	// requests a new token.
+	DisableTokenCache bool
+}
+
+// ClientAssertionCredential enables authentication of a service principal to Azure Active Directory using a signed client assertion,
+// such as a federated token issued by Kubernetes or GitHub Actions.  More information on how to configure workload identity federation
+// can be found here:
+// https://docs.microsoft.com/en-us/azure/active-directory/develop/workload-identity-federation
+type ClientAssertionCredential struct {
+	client       *aadIdentityClient
+	tenantID     string                                    // The Azure Active Directory tenant (directory) ID of the service principal
+	clientID     string                                    // The client (application) ID of the service principal
+	getAssertion func(ctx context.Context) (string, error) // Returns the assertion to authenticate with
+	cache        *tokenCache
+}
+
+// NewClientAssertionCredential constructs a new ClientAssertionCredential with the details needed to authenticate against Azure Active Directory with a client assertion.
+// tenantID: The Azure Active Directory tenant (directory) ID of the service principal.
+// clientID: The client (application) ID of the service principal.
+// getAssertion: A callback returning a signed JWT asserting the service principal's identity.  It's called each time the credential requests a token,
+// so it should return a fresh assertion when the previous one expires.
+// options: allow to configure the management of the requests sent to Azure Active Directory.
+func NewClientAssertionCredential(tenantID string, clientID string, getAssertion func(ctx context.Context) (string, error), options *ClientAssertionCredentialOptions) (*ClientAssertionCredential, error) {
+	if !validTenantID(tenantID) {
+		return nil, &CredentialUnavailableError{credentialType: "Client Assertion Credential", message: tenantIDValidationErr}
+	}
+	if getAssertion == nil {
+		return nil, errors.New("getAssertion must not be nil")
+	}
+	if options == nil {
+		options = &ClientAssertionCredentialOptions{}
+	}
+	authorityHost, err := setAuthorityHost(authorityHostFor(options.AuthorityHost, options.Cloud))
+	if err != nil {
+		return nil, err
+	}
+	c, err := newAADIdentityClient(authorityHost, pipelineOptions{HTTPClient: options.HTTPClient, Retry: options.Retry, Telemetry: options.Telemetry, Logging: options.Logging})
+	if err != nil {
+		return nil, err
+	}
+	return &ClientAssertionCredential{tenantID: tenantID, clientID: clientID, getAssertion: getAssertion, client: c, cache: newTokenCache(options.DisableTokenCache)}, nil
+}
+
+// GetToken obtains a token from Azure Active Directory, using the assertion returned by the credential's callback to authenticate.
+// ctx: Context used to control the request lifetime.
+// opts: TokenRequestOptions contains the list of scopes for which the token will have access.
+// Returns an AccessToken which can be used to authenticate service client calls.
+func (c *ClientAssertionCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	tk, err := c.cache.getToken(ctx, tokenCacheKey(c.tenantID, opts), func(ctx context.Context) (*azcore.AccessToken, error) {
+		return c.authenticate(ctx, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logGetTokenSuccess(c, opts)
+	return tk, nil
+}
+
+// authenticate requests a token with a new assertion from the credential's callback
+func (c *ClientAssertionCredential) authenticate(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	assertion, err := c.getAssertion(ctx)
+	if err != nil {
+		// wrap the callback's error so a CredentialUnavailableError remains visible to a ChainedTokenCredential
+		authErr := &AuthenticationFailedError{inner: err, msg: "failed to get the client assertion: " + err.Error()}
+		addGetTokenFailureLogs("Client Assertion Credential", authErr, true)
+		return nil, authErr
+	}
+	tk, err := c.client.authenticateAssertion(ctx, c.tenantID, c.clientID, assertion, opts.Scopes, opts.Claims)
+	if err != nil {
+		addGetTokenFailureLogs("Client Assertion Credential", err, true)
+		return nil, err
+	}
+	return tk, nil
+}
+
+var _ azcore.TokenCredential = (*ClientAssertionCredential)(nil)
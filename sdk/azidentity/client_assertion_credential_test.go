@@ -0,0 +1,99 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+const assertion = "header.payload.signature"
+
+func TestClientAssertionCredential_InvalidTenantID(t *testing.T) {
+	cred, err := NewClientAssertionCredential(badTenantID, clientID, func(context.Context) (string, error) { return assertion, nil }, nil)
+	if err == nil {
+		t.Fatal("Expected an error but received none")
+	}
+	if cred != nil {
+		t.Fatalf("Expected a nil credential value. Received: %v", cred)
+	}
+	var errType *CredentialUnavailableError
+	if !errors.As(err, &errType) {
+		t.Fatalf("Did not receive a CredentialUnavailableError. Received: %t", err)
+	}
+}
+
+func TestClientAssertionCredential_CreateAuthRequestSuccess(t *testing.T) {
+	cred, err := NewClientAssertionCredential(tenantID, clientID, func(context.Context) (string, error) { return assertion, nil }, nil)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	req, err := cred.client.createClientAssertionAuthRequest(context.Background(), cred.tenantID, cred.clientID, assertion, []string{scope}, "")
+	if err != nil {
+		t.Fatalf("Unexpectedly received an error: %v", err)
+	}
+	body, err := ioutil.ReadAll(req.Raw().Body)
+	if err != nil {
+		t.Fatalf("Unable to read request body")
+	}
+	reqQueryParams, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatalf("Unable to parse query params in request")
+	}
+	if reqQueryParams.Get(qpClientID) != clientID {
+		t.Fatalf("Unexpected client ID in the client_id header")
+	}
+	if reqQueryParams.Get(qpClientAssertion) != assertion || reqQueryParams.Get(qpClientAssertionType) != clientAssertionType {
+		t.Fatalf("Unexpected client assertion %v", reqQueryParams)
+	}
+	if reqQueryParams.Get(qpScope) != scope {
+		t.Fatalf("Unexpected scope in scope header")
+	}
+	if req.Raw().URL.Host != defaultTestAuthorityHost {
+		t.Fatalf("Unexpected default authority host")
+	}
+}
+
+func TestClientAssertionCredential_GetTokenSuccess(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.AppendResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	srv.AppendResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	calls := 0
+	getAssertion := func(context.Context) (string, error) {
+		calls++
+		return assertion, nil
+	}
+	cred, err := NewClientAssertionCredential(tenantID, clientID, getAssertion, &ClientAssertionCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: srv, DisableTokenCache: true})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+			t.Fatalf("Expected an empty error but received: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("Expected the callback to be called for each token request, got %d calls", calls)
+	}
+}
+
+func TestClientAssertionCredential_GetAssertionError(t *testing.T) {
+	expected := errors.New("no assertion")
+	cred, err := NewClientAssertionCredential(tenantID, clientID, func(context.Context) (string, error) { return "", expected }, nil)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	var authFailed *AuthenticationFailedError
+	if !errors.As(err, &authFailed) || !errors.Is(err, expected) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
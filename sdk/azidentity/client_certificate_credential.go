@@ -12,6 +12,7 @@ import (
 	"errors"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"golang.org/x/crypto/pkcs12"
 )
@@ -27,6 +28,8 @@ type ClientCertificateCredentialOptions struct {
 	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
 	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
 	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
 	// HTTPClient sets the transport for making HTTP requests
 	// Leave this as nil to use the default HTTP transport
 	HTTPClient policy.Transporter
@@ -36,6 +39,9 @@ type ClientCertificateCredentialOptions struct {
 	Telemetry policy.TelemetryOptions
 	// Logging configures the built-in logging policy behavior.
 	Logging policy.LogOptions
+	// DisableTokenCache disables the credential's in-memory token cache, so every call to GetToken
+	// requests a new token.
+	DisableTokenCache bool
 }
 
 // ClientCertificateCredential enables authentication of a service principal to Azure Active Directory using a certificate that is assigned to its App Registration. More information
@@ -47,6 +53,7 @@ type ClientCertificateCredential struct {
 	clientID             string        // The client (application) ID of the service principal
 	cert                 *certContents // The contents of the certificate file
 	sendCertificateChain bool          // Determines whether to include the certificate chain in the claims to retreive a token
+	cache                *tokenCache
 }
 
 // NewClientCertificateCredential creates an instance of ClientCertificateCredential with the details needed to authenticate against Azure Active Directory with the specified certificate.
@@ -61,16 +68,13 @@ func NewClientCertificateCredential(tenantID string, clientID string, certData [
 	if options == nil {
 		options = &ClientCertificateCredentialOptions{}
 	}
-	cert, err := loadPEMCert(certData, options.Password, options.SendCertificateChain)
-	if err != nil {
-		cert, err = loadPKCS12Cert(certData, options.Password, options.SendCertificateChain)
-	}
+	cert, err := loadCert(certData, options.Password, options.SendCertificateChain)
 	if err != nil {
 		credErr := &CredentialUnavailableError{credentialType: "Client Certificate Credential", message: err.Error()}
 		logCredentialError(credErr.credentialType, credErr)
 		return nil, credErr
 	}
-	authorityHost, err := setAuthorityHost(options.AuthorityHost)
+	authorityHost, err := setAuthorityHost(authorityHostFor(options.AuthorityHost, options.Cloud))
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +82,7 @@ func NewClientCertificateCredential(tenantID string, clientID string, certData [
 	if err != nil {
 		return nil, err
 	}
-	return &ClientCertificateCredential{tenantID: tenantID, clientID: clientID, cert: cert, sendCertificateChain: options.SendCertificateChain, client: c}, nil
+	return &ClientCertificateCredential{tenantID: tenantID, clientID: clientID, cert: cert, sendCertificateChain: options.SendCertificateChain, client: c, cache: newTokenCache(options.DisableTokenCache)}, nil
 }
 
 // contains decoded cert contents we care about
@@ -153,6 +157,15 @@ func newCertContents(blocks []*pem.Block, fromPEM bool, sendCertificateChain boo
 	return &cc, nil
 }
 
+// loadCert loads a certificate in PEM or PKCS12 format
+func loadCert(certData []byte, password string, sendCertificateChain bool) (*certContents, error) {
+	cert, err := loadPEMCert(certData, password, sendCertificateChain)
+	if err != nil {
+		cert, err = loadPKCS12Cert(certData, password, sendCertificateChain)
+	}
+	return cert, err
+}
+
 func loadPEMCert(certData []byte, password string, sendCertificateChain bool) (*certContents, error) {
 	// TODO: wire up support for password
 	blocks := []*pem.Block{}
@@ -189,7 +202,9 @@ func loadPKCS12Cert(certData []byte, password string, sendCertificateChain bool)
 // ctx: controlling the request lifetime.
 // Returns an AccessToken which can be used to authenticate service client calls.
 func (c *ClientCertificateCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
-	tk, err := c.client.authenticateCertificate(ctx, c.tenantID, c.clientID, c.cert, c.sendCertificateChain, opts.Scopes)
+	tk, err := c.cache.getToken(ctx, tokenCacheKey(c.tenantID, opts), func(ctx context.Context) (*azcore.AccessToken, error) {
+		return c.client.authenticateCertificate(ctx, c.tenantID, c.clientID, c.cert, c.sendCertificateChain, opts.Scopes, opts.Claims)
+	})
 	if err != nil {
 		addGetTokenFailureLogs("Client Certificate Credential", err, true)
 		return nil, err
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 )
 
@@ -16,6 +17,8 @@ type ClientSecretCredentialOptions struct {
 	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
 	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
 	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
 	// HTTPClient sets the transport for making HTTP requests
 	// Leave this as nil to use the default HTTP transport
 	HTTPClient policy.Transporter
@@ -25,6 +28,9 @@ type ClientSecretCredentialOptions struct {
 	Telemetry policy.TelemetryOptions
 	// Logging configures the built-in logging policy behavior.
 	Logging policy.LogOptions
+	// DisableTokenCache disables the credential's in-memory token cache, so every call to GetToken
+	// requests a new token.
+	DisableTokenCache bool
 }
 
 // ClientSecretCredential enables authentication to Azure Active Directory using a client secret that was generated for an App Registration.  More information on how
@@ -35,6 +41,7 @@ type ClientSecretCredential struct {
 	tenantID     string // Gets the Azure Active Directory tenant (directory) ID of the service principal
 	clientID     string // Gets the client (application) ID of the service principal
 	clientSecret string // Gets the client secret that was generated for the App Registration used to authenticate the client.
+	cache        *tokenCache
 }
 
 // NewClientSecretCredential constructs a new ClientSecretCredential with the details needed to authenticate against Azure Active Directory with a client secret.
@@ -49,7 +56,7 @@ func NewClientSecretCredential(tenantID string, clientID string, clientSecret st
 	if options == nil {
 		options = &ClientSecretCredentialOptions{}
 	}
-	authorityHost, err := setAuthorityHost(options.AuthorityHost)
+	authorityHost, err := setAuthorityHost(authorityHostFor(options.AuthorityHost, options.Cloud))
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +64,7 @@ func NewClientSecretCredential(tenantID string, clientID string, clientSecret st
 	if err != nil {
 		return nil, err
 	}
-	return &ClientSecretCredential{tenantID: tenantID, clientID: clientID, clientSecret: clientSecret, client: c}, nil
+	return &ClientSecretCredential{tenantID: tenantID, clientID: clientID, clientSecret: clientSecret, client: c, cache: newTokenCache(options.DisableTokenCache)}, nil
 }
 
 // GetToken obtains a token from Azure Active Directory, using the specified client secret to authenticate.
@@ -65,7 +72,9 @@ func NewClientSecretCredential(tenantID string, clientID string, clientSecret st
 // opts: TokenRequestOptions contains the list of scopes for which the token will have access.
 // Returns an AccessToken which can be used to authenticate service client calls.
 func (c *ClientSecretCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
-	tk, err := c.client.authenticate(ctx, c.tenantID, c.clientID, c.clientSecret, opts.Scopes)
+	tk, err := c.cache.getToken(ctx, tokenCacheKey(c.tenantID, opts), func(ctx context.Context) (*azcore.AccessToken, error) {
+		return c.client.authenticate(ctx, c.tenantID, c.clientID, c.clientSecret, opts.Scopes, opts.Claims)
+	})
 	if err != nil {
 		addGetTokenFailureLogs("Client Secret Credential", err, true)
 		return nil, err
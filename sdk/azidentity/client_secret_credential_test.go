@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
 )
@@ -45,7 +46,7 @@ func TestClientSecretCredential_CreateAuthRequestSuccess(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unable to create credential. Received: %v", err)
 	}
-	req, err := cred.client.createClientSecretAuthRequest(context.Background(), cred.tenantID, cred.clientID, cred.clientSecret, []string{scope})
+	req, err := cred.client.createClientSecretAuthRequest(context.Background(), cred.tenantID, cred.clientID, cred.clientSecret, []string{scope}, "")
 	if err != nil {
 		t.Fatalf("Unexpectedly received an error: %v", err)
 	}
@@ -117,6 +118,13 @@ func TestClientSecretCredential_GetTokenInvalidCredentials(t *testing.T) {
 	if authFailed.RawResponse() == nil {
 		t.Fatalf("Expected error to include a response")
 	}
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("Expected the error to wrap a ResponseError")
+	}
+	if respErr.StatusCode != http.StatusUnauthorized || respErr.ErrorCode != "invalid_client" {
+		t.Fatalf("Unexpected status code %d or error code %s", respErr.StatusCode, respErr.ErrorCode)
+	}
 }
 
 func TestClientSecretCredential_GetTokenUnexpectedJSON(t *testing.T) {
@@ -4,7 +4,11 @@
 package azidentity
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
 )
@@ -14,12 +18,28 @@ const (
 	developerSignOnClientID = "04b07795-8ddb-461a-bbee-02f9e1bf7b46"
 )
 
+// CredentialSource identifies a type of credential in the DefaultAzureCredential chain.
+type CredentialSource string
+
+const (
+	// CredentialSourceEnvironment identifies EnvironmentCredential.
+	CredentialSourceEnvironment CredentialSource = "EnvironmentCredential"
+	// CredentialSourceWorkloadIdentity identifies WorkloadIdentityCredential.
+	CredentialSourceWorkloadIdentity CredentialSource = "WorkloadIdentityCredential"
+	// CredentialSourceManagedIdentity identifies ManagedIdentityCredential.
+	CredentialSourceManagedIdentity CredentialSource = "ManagedIdentityCredential"
+	// CredentialSourceAzureCLI identifies AzureCLICredential.
+	CredentialSourceAzureCLI CredentialSource = "AzureCLICredential"
+)
+
 // DefaultAzureCredentialOptions contains options for configuring authentication. These options
 // may not apply to all credentials in the default chain.
 type DefaultAzureCredentialOptions struct {
 	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
 	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
 	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
 	// HTTPClient sets the transport for making HTTP requests
 	// Leave this as nil to use the default HTTP transport
 	HTTPClient policy.Transporter
@@ -29,57 +49,100 @@ type DefaultAzureCredentialOptions struct {
 	Telemetry policy.TelemetryOptions
 	// Logging configures the built-in logging policy behavior.
 	Logging policy.LogOptions
+	// ExcludeCredentials lists the credentials to leave out of the chain, for example CredentialSourceManagedIdentity
+	// for an application which never runs in Azure.
+	ExcludeCredentials []CredentialSource
+	// CredentialTimeouts limits how long the chain waits for a credential to provide a token. A credential which
+	// times out is considered unavailable and the chain tries the next one. Credentials without a timeout are limited
+	// only by the context passed to GetToken.
+	CredentialTimeouts map[CredentialSource]time.Duration
+	// RememberSuccessfulSource configures the chain to use only the first credential which provides a token
+	// on later calls to GetToken. See ChainedTokenCredentialOptions.
+	RememberSuccessfulSource bool
 }
 
 // NewDefaultAzureCredential provides a default ChainedTokenCredential configuration for applications that will be deployed to Azure.  The following credential
 // types will be tried, in the following order:
 // - EnvironmentCredential
+// - WorkloadIdentityCredential
 // - ManagedIdentityCredential
 // - AzureCLICredential
 // Consult the documentation for these credential types for more information on how they attempt authentication.
+// Use DefaultAzureCredentialOptions to exclude credentials from the chain or limit how long each may take.
 func NewDefaultAzureCredential(options *DefaultAzureCredentialOptions) (*ChainedTokenCredential, error) {
 	var creds []azcore.TokenCredential
+	var timeouts []time.Duration
 	errMsg := ""
 
 	if options == nil {
 		options = &DefaultAzureCredentialOptions{}
 	}
+	excluded := map[CredentialSource]bool{}
+	for _, source := range options.ExcludeCredentials {
+		switch source {
+		case CredentialSourceEnvironment, CredentialSourceWorkloadIdentity, CredentialSourceManagedIdentity, CredentialSourceAzureCLI:
+			excluded[source] = true
+		default:
+			return nil, fmt.Errorf("unknown credential source %q", source)
+		}
+	}
+	add := func(source CredentialSource, newCred func() (azcore.TokenCredential, error)) {
+		if excluded[source] {
+			log.Writef(LogCredential, "Azure Identity => NewDefaultAzureCredential() excluding %s", source)
+			return
+		}
+		cred, err := newCred()
+		if err != nil {
+			errMsg += err.Error()
+			return
+		}
+		creds = append(creds, cred)
+		timeouts = append(timeouts, options.CredentialTimeouts[source])
+	}
 
-	envCred, err := NewEnvironmentCredential(&EnvironmentCredentialOptions{AuthorityHost: options.AuthorityHost,
-		HTTPClient: options.HTTPClient,
-		Logging:    options.Logging,
-		Retry:      options.Retry,
-		Telemetry:  options.Telemetry,
+	add(CredentialSourceEnvironment, func() (azcore.TokenCredential, error) {
+		return NewEnvironmentCredential(&EnvironmentCredentialOptions{AuthorityHost: options.AuthorityHost, Cloud: options.Cloud,
+			HTTPClient: options.HTTPClient,
+			Logging:    options.Logging,
+			Retry:      options.Retry,
+			Telemetry:  options.Telemetry,
+		})
 	})
-	if err == nil {
-		creds = append(creds, envCred)
-	} else {
-		errMsg += err.Error()
-	}
 
-	msiCred, err := NewManagedIdentityCredential(&ManagedIdentityCredentialOptions{HTTPClient: options.HTTPClient,
-		Logging:   options.Logging,
-		Telemetry: options.Telemetry,
+	add(CredentialSourceWorkloadIdentity, func() (azcore.TokenCredential, error) {
+		return NewWorkloadIdentityCredential(&WorkloadIdentityCredentialOptions{AuthorityHost: options.AuthorityHost, Cloud: options.Cloud,
+			HTTPClient: options.HTTPClient,
+			Logging:    options.Logging,
+			Retry:      options.Retry,
+			Telemetry:  options.Telemetry,
+		})
 	})
-	if err == nil {
-		creds = append(creds, msiCred)
-	} else {
-		errMsg += err.Error()
-	}
 
-	cliCred, err := NewAzureCLICredential(nil)
-	if err == nil {
-		creds = append(creds, cliCred)
-	} else {
-		errMsg += err.Error()
-	}
+	add(CredentialSourceManagedIdentity, func() (azcore.TokenCredential, error) {
+		return NewManagedIdentityCredential(&ManagedIdentityCredentialOptions{HTTPClient: options.HTTPClient,
+			Logging:   options.Logging,
+			Telemetry: options.Telemetry,
+		})
+	})
+
+	add(CredentialSourceAzureCLI, func() (azcore.TokenCredential, error) {
+		return NewAzureCLICredential(nil)
+	})
 
 	// if no credentials are added to the slice of TokenCredentials then return a CredentialUnavailableError
 	if len(creds) == 0 {
+		if errMsg == "" {
+			errMsg = "all credentials are excluded"
+		}
 		err := &CredentialUnavailableError{credentialType: "Default Azure Credential", message: errMsg}
 		logCredentialError(err.credentialType, err)
 		return nil, err
 	}
 	log.Write(LogCredential, "Azure Identity => NewDefaultAzureCredential() invoking NewChainedTokenCredential()")
-	return NewChainedTokenCredential(creds, nil)
+	chain, err := NewChainedTokenCredential(creds, &ChainedTokenCredentialOptions{RememberSuccessfulSource: options.RememberSuccessfulSource})
+	if err != nil {
+		return nil, err
+	}
+	chain.timeouts = timeouts
+	return chain, nil
 }
@@ -6,6 +6,7 @@ package azidentity
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 const (
@@ -39,3 +40,39 @@ func TestDefaultAzureCredential_NilOptions(t *testing.T) {
 		t.Fatalf("Received an error when trying to determine MSI type: %v", err)
 	}
 }
+
+func TestDefaultAzureCredential_ExcludeCredentials(t *testing.T) {
+	resetEnvironmentVarsForTest()
+	defer resetEnvironmentVarsForTest()
+	if err := initEnvironmentVarsForTest(); err != nil {
+		t.Fatalf("Unexpected error when initializing environment variables: %v", err)
+	}
+	cred, err := NewDefaultAzureCredential(&DefaultAzureCredentialOptions{
+		ExcludeCredentials: []CredentialSource{CredentialSourceManagedIdentity, CredentialSourceAzureCLI},
+		CredentialTimeouts: map[CredentialSource]time.Duration{CredentialSourceEnvironment: time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cred.sources) != 1 {
+		t.Fatalf("expected only EnvironmentCredential in the chain, got %d sources", len(cred.sources))
+	}
+	if _, ok := cred.sources[0].(*EnvironmentCredential); !ok {
+		t.Fatalf("unexpected source %T", cred.sources[0])
+	}
+	if len(cred.timeouts) != 1 || cred.timeouts[0] != time.Second {
+		t.Fatalf("unexpected timeouts %v", cred.timeouts)
+	}
+
+	_, err = NewDefaultAzureCredential(&DefaultAzureCredentialOptions{
+		ExcludeCredentials: []CredentialSource{CredentialSourceEnvironment, CredentialSourceWorkloadIdentity, CredentialSourceManagedIdentity, CredentialSourceAzureCLI},
+	})
+	var unavailableErr *CredentialUnavailableError
+	if !errors.As(err, &unavailableErr) {
+		t.Fatalf("expected a CredentialUnavailableError when all credentials are excluded, got %v", err)
+	}
+
+	if _, err = NewDefaultAzureCredential(&DefaultAzureCredentialOptions{ExcludeCredentials: []CredentialSource{"Unknown"}}); err == nil {
+		t.Fatal("expected an error for an unknown credential source")
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
 )
 
 const (
@@ -34,6 +36,8 @@ type DeviceCodeCredentialOptions struct {
 	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
 	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
 	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
 	// HTTPClient sets the transport for making HTTP requests
 	// Leave this as nil to use the default HTTP transport
 	HTTPClient policy.Transporter
@@ -43,6 +47,15 @@ type DeviceCodeCredentialOptions struct {
 	Telemetry policy.TelemetryOptions
 	// Logging configures the built-in logging policy behavior.
 	Logging policy.LogOptions
+	// TokenCachePersistence persists the credential's refresh tokens, so that other instances of the credential
+	// can authenticate silently. NewFileTokenCache returns the default implementation. When nil, refresh tokens
+	// are kept only in memory.
+	TokenCachePersistence TokenCachePersistence
+	// AuthenticationRecord identifies the account to authenticate silently with a refresh token from
+	// TokenCachePersistence. Get a record from the Authenticate method of a credential which authenticated the account.
+	// The record's ClientID and Authority must match the credential's. When TenantID isn't set, the credential
+	// authenticates in the record's tenant.
+	AuthenticationRecord AuthenticationRecord
 }
 
 // init provides the default settings for DeviceCodeCredential.
@@ -83,6 +96,7 @@ type DeviceCodeCredential struct {
 	clientID     string                  // Gets the client (application) ID of the service principal
 	userPrompt   func(DeviceCodeMessage) // Sends the user a message with a verification URL and device code to sign in to the login server
 	refreshToken string                  // Gets the refresh token sent from the service and will be used to retreive new access tokens after the initial request for a token. Thread safety for updates is handled in the authentication policy since only one goroutine will be updating at a time
+	account      *accountCache           // Persists the refresh token of the authenticated account
 }
 
 // NewDeviceCodeCredential constructs a new DeviceCodeCredential used to authenticate against Azure Active Directory with a device code.
@@ -92,53 +106,94 @@ func NewDeviceCodeCredential(options *DeviceCodeCredentialOptions) (*DeviceCodeC
 	if options != nil {
 		cp = *options
 	}
+	if cp.TenantID == "" {
+		// authenticate in the home tenant of the record's account
+		cp.TenantID = cp.AuthenticationRecord.TenantID
+	}
 	cp.init()
 	if !validTenantID(cp.TenantID) {
 		return nil, &CredentialUnavailableError{credentialType: "Device Code Credential", message: tenantIDValidationErr}
 	}
-	authorityHost, err := setAuthorityHost(cp.AuthorityHost)
+	authorityHost, err := setAuthorityHost(authorityHostFor(cp.AuthorityHost, cp.Cloud))
 	if err != nil {
 		return nil, err
 	}
+	if err := cp.AuthenticationRecord.validate(authorityHost, cp.ClientID); err != nil {
+		return nil, &CredentialUnavailableError{credentialType: "Device Code Credential", message: err.Error()}
+	}
 	c, err := newAADIdentityClient(authorityHost, pipelineOptions{HTTPClient: cp.HTTPClient, Retry: cp.Retry, Telemetry: cp.Telemetry, Logging: cp.Logging})
 	if err != nil {
 		return nil, err
 	}
-	return &DeviceCodeCredential{tenantID: cp.TenantID, clientID: cp.ClientID, userPrompt: cp.UserPrompt, client: c}, nil
+	account := newAccountCache("Device Code Credential", cp.TokenCachePersistence, authorityHost, cp.ClientID, cp.AuthenticationRecord)
+	return &DeviceCodeCredential{tenantID: cp.TenantID, clientID: cp.ClientID, userPrompt: cp.UserPrompt, client: c, account: account}, nil
+}
+
+// Authenticate authenticates a user with the device code flow and returns a record of the account. Set the record in
+// DeviceCodeCredentialOptions, along with the TokenCachePersistence used by this credential, to authenticate the account
+// silently in other processes.
+// ctx: The context for controlling the request lifetime.
+// opts: The scopes to request consent for. Pass nil to request only the scopes needed to sign in.
+func (c *DeviceCodeCredential) Authenticate(ctx context.Context, opts *policy.TokenRequestOptions) (AuthenticationRecord, error) {
+	scopes := []string{}
+	if opts != nil {
+		scopes = opts.Scopes
+	}
+	if _, err := c.authenticate(ctx, withUserScopes(scopes)); err != nil {
+		addGetTokenFailureLogs("Device Code Credential", err, true)
+		return AuthenticationRecord{}, err
+	}
+	return c.account.authenticationRecord(), nil
 }
 
 // GetToken obtains a token from Azure Active Directory, following the device code authentication
 // flow. This function first requests a device code and requests that the user login before continuing to authenticate the device.
 // This function will keep polling the service for a token until the user logs in.
-// scopes: The list of scopes for which the token will have access. The "offline_access" scope is checked for and automatically added in case it isn't present to allow for silent token refresh.
+// When the credential has a TokenCachePersistence with a refresh token for its AuthenticationRecord, this function uses that instead.
+// When the service rejects the refresh token, for example because it expired or was revoked, the token is deleted and this
+// function starts the device code flow.
+// scopes: The list of scopes for which the token will have access. The "offline_access", "openid" and "profile" scopes are checked for and automatically added in case they aren't present to allow for silent token refresh.
 // ctx: The context for controlling the request lifetime.
 // Returns an AccessToken which can be used to authenticate service client calls.
 func (c *DeviceCodeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
-	for i, scope := range opts.Scopes {
-		if scope == "offline_access" { // if we find that the opts.Scopes slice contains "offline_access" then we don't need to do anything and exit
-			break
-		}
-		if i == len(opts.Scopes)-1 && scope != "offline_access" { // if we haven't found "offline_access" when reaching the last element in the slice then we append it
-			opts.Scopes = append(opts.Scopes, "offline_access")
-		}
+	opts.Scopes = withUserScopes(opts.Scopes)
+	if len(c.refreshToken) == 0 {
+		c.refreshToken = c.account.refreshToken(ctx)
 	}
 	if len(c.refreshToken) != 0 {
-		tk, err := c.client.refreshAccessToken(ctx, c.tenantID, c.clientID, "", c.refreshToken, opts.Scopes)
-		if err != nil {
+		tk, err := c.client.refreshAccessToken(ctx, c.tenantID, c.clientID, "", c.refreshToken, opts.Scopes, opts.Claims)
+		if err == nil {
+			// assign new refresh token to the credential for future use
+			c.refreshToken = tk.refreshToken
+			c.account.storeRefreshToken(ctx, tk.refreshToken)
+			logGetTokenSuccess(c, opts)
+			// passing the access token and/or error back up
+			return tk.token, nil
+		}
+		if !isInvalidGrant(err) {
 			addGetTokenFailureLogs("Device Code Credential", err, true)
 			return nil, err
 		}
-		// assign new refresh token to the credential for future use
-		c.refreshToken = tk.refreshToken
-		logGetTokenSuccess(c, opts)
-		// passing the access token and/or error back up
-		return tk.token, nil
+		// the refresh token expired or was revoked, so the user must authenticate again
+		log.Write(LogCredential, "Azure Identity => Device Code Credential's refresh token was rejected, starting the device code flow")
+		c.refreshToken = ""
+		c.account.removeRefreshToken(ctx)
 	}
 	// if there is no refreshToken, then begin the Device Code flow from the beginning
-	// make initial request to the device code endpoint for a device code and instructions for authentication
-	dc, err := c.client.requestNewDeviceCode(ctx, c.tenantID, c.clientID, opts.Scopes)
+	tk, err := c.authenticate(ctx, opts.Scopes)
 	if err != nil {
 		addGetTokenFailureLogs("Device Code Credential", err, true)
+		return nil, err
+	}
+	logGetTokenSuccess(c, opts)
+	return tk.token, nil
+}
+
+// authenticate runs the device code flow, then saves the account's refresh token
+func (c *DeviceCodeCredential) authenticate(ctx context.Context, scopes []string) (*tokenResponse, error) {
+	// make initial request to the device code endpoint for a device code and instructions for authentication
+	dc, err := c.client.requestNewDeviceCode(ctx, c.tenantID, c.clientID, scopes)
+	if err != nil {
 		return nil, err // TODO check what error type to return here
 	}
 	// send authentication flow instructions back to the user to log in and authorize the device
@@ -149,12 +204,13 @@ func (c *DeviceCodeCredential) GetToken(ctx context.Context, opts policy.TokenRe
 		Message:         dc.Message})
 	// poll the token endpoint until a valid access token is received or until authentication fails
 	for {
-		tk, err := c.client.authenticateDeviceCode(ctx, c.tenantID, c.clientID, dc.DeviceCode, opts.Scopes)
-		// if there is no error, save the refresh token and return the token credential
+		tk, err := c.client.authenticateDeviceCode(ctx, c.tenantID, c.clientID, dc.DeviceCode, scopes)
+		// if there is no error, save the refresh token and return the token
 		if err == nil {
 			c.refreshToken = tk.refreshToken
-			logGetTokenSuccess(c, opts)
-			return tk.token, err
+			c.account.setRecord(newAuthenticationRecord(c.client.authorityHost, c.clientID, c.tenantID, tk))
+			c.account.storeRefreshToken(ctx, tk.refreshToken)
+			return tk, nil
 		}
 		// if there is an error, check for an AADAuthenticationFailedError in order to check the status for token retrieval
 		// if the error is not an AADAuthenticationFailedError, then fail here since something unexpected occurred
@@ -163,7 +219,6 @@ func (c *DeviceCodeCredential) GetToken(ctx context.Context, opts policy.TokenRe
 			// wait for the interval specified from the initial device code endpoint and then poll for the token again
 			time.Sleep(time.Duration(dc.Interval) * time.Second)
 		} else {
-			addGetTokenFailureLogs("Device Code Credential", err, true)
 			// any other error should be returned
 			return nil, err
 		}
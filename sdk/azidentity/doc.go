@@ -13,6 +13,7 @@ The following credential types are included in this module:
 	- AuthorizationCodeCredential
 	- AzureCLICredential
 	- ChainedTokenCredential
+	- ClientAssertionCredential
 	- ClientCertificateCredential
 	- ClientSecretCredential
 	- DefaultAzureCredential
@@ -20,11 +21,14 @@ The following credential types are included in this module:
 	- EnvironmentCredential
 	- InteractiveBrowserCredential
 	- ManagedIdentityCredential
+	- OnBehalfOfCredential
 	- UsernamePasswordCredential
+	- WorkloadIdentityCredential
 
 By default, the recommendation is that users call NewDefaultAzureCredential() which will
 provide a default ChainedTokenCredential configuration composed of:
 	- EnvironmentCredential
+	- WorkloadIdentityCredential
 	- ManagedIdentityCredential
 	- AzureCLICredential
 Configuration options can be used to exclude any of the previous credentials from the
@@ -39,12 +43,30 @@ Example call to NewDefaultAzureCredential():
 
 Example call to NewDefaultAzureCredential() with options set:
 	// these options will make sure the AzureCLICredential will not be added to the credential chain
-	cred, err := NewDefaultAzureCredential(&DefaultAzureCredentialOptions{ExcludeAzureCLICredential: true})
+	cred, err := NewDefaultAzureCredential(&DefaultAzureCredentialOptions{ExcludeCredentials: []CredentialSource{CredentialSourceAzureCLI}})
 	if err != nil {
 		// process error
 	}
 	// pass credential in to an Azure SDK client
 
+The chain remembers the credential which provided the first token when RememberSuccessfulSource is set,
+and reports the credential providing tokens through SelectedCredential():
+	cred, err := NewDefaultAzureCredential(&DefaultAzureCredentialOptions{
+		CredentialTimeouts:       map[CredentialSource]time.Duration{CredentialSourceManagedIdentity: 2 * time.Second},
+		RememberSuccessfulSource: true,
+	})
+	if err != nil {
+		// process error
+	}
+	tk, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: scopes})
+	var chainErr *ChainedTokenCredentialError
+	if errors.As(err, &chainErr) {
+		for _, f := range chainErr.Failures {
+			// inspect f.Credential and f.Err
+		}
+	}
+	log.Printf("authenticated with %T", cred.SelectedCredential())
+
 Additional configuration of each credential can be done through each credential's
 options type. These options can also be used to modify the default pipeline for each
 credential.
@@ -70,10 +92,36 @@ Example of setting an alternate Azure authority host through code:
 	}
 	// pass credential in to an Azure SDK client
 
+Credential options also accept a cloud.Configuration from azcore, which is shared with ARM clients.
+Its authority host is used when AuthorityHost isn't set:
+	cred, err := azidentity.NewClientSecretCredential("<tenant ID>", "<client ID>", "<client secret>", &ClientSecretCredentialOptions{Cloud: cloud.AzureGovernment})
+
 Example of setting an alternate authority host value in the AZURE_AUTHORITY_HOST environment variable (in Powershell):
 	$env:AZURE_AUTHORITY_HOST="https://contoso.com/auth/"
 
 
+PERSISTENT TOKEN CACHING
+
+DeviceCodeCredential and InteractiveBrowserCredential can persist refresh tokens, so that later processes
+authenticate the same user without prompting. Authenticate the user once, then save the returned AuthenticationRecord:
+	cache, err := azidentity.NewFileTokenCache(nil)
+	if err != nil {
+		// process error
+	}
+	cred, err := azidentity.NewDeviceCodeCredential(&azidentity.DeviceCodeCredentialOptions{TokenCachePersistence: cache})
+	if err != nil {
+		// process error
+	}
+	record, err := cred.Authenticate(context.TODO(), nil)
+	if err != nil {
+		// process error
+	}
+	// save the record, for example as JSON; it doesn't contain secrets
+
+Later processes set the saved record and the same cache in the credential's options:
+	cred, err := azidentity.NewDeviceCodeCredential(&azidentity.DeviceCodeCredentialOptions{AuthenticationRecord: record, TokenCachePersistence: cache})
+
+
 ERROR HANDLING
 
 The credential types in azidentity will return one of the following error types, unless there was some other unexpected failure:
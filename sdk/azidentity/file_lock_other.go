@@ -0,0 +1,22 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"errors"
+	"os"
+	"runtime"
+)
+
+// tryLockFile returns an error because this platform has no supported file locking
+func tryLockFile(*os.File) (bool, error) {
+	return false, errors.New("FileTokenCache can't lock files on " + runtime.GOOS)
+}
+
+func unlockFile(*os.File) error {
+	return nil
+}
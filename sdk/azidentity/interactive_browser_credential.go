@@ -11,10 +11,13 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/uuid"
 	"github.com/pkg/browser"
 )
@@ -34,6 +37,8 @@ type InteractiveBrowserCredentialOptions struct {
 	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
 	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
 	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
 	// HTTPClient sets the transport for making HTTP requests
 	// Leave this as nil to use the default HTTP transport
 	HTTPClient policy.Transporter
@@ -43,6 +48,15 @@ type InteractiveBrowserCredentialOptions struct {
 	Telemetry policy.TelemetryOptions
 	// Logging configures the built-in logging policy behavior.
 	Logging policy.LogOptions
+	// TokenCachePersistence persists the credential's refresh tokens, so that other instances of the credential
+	// can authenticate silently. NewFileTokenCache returns the default implementation. When nil, refresh tokens
+	// are kept only in memory.
+	TokenCachePersistence TokenCachePersistence
+	// AuthenticationRecord identifies the account to authenticate silently with a refresh token from
+	// TokenCachePersistence. Get a record from the Authenticate method of a credential which authenticated the account.
+	// The record's ClientID and Authority must match the credential's. When TenantID isn't set, the credential
+	// authenticates in the record's tenant.
+	AuthenticationRecord AuthenticationRecord
 }
 
 // init returns an instance of InteractiveBrowserCredentialOptions initialized with default values.
@@ -60,6 +74,11 @@ type InteractiveBrowserCredential struct {
 	client *aadIdentityClient
 	// options contains data necessary to authenticate through an interactive browser window
 	options InteractiveBrowserCredentialOptions
+	// account persists the refresh token of the authenticated account
+	account *accountCache
+
+	mu           sync.Mutex
+	refreshToken string
 }
 
 // NewInteractiveBrowserCredential constructs a new InteractiveBrowserCredential with the details needed to authenticate against Azure Active Directory through an interactive browser window.
@@ -69,35 +88,105 @@ func NewInteractiveBrowserCredential(options *InteractiveBrowserCredentialOption
 	if options != nil {
 		cp = *options
 	}
+	if cp.TenantID == "" {
+		// authenticate in the home tenant of the record's account
+		cp.TenantID = cp.AuthenticationRecord.TenantID
+	}
 	cp.init()
 	if !validTenantID(cp.TenantID) {
 		return nil, &CredentialUnavailableError{credentialType: "Interactive Browser Credential", message: tenantIDValidationErr}
 	}
-	authorityHost, err := setAuthorityHost(cp.AuthorityHost)
+	authorityHost, err := setAuthorityHost(authorityHostFor(cp.AuthorityHost, cp.Cloud))
 	if err != nil {
 		return nil, err
 	}
+	if err := cp.AuthenticationRecord.validate(authorityHost, cp.ClientID); err != nil {
+		return nil, &CredentialUnavailableError{credentialType: "Interactive Browser Credential", message: err.Error()}
+	}
 	c, err := newAADIdentityClient(authorityHost, pipelineOptions{HTTPClient: cp.HTTPClient, Retry: cp.Retry, Telemetry: cp.Telemetry, Logging: cp.Logging})
 	if err != nil {
 		return nil, err
 	}
-	return &InteractiveBrowserCredential{options: cp, client: c}, nil
+	account := newAccountCache("Interactive Browser Credential", cp.TokenCachePersistence, authorityHost, cp.ClientID, cp.AuthenticationRecord)
+	return &InteractiveBrowserCredential{options: cp, client: c, account: account}, nil
+}
+
+// Authenticate authenticates a user with an interactive browser and returns a record of the account. Set the record in
+// InteractiveBrowserCredentialOptions, along with the TokenCachePersistence used by this credential, to authenticate
+// the account silently in other processes.
+// ctx: Context used to control the request lifetime.
+// opts: The scopes to request consent for. Pass nil to request only the scopes needed to sign in.
+func (c *InteractiveBrowserCredential) Authenticate(ctx context.Context, opts *policy.TokenRequestOptions) (AuthenticationRecord, error) {
+	scopes := []string{}
+	if opts != nil {
+		scopes = opts.Scopes
+	}
+	if _, err := c.authenticate(ctx, withUserScopes(scopes)); err != nil {
+		addGetTokenFailureLogs("Interactive Browser Credential", err, true)
+		return AuthenticationRecord{}, err
+	}
+	return c.account.authenticationRecord(), nil
 }
 
 // GetToken obtains a token from Azure Active Directory using an interactive browser to authenticate.
 // ctx: Context used to control the request lifetime.
 // opts: TokenRequestOptions contains the list of scopes for which the token will have access.
 // Returns an AccessToken which can be used to authenticate service client calls.
+// After the user logs in once, and when the credential has a TokenCachePersistence with a refresh token
+// for its AuthenticationRecord, this function gets tokens with a refresh token instead of a browser. When the service
+// rejects the refresh token, for example because it expired or was revoked, the token is deleted and the user logs in again.
 func (c *InteractiveBrowserCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
-	tk, err := c.client.authenticateInteractiveBrowser(ctx, &c.options, opts.Scopes)
+	opts.Scopes = withUserScopes(opts.Scopes)
+	c.mu.Lock()
+	if c.refreshToken == "" {
+		c.refreshToken = c.account.refreshToken(ctx)
+	}
+	refreshToken := c.refreshToken
+	c.mu.Unlock()
+	var tk *tokenResponse
+	var err error
+	if refreshToken != "" {
+		tk, err = c.client.refreshAccessToken(ctx, c.options.TenantID, c.options.ClientID, "", refreshToken, opts.Scopes, opts.Claims)
+		if err == nil {
+			c.setRefreshToken(tk.refreshToken)
+			c.account.storeRefreshToken(ctx, tk.refreshToken)
+		} else if isInvalidGrant(err) {
+			// the refresh token expired or was revoked, so the user must log in again
+			log.Write(LogCredential, "Azure Identity => Interactive Browser Credential's refresh token was rejected, opening a browser")
+			c.setRefreshToken("")
+			c.account.removeRefreshToken(ctx)
+			refreshToken = ""
+		}
+	}
+	if refreshToken == "" {
+		tk, err = c.authenticate(ctx, opts.Scopes)
+	}
 	if err != nil {
 		addGetTokenFailureLogs("Interactive Browser Credential", err, true)
 		return nil, err
 	}
 	logGetTokenSuccess(c, opts)
+	return tk.token, nil
+}
+
+// authenticate gets a token with an interactive browser, then saves the account's refresh token
+func (c *InteractiveBrowserCredential) authenticate(ctx context.Context, scopes []string) (*tokenResponse, error) {
+	tk, err := c.client.authenticateInteractiveBrowser(ctx, &c.options, scopes)
+	if err != nil {
+		return nil, err
+	}
+	c.setRefreshToken(tk.refreshToken)
+	c.account.setRecord(newAuthenticationRecord(c.client.authorityHost, c.options.ClientID, c.options.TenantID, tk))
+	c.account.storeRefreshToken(ctx, tk.refreshToken)
 	return tk, nil
 }
 
+func (c *InteractiveBrowserCredential) setRefreshToken(refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshToken = refreshToken
+}
+
 var _ azcore.TokenCredential = (*InteractiveBrowserCredential)(nil)
 
 // authCodeReceiver is used to allow for testing without opening an interactive browser window. Allows mocking a response authorization code and redirect URI.
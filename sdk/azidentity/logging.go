@@ -35,6 +35,9 @@ func logEnvVars() {
 	if envCheck := os.Getenv("AZURE_CLIENT_SECRET"); len(envCheck) > 0 {
 		envVars = append(envVars, "AZURE_CLIENT_SECRET")
 	}
+	if envCheck := os.Getenv(azureFederatedTokenFile); len(envCheck) > 0 {
+		envVars = append(envVars, azureFederatedTokenFile)
+	}
 	if envCheck := os.Getenv("AZURE_AUTHORITY_HOST"); len(envCheck) > 0 {
 		envVars = append(envVars, "AZURE_AUTHORITY_HOST")
 	}
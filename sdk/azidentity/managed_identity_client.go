@@ -119,7 +119,7 @@ func (c *managedIdentityClient) authenticate(ctx context.Context, id ManagedIDKi
 		return nil, &CredentialUnavailableError{credentialType: "Managed Identity Credential", message: c.unavailableMessage}
 	}
 
-	return nil, &AuthenticationFailedError{resp: resp, msg: "authentication failed"}
+	return nil, newAuthenticationFailedError("authentication failed", resp)
 }
 
 func (c *managedIdentityClient) createAccessToken(res *http.Response) (*azcore.AccessToken, error) {
@@ -284,7 +284,7 @@ func (c *managedIdentityClient) getAzureArcSecretKey(ctx context.Context, resour
 	// the endpoint is expected to return a 401 with the WWW-Authenticate header set to the location
 	// of the secret key file. Any other status code indicates an error in the request.
 	if response.StatusCode != 401 {
-		return "", &AuthenticationFailedError{resp: response, msg: fmt.Sprintf("expected a 401 response, received %d", response.StatusCode)}
+		return "", newAuthenticationFailedError(fmt.Sprintf("expected a 401 response, received %d", response.StatusCode), response)
 	}
 	header := response.Header.Get("WWW-Authenticate")
 	if len(header) == 0 {
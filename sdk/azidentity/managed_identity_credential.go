@@ -67,6 +67,10 @@ type ManagedIdentityCredentialOptions struct {
 
 	// Logging configures the built-in logging policy behavior.
 	Logging policy.LogOptions
+
+	// DisableTokenCache disables the credential's in-memory token cache, so every call to GetToken
+	// requests a new token.
+	DisableTokenCache bool
 }
 
 // ManagedIdentityCredential attempts authentication using a managed identity that has been assigned to the deployment environment. This authentication type works in several
@@ -75,6 +79,7 @@ type ManagedIdentityCredentialOptions struct {
 type ManagedIdentityCredential struct {
 	id     ManagedIDKind
 	client *managedIdentityClient
+	cache  *tokenCache
 }
 
 // NewManagedIdentityCredential creates a credential instance capable of authenticating an Azure managed identity in any hosting environment
@@ -109,7 +114,7 @@ func NewManagedIdentityCredential(options *ManagedIdentityCredentialOptions) (*M
 			}
 		}
 	}
-	return &ManagedIdentityCredential{id: id, client: client}, nil
+	return &ManagedIdentityCredential{id: id, client: client, cache: newTokenCache(options.DisableTokenCache)}, nil
 }
 
 // GetToken obtains an AccessToken from the Managed Identity service if available.
@@ -128,7 +133,9 @@ func (c *ManagedIdentityCredential) GetToken(ctx context.Context, opts policy.To
 	}
 	// managed identity endpoints require an AADv1 resource (i.e. token audience), not a v2 scope, so we remove "/.default" here
 	scopes := []string{strings.TrimSuffix(opts.Scopes[0], defaultSuffix)}
-	tk, err := c.client.authenticate(ctx, c.id, scopes)
+	tk, err := c.cache.getToken(ctx, tokenCacheKey("", opts), func(ctx context.Context) (*azcore.AccessToken, error) {
+		return c.client.authenticate(ctx, c.id, scopes)
+	})
 	if err != nil {
 		addGetTokenFailureLogs("Managed Identity Credential", err, true)
 		return nil, err
@@ -0,0 +1,145 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// OnBehalfOfCredentialOptions contains optional parameters for OnBehalfOfCredential.
+// All zero-value fields will be initialized with their default values.
+type OnBehalfOfCredentialOptions struct {
+	// The password required to decrypt the certificate's private key.  Leave empty if there is no password.
+	// Applies only to credentials created with NewOnBehalfOfCredentialWithCertificate.
+	Password string
+	// Set to true to include x5c header in client claims when acquiring a token to enable
+	// SubjectName and Issuer based authentication. Applies only to credentials created with
+	// NewOnBehalfOfCredentialWithCertificate.
+	SendCertificateChain bool
+	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
+	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
+	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
+	// HTTPClient sets the transport for making HTTP requests
+	// Leave this as nil to use the default HTTP transport
+	HTTPClient policy.Transporter
+	// Retry configures the built-in retry policy behavior
+	Retry policy.RetryOptions
+	// Telemetry configures the built-in telemetry policy behavior
+	Telemetry policy.TelemetryOptions
+	// Logging configures the built-in logging policy behavior.
+	Logging policy.LogOptions
+}
+
+// OnBehalfOfCredential authenticates a service principal on behalf of a user, exchanging the access token the user sent
+// to the service principal for tokens to downstream APIs.  Create one credential per user assertion and reuse it for that
+// user's downstream calls; the credential caches tokens per user assertion and scope.  More information on the on-behalf-of
+// flow can be found here:
+// https://docs.microsoft.com/en-us/azure/active-directory/develop/v2-oauth2-on-behalf-of-flow
+type OnBehalfOfCredential struct {
+	client               *aadIdentityClient
+	tenantID             string        // The Azure Active Directory tenant (directory) ID of the service principal
+	clientID             string        // The client (application) ID of the service principal
+	userAssertion        string        // The access token the user sent to the service principal
+	assertionHash        string        // Identifies the user assertion in cache keys
+	clientSecret         string        // The service principal's client secret, when it isn't using a certificate
+	cert                 *certContents // The service principal's certificate, when it isn't using a client secret
+	sendCertificateChain bool          // Determines whether to include the certificate chain in the claims to retreive a token
+	cache                *tokenCache
+}
+
+// NewOnBehalfOfCredentialWithSecret constructs an OnBehalfOfCredential for a service principal authenticating with a client secret.
+// tenantID: The Azure Active Directory tenant (directory) ID of the service principal.
+// clientID: The client (application) ID of the service principal.
+// userAssertion: The access token the user sent to the service principal.
+// clientSecret: A client secret that was generated for the App Registration used to authenticate the client.
+// options: allow to configure the management of the requests sent to Azure Active Directory.
+func NewOnBehalfOfCredentialWithSecret(tenantID string, clientID string, userAssertion string, clientSecret string, options *OnBehalfOfCredentialOptions) (*OnBehalfOfCredential, error) {
+	return newOnBehalfOfCredential(tenantID, clientID, userAssertion, clientSecret, nil, options)
+}
+
+// NewOnBehalfOfCredentialWithCertificate constructs an OnBehalfOfCredential for a service principal authenticating with a certificate.
+// tenantID: The Azure Active Directory tenant (directory) ID of the service principal.
+// clientID: The client (application) ID of the service principal.
+// userAssertion: The access token the user sent to the service principal.
+// certData: The bytes of a certificate in PEM or PKCS12 format, including the private key.
+// options: allow to configure the management of the requests sent to Azure Active Directory, and the certificate password.
+func NewOnBehalfOfCredentialWithCertificate(tenantID string, clientID string, userAssertion string, certData []byte, options *OnBehalfOfCredentialOptions) (*OnBehalfOfCredential, error) {
+	if options == nil {
+		options = &OnBehalfOfCredentialOptions{}
+	}
+	cert, err := loadCert(certData, options.Password, options.SendCertificateChain)
+	if err != nil {
+		credErr := &CredentialUnavailableError{credentialType: "On-Behalf-Of Credential", message: err.Error()}
+		logCredentialError(credErr.credentialType, credErr)
+		return nil, credErr
+	}
+	return newOnBehalfOfCredential(tenantID, clientID, userAssertion, "", cert, options)
+}
+
+func newOnBehalfOfCredential(tenantID string, clientID string, userAssertion string, clientSecret string, cert *certContents, options *OnBehalfOfCredentialOptions) (*OnBehalfOfCredential, error) {
+	if !validTenantID(tenantID) {
+		return nil, &CredentialUnavailableError{credentialType: "On-Behalf-Of Credential", message: tenantIDValidationErr}
+	}
+	if userAssertion == "" {
+		return nil, &CredentialUnavailableError{credentialType: "On-Behalf-Of Credential", message: "userAssertion must not be empty"}
+	}
+	if options == nil {
+		options = &OnBehalfOfCredentialOptions{}
+	}
+	authorityHost, err := setAuthorityHost(authorityHostFor(options.AuthorityHost, options.Cloud))
+	if err != nil {
+		return nil, err
+	}
+	c, err := newAADIdentityClient(authorityHost, pipelineOptions{HTTPClient: options.HTTPClient, Retry: options.Retry, Telemetry: options.Telemetry, Logging: options.Logging})
+	if err != nil {
+		return nil, err
+	}
+	// the assertion is a bearer token, so cache keys contain its hash rather than its value
+	h := sha256.Sum256([]byte(userAssertion))
+	return &OnBehalfOfCredential{
+		client:               c,
+		tenantID:             tenantID,
+		clientID:             clientID,
+		userAssertion:        userAssertion,
+		assertionHash:        hex.EncodeToString(h[:]),
+		clientSecret:         clientSecret,
+		cert:                 cert,
+		sendCertificateChain: options.SendCertificateChain,
+		cache:                newTokenCache(false),
+	}, nil
+}
+
+// GetToken obtains a token from Azure Active Directory on behalf of the user, returning a cached token when it has one for the scopes.
+// ctx: Context used to control the request lifetime.
+// opts: TokenRequestOptions contains the list of scopes for which the token will have access, and optionally a tenant
+// overriding the credential's.
+// Returns an AccessToken which can be used to authenticate service client calls.
+func (c *OnBehalfOfCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	tenantID := c.tenantID
+	if opts.TenantID != "" {
+		if !validTenantID(opts.TenantID) {
+			return nil, &CredentialUnavailableError{credentialType: "On-Behalf-Of Credential", message: tenantIDValidationErr}
+		}
+		tenantID = opts.TenantID
+	}
+	tk, err := c.cache.getToken(ctx, cacheKey(c.assertionHash, tokenCacheKey(tenantID, opts)), func(ctx context.Context) (*azcore.AccessToken, error) {
+		return c.client.authenticateOnBehalfOf(ctx, tenantID, c.clientID, c.userAssertion, c.clientSecret, c.cert, c.sendCertificateChain, opts.Scopes, opts.Claims)
+	})
+	if err != nil {
+		addGetTokenFailureLogs("On-Behalf-Of Credential", err, true)
+		return nil, err
+	}
+	logGetTokenSuccess(c, opts)
+	return tk, nil
+}
+
+var _ azcore.TokenCredential = (*OnBehalfOfCredential)(nil)
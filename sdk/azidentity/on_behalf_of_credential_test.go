@@ -0,0 +1,143 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+const userAssertion = "user.access.token"
+
+func TestOnBehalfOfCredential_InvalidInput(t *testing.T) {
+	var credErr *CredentialUnavailableError
+	if _, err := NewOnBehalfOfCredentialWithSecret(badTenantID, clientID, userAssertion, secret, nil); !errors.As(err, &credErr) {
+		t.Fatalf("Did not receive a CredentialUnavailableError. Received: %v", err)
+	}
+	if _, err := NewOnBehalfOfCredentialWithSecret(tenantID, clientID, "", secret, nil); !errors.As(err, &credErr) {
+		t.Fatalf("Did not receive a CredentialUnavailableError. Received: %v", err)
+	}
+	if _, err := NewOnBehalfOfCredentialWithCertificate(tenantID, clientID, userAssertion, []byte("not a certificate"), nil); !errors.As(err, &credErr) {
+		t.Fatalf("Did not receive a CredentialUnavailableError. Received: %v", err)
+	}
+}
+
+func TestOnBehalfOfCredential_CreateAuthRequest(t *testing.T) {
+	secretCred, err := NewOnBehalfOfCredentialWithSecret(tenantID, clientID, userAssertion, secret, nil)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	certCred, err := NewOnBehalfOfCredentialWithCertificate(tenantID, clientID, userAssertion, pemCert, nil)
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	for _, cred := range []*OnBehalfOfCredential{secretCred, certCred} {
+		req, err := cred.client.createOnBehalfOfAuthRequest(context.Background(), cred.tenantID, cred.clientID, cred.userAssertion, cred.clientSecret, cred.cert, false, []string{scope}, "")
+		if err != nil {
+			t.Fatalf("Unexpectedly received an error: %v", err)
+		}
+		body, err := ioutil.ReadAll(req.Raw().Body)
+		if err != nil {
+			t.Fatalf("Unable to read request body")
+		}
+		reqQueryParams, err := url.ParseQuery(string(body))
+		if err != nil {
+			t.Fatalf("Unable to parse query params in request")
+		}
+		if reqQueryParams.Get(qpGrantType) != jwtBearerGrantType || reqQueryParams.Get(qpRequestedTokenUse) != "on_behalf_of" {
+			t.Fatalf("Unexpected grant %v", reqQueryParams)
+		}
+		if reqQueryParams.Get(qpAssertion) != userAssertion || reqQueryParams.Get(qpClientID) != clientID || reqQueryParams.Get(qpScope) != scope {
+			t.Fatalf("Unexpected request %v", reqQueryParams)
+		}
+		if cred.cert == nil {
+			if reqQueryParams.Get(qpClientSecret) != secret || reqQueryParams.Get(qpClientAssertion) != "" {
+				t.Fatalf("Unexpected client authentication %v", reqQueryParams)
+			}
+		} else if reqQueryParams.Get(qpClientSecret) != "" || reqQueryParams.Get(qpClientAssertionType) != clientAssertionType || reqQueryParams.Get(qpClientAssertion) == "" {
+			t.Fatalf("Unexpected client authentication %v", reqQueryParams)
+		}
+	}
+}
+
+func TestOnBehalfOfCredential_GetTokenCaches(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	cred, err := NewOnBehalfOfCredentialWithSecret(tenantID, clientID, userAssertion, secret, &OnBehalfOfCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: srv})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	for _, s := range []string{scope, scope, "https://vault.azure.net/.default"} {
+		tk, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{s}})
+		if err != nil {
+			t.Fatalf("Expected an empty error but received: %v", err)
+		}
+		if tk.Token != tokenValue {
+			t.Fatalf("Unexpected token %s", tk.Token)
+		}
+	}
+	// the second request for scope was served from the cache
+	if r := srv.Requests(); r != 2 {
+		t.Fatalf("Expected 2 token requests, got %d", r)
+	}
+}
+
+func TestOnBehalfOfCredential_GetTokenError(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte(accessTokenRespError)), mock.WithStatusCode(http.StatusBadRequest))
+	cred, err := NewOnBehalfOfCredentialWithSecret(tenantID, clientID, userAssertion, secret, &OnBehalfOfCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: srv})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	var authFailed *AuthenticationFailedError
+	if !errors.As(err, &authFailed) {
+		t.Fatalf("Expected: AuthenticationFailedError, Received: %T", err)
+	}
+}
+
+// pathRecorder records the paths of requests
+type pathRecorder struct {
+	srv   *mock.Server
+	paths []string
+}
+
+func (p *pathRecorder) Do(req *http.Request) (*http.Response, error) {
+	p.paths = append(p.paths, req.URL.Path)
+	return p.srv.Do(req)
+}
+
+func TestOnBehalfOfCredential_GetTokenTenantID(t *testing.T) {
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	recorder := &pathRecorder{srv: srv}
+	cred, err := NewOnBehalfOfCredentialWithSecret(tenantID, clientID, userAssertion, secret, &OnBehalfOfCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: recorder})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	const otherTenant = "other-tenant"
+	for _, tenant := range []string{"", otherTenant, otherTenant, ""} {
+		if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}, TenantID: tenant}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the credential requested a token from each tenant once
+	if len(recorder.paths) != 2 || !strings.HasPrefix(recorder.paths[0], "/"+tenantID+"/") || !strings.HasPrefix(recorder.paths[1], "/"+otherTenant+"/") {
+		t.Fatalf("unexpected requests %v", recorder.paths)
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}, TenantID: badTenantID}); err == nil {
+		t.Fatal("expected an error for an invalid tenant")
+	}
+}
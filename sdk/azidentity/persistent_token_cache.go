@@ -0,0 +1,251 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/log"
+)
+
+// TokenCachePersistence stores the data of a persistent token cache, which DeviceCodeCredential and
+// InteractiveBrowserCredential use to authenticate accounts silently across processes. The data is opaque
+// and contains secrets such as refresh tokens. Implementations must be safe for concurrent use.
+type TokenCachePersistence interface {
+	// Load returns the cache's data, or nil when the cache is empty.
+	Load(ctx context.Context) ([]byte, error)
+	// Update replaces the cache's data with the value returned by update, which receives the current data.
+	// Implementations shared by processes must prevent concurrent updates, so that none is lost.
+	Update(ctx context.Context, update func(data []byte) ([]byte, error)) error
+}
+
+const (
+	defaultTokenCacheName = "azidentity"
+	// lockRetryInterval is how often a FileTokenCache retries acquiring its lock
+	lockRetryInterval = 20 * time.Millisecond
+)
+
+// errCorruptTokenCache indicates a FileTokenCache's data can't be decrypted, for example because its key was lost
+var errCorruptTokenCache = errors.New("token cache data can't be decrypted")
+
+// FileTokenCacheOptions contains optional parameters for NewFileTokenCache.
+type FileTokenCacheOptions struct {
+	// Name distinguishes the cache from others in the same directory. Credentials using caches with the
+	// same name and directory share data. The default value is "azidentity".
+	Name string
+	// Dir is the directory containing the cache's files. The default is the "azidentity" subdirectory
+	// of the user's configuration directory, as returned by os.UserConfigDir.
+	Dir string
+	// AllowUnencryptedStorage permits storing the cache's encryption key unencrypted in a file next to the
+	// cache when the OS has no secret storage available. Any program running as the same user can read this
+	// key and decrypt the cache. By default, NewFileTokenCache returns an error in that case.
+	AllowUnencryptedStorage bool
+}
+
+// FileTokenCache is the default TokenCachePersistence. It encrypts data with AES-256-GCM and stores it in a file
+// readable only by the user who created it. The OS protects the encryption key: on Windows, it's encrypted with
+// DPAPI; on macOS, it's stored in the login keychain; on Linux, it's stored with the Secret Service, such as GNOME
+// Keyring, through libsecret's secret-tool. When the OS has no such storage, the key is stored unencrypted in a file
+// only if FileTokenCacheOptions.AllowUnencryptedStorage is set. An OS lock on a lock file serializes access by
+// concurrent processes. The operating system releases the lock when a process exits, so a process which crashes
+// while holding it can't block others.
+type FileTokenCache struct {
+	path string
+	keys keyStore
+}
+
+// NewFileTokenCache constructs a FileTokenCache, creating its directory if necessary.
+// options: Optional configuration. Pass nil to accept the default values.
+func NewFileTokenCache(options *FileTokenCacheOptions) (*FileTokenCache, error) {
+	cp := FileTokenCacheOptions{}
+	if options != nil {
+		cp = *options
+	}
+	if cp.Name == "" {
+		cp.Name = defaultTokenCacheName
+	}
+	if filepath.Base(cp.Name) != cp.Name {
+		return nil, fmt.Errorf("invalid token cache name %q", cp.Name)
+	}
+	if cp.Dir == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		cp.Dir = filepath.Join(dir, defaultTokenCacheName)
+	}
+	if err := os.MkdirAll(cp.Dir, 0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(cp.Dir, cp.Name)
+	keys, err := newOSKeyStore(path)
+	if err != nil {
+		if !cp.AllowUnencryptedStorage {
+			return nil, fmt.Errorf("FileTokenCache can't protect its key: %w; set AllowUnencryptedStorage to store it unencrypted", err)
+		}
+		log.Write(LogCredential, "Azure Identity => FileTokenCache storing its key unencrypted: "+err.Error())
+		keys = fileKeyStore{path: path + ".key"}
+	}
+	return &FileTokenCache{path: path, keys: keys}, nil
+}
+
+// Load returns the cache's decrypted data, or nil when the cache is empty.
+func (c *FileTokenCache) Load(ctx context.Context) ([]byte, error) {
+	lock, err := c.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.unlock(lock)
+	return c.read()
+}
+
+// Update replaces the cache's data with the value returned by update, holding the cache's lock
+// so other processes can't update it concurrently. When the cache's data can't be decrypted,
+// for example because its key was deleted, update receives nil. Other errors reading
+// the data, such as a denied permission, are returned without calling update.
+func (c *FileTokenCache) Update(ctx context.Context, update func(data []byte) ([]byte, error)) error {
+	lock, err := c.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.unlock(lock)
+	data, err := c.read()
+	if errors.Is(err, errCorruptTokenCache) {
+		// start over; it's only a cache
+		data = nil
+	} else if err != nil {
+		return err
+	}
+	if data, err = update(data); err != nil {
+		return err
+	}
+	key, err := c.key()
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path+".cache", gcm.Seal(nonce, nonce, data, nil))
+}
+
+// read returns the cache's decrypted data, or nil when there's no data. The caller must hold the lock.
+func (c *FileTokenCache) read() ([]byte, error) {
+	sealed, err := os.ReadFile(c.path + ".cache")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	key, err := c.keys.load()
+	if errors.Is(err, errNoKey) {
+		return nil, fmt.Errorf("%w: %v", errCorruptTokenCache, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("reading token cache key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errCorruptTokenCache, err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: the data is truncated", errCorruptTokenCache)
+	}
+	data, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errCorruptTokenCache, err)
+	}
+	return data, nil
+}
+
+// key returns the cache's encryption key, creating it if necessary. The caller must hold the lock.
+func (c *FileTokenCache) key() ([]byte, error) {
+	key, err := c.keys.load()
+	if err == nil && len(key) == 32 {
+		return key, nil
+	} else if err != nil && !errors.Is(err, errNoKey) {
+		return nil, err
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := c.keys.store(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// lock acquires an OS lock on the cache's lock file, waiting until it's available or ctx is done.
+// The lock file is never deleted, because a process could lock a deleted file while another locks its replacement.
+func (c *FileTokenCache) lock(ctx context.Context) (*os.File, error) {
+	f, err := os.OpenFile(c.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		locked, err := tryLockFile(f)
+		if locked {
+			return f, nil
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("acquiring token cache lock: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, fmt.Errorf("acquiring token cache lock: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// unlock releases the lock acquired by lock
+func (c *FileTokenCache) unlock(f *os.File) {
+	_ = unlockFile(f)
+	f.Close()
+}
+
+// writeFileAtomic replaces the content of the named file, so that readers see either its old or new content
+func writeFileAtomic(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+var _ TokenCachePersistence = (*FileTokenCache)(nil)
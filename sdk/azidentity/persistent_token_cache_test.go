@@ -0,0 +1,267 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileTokenCache_LoadUpdate(t *testing.T) {
+	dir := t.TempDir()
+	c, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != nil {
+		t.Fatalf("expected no data, got %q", data)
+	}
+	err = c.Update(context.Background(), func(data []byte) ([]byte, error) {
+		if data != nil {
+			t.Fatalf("expected no data, got %q", data)
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, defaultTokenCacheName+".cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored, []byte(secret)) {
+		t.Fatal("cache file contains unencrypted data")
+	}
+	other, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = other.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != secret {
+		t.Fatalf("expected %q, got %q", secret, data)
+	}
+	named, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir, Name: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = named.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if data != nil {
+		t.Fatalf("expected a cache with a different name to be empty, got %q", data)
+	}
+}
+
+func TestFileTokenCache_ConcurrentUpdates(t *testing.T) {
+	dir := t.TempDir()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		// separate instances, like separate processes, have only the lock file in common
+		c, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.Update(context.Background(), func(data []byte) ([]byte, error) {
+				return append(data, 'x'), nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	c, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 10 {
+		t.Fatalf("expected 10 updates, got %d", len(data))
+	}
+}
+
+func TestFileTokenCache_Lock(t *testing.T) {
+	dir := t.TempDir()
+	c, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// another instance, like another process, holds the lock
+	other, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := other.lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.Load(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected an error while another process holds the lock, got %v", err)
+	}
+	other.unlock(lock)
+	if _, err := c.Load(context.Background()); err != nil {
+		t.Fatalf("expected the lock to be released, got %v", err)
+	}
+	// a lock file left behind doesn't block anyone, because the lock is the OS's, not the file's existence
+	if _, err := os.Stat(filepath.Join(dir, defaultTokenCacheName+".lock")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileTokenCache_LostKey(t *testing.T) {
+	dir := t.TempDir()
+	c, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Update(context.Background(), func([]byte) ([]byte, error) { return []byte(secret), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, defaultTokenCacheName+".key")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Load(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	err = c.Update(context.Background(), func(data []byte) ([]byte, error) {
+		if data != nil {
+			t.Fatalf("expected no data, got %q", data)
+		}
+		return []byte("new data"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new data" {
+		t.Fatalf("unexpected data %q", data)
+	}
+}
+
+func TestFileTokenCache_ReadError(t *testing.T) {
+	dir := t.TempDir()
+	c, err := newTestFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Update(context.Background(), func([]byte) ([]byte, error) { return []byte(secret), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an error other than corruption, here a directory where the data file should be, doesn't discard the data
+	name := filepath.Join(dir, defaultTokenCacheName+".cache")
+	if err := os.Rename(name, name+".bak"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(name, 0700); err != nil {
+		t.Fatal(err)
+	}
+	err = c.Update(context.Background(), func([]byte) ([]byte, error) {
+		t.Fatal("unexpected call to update")
+		return nil, nil
+	})
+	if err == nil || errors.Is(err, errCorruptTokenCache) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFileTokenCache_InvalidName(t *testing.T) {
+	if _, err := NewFileTokenCache(&FileTokenCacheOptions{Dir: t.TempDir(), Name: "../cache"}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+// newTestFileTokenCache constructs a FileTokenCache which stores its key in a file, so tests don't depend on the host's secret storage
+func newTestFileTokenCache(o *FileTokenCacheOptions) (*FileTokenCache, error) {
+	cp := *o
+	cp.AllowUnencryptedStorage = true
+	c, err := NewFileTokenCache(&cp)
+	if err == nil {
+		c.keys = fileKeyStore{path: c.path + ".key"}
+	}
+	return c, err
+}
+
+type memoryKeyStore struct {
+	key []byte
+}
+
+func (s *memoryKeyStore) load() ([]byte, error) {
+	if s.key == nil {
+		return nil, errNoKey
+	}
+	return s.key, nil
+}
+
+func (s *memoryKeyStore) store(key []byte) error {
+	s.key = key
+	return nil
+}
+
+func TestFileTokenCache_KeyStorage(t *testing.T) {
+	defer func(f func(string) (keyStore, error)) { newOSKeyStore = f }(newOSKeyStore)
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, defaultTokenCacheName+".key")
+	update := func([]byte) ([]byte, error) { return []byte(secret), nil }
+
+	// the OS protects the key when it can
+	ks := &memoryKeyStore{}
+	newOSKeyStore = func(string) (keyStore, error) { return ks, nil }
+	c, err := NewFileTokenCache(&FileTokenCacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Update(context.Background(), update); err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.key) != 32 {
+		t.Fatal("expected the key in the OS key store")
+	}
+	if _, err := os.Stat(keyFile); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no key file, got %v", err)
+	}
+
+	// otherwise the key is stored unencrypted only with the caller's consent
+	newOSKeyStore = func(string) (keyStore, error) { return nil, errors.New("no secret storage") }
+	if _, err = NewFileTokenCache(&FileTokenCacheOptions{Dir: dir}); err == nil {
+		t.Fatal("expected an error")
+	}
+	c, err = NewFileTokenCache(&FileTokenCacheOptions{Dir: dir, AllowUnencryptedStorage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Update(context.Background(), update); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := os.ReadFile(keyFile); err != nil || len(key) != 32 {
+		t.Fatalf("expected a key file, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// tokenRefreshWindow is how long before a cached token expires that it's refreshed
+const tokenRefreshWindow = 5 * time.Minute
+
+// tokenCache is a concurrency-safe cache of access tokens.  A nil *tokenCache caches nothing.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]azcore.AccessToken
+	// acquiring holds the acquisitions in progress, by key
+	acquiring map[string]*tokenAcquisition
+}
+
+// tokenAcquisition is the shared result of acquiring a token
+type tokenAcquisition struct {
+	done chan struct{}
+	tk   *azcore.AccessToken
+	err  error
+	// waiters is the number of callers waiting for the result.  When all of them stop
+	// waiting, cancel cancels the acquisition.
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// newTokenCache returns a new tokenCache, or nil when caching is disabled
+func newTokenCache(disabled bool) *tokenCache {
+	if disabled {
+		return nil
+	}
+	return &tokenCache{}
+}
+
+// getToken returns the token cached for key, calling acquire for a new token when there's no cached token
+// or the cached token is about to expire.  Only one acquisition per key is in progress at a time; concurrent
+// callers wait for its result or, when refreshing, return the cached token while it's still valid.  When
+// refreshing fails and the cached token hasn't expired, the cached token is returned.
+//
+// The acquisition is shared, so its context carries the values of the first caller's ctx but isn't
+// canceled by any one caller.  It's canceled when every caller has stopped waiting for it, and callers
+// arriving after that start a new acquisition.
+func (c *tokenCache) getToken(ctx context.Context, key string, acquire func(context.Context) (*azcore.AccessToken, error)) (*azcore.AccessToken, error) {
+	if c == nil {
+		return acquire(ctx)
+	}
+	c.mu.Lock()
+	cached, hasCached := c.tokens[key]
+	now := time.Now()
+	valid := hasCached && now.Before(cached.ExpiresOn)
+	if valid && cached.ExpiresOn.Sub(now) > tokenRefreshWindow {
+		c.mu.Unlock()
+		return &cached, nil
+	}
+	a, ok := c.acquiring[key]
+	if ok {
+		if valid {
+			// another goroutine is refreshing the token
+			c.mu.Unlock()
+			return &cached, nil
+		}
+	} else {
+		actx, cancel := context.WithCancel(detachedContext{ctx})
+		a = &tokenAcquisition{done: make(chan struct{}), cancel: cancel}
+		if c.acquiring == nil {
+			c.acquiring = map[string]*tokenAcquisition{}
+		}
+		c.acquiring[key] = a
+		go c.acquire(actx, key, a, acquire)
+	}
+	a.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		c.mu.Lock()
+		if a.waiters--; a.waiters == 0 {
+			// abandon the acquisition so later callers start a new one instead of joining a canceled one
+			a.cancel()
+			if c.acquiring[key] == a {
+				delete(c.acquiring, key)
+			}
+		}
+		c.mu.Unlock()
+		if valid && time.Now().Before(cached.ExpiresOn) {
+			return &cached, nil
+		}
+		return nil, ctx.Err()
+	}
+	if a.err != nil {
+		if valid && time.Now().Before(cached.ExpiresOn) {
+			return &cached, nil
+		}
+		return nil, a.err
+	}
+	tk := *a.tk
+	return &tk, nil
+}
+
+// acquire calls acquireToken and shares its result through a
+func (c *tokenCache) acquire(ctx context.Context, key string, a *tokenAcquisition, acquireToken func(context.Context) (*azcore.AccessToken, error)) {
+	defer func() {
+		if v := recover(); v != nil {
+			a.tk, a.err = nil, fmt.Errorf("token acquisition panicked: %v", v)
+		}
+		a.cancel()
+		c.mu.Lock()
+		if c.acquiring[key] == a {
+			delete(c.acquiring, key)
+		}
+		if a.err == nil {
+			if c.tokens == nil {
+				c.tokens = map[string]azcore.AccessToken{}
+			}
+			// evict expired tokens so the cache doesn't grow without bound
+			now := time.Now()
+			for k, v := range c.tokens {
+				if now.After(v.ExpiresOn) {
+					delete(c.tokens, k)
+				}
+			}
+			c.tokens[key] = *a.tk
+		}
+		c.mu.Unlock()
+		// waiters may proceed only after the cache reflects the result
+		close(a.done)
+	}()
+	a.tk, a.err = acquireToken(ctx)
+}
+
+// detachedContext has the values of its parent but not its deadline or cancelation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
+
+// tokenCacheKey returns the cache key for a token request to the specified tenant
+func tokenCacheKey(tenantID string, opts policy.TokenRequestOptions) string {
+	return cacheKey(tenantID, strings.Join(opts.Scopes, " "), opts.Claims)
+}
+
+// cacheKey returns a cache key for the specified values
+func cacheKey(values ...string) string {
+	return strings.Join(values, "\x00")
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// keyStoreService identifies FileTokenCache keys in the OS's secret storage
+	keyStoreService = "azidentity"
+	keyStoreLabel   = "Azure SDK token cache key"
+)
+
+// errNoKey indicates a key store has no usable key
+var errNoKey = errors.New("token cache key not found")
+
+// keyStore stores a FileTokenCache's encryption key
+type keyStore interface {
+	// load returns the key, or an error wrapping errNoKey when there's no usable key
+	load() ([]byte, error)
+	// store replaces the key
+	store(key []byte) error
+}
+
+// newOSKeyStore returns a keyStore which protects the key of the cache at path with the OS's secret storage,
+// or an error when that isn't available. Tests replace it to avoid depending on the host's configuration.
+var newOSKeyStore = osKeyStore
+
+// fileKeyStore stores the key unencrypted in a file readable only by the user who created it
+type fileKeyStore struct {
+	path string
+}
+
+func (s fileKeyStore) load() ([]byte, error) {
+	key, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: the key file doesn't exist", errNoKey)
+	}
+	return key, err
+}
+
+func (s fileKeyStore) store(key []byte) error {
+	return writeFileAtomic(s.path, key)
+}
+
+// keyStoreAccount returns a name for the key of the cache at path, unique per cache
+// and free of characters requiring quoting on a command line
+func keyStoreAccount(path string) string {
+	h := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status of the security tool when the keychain has no matching item
+const errSecItemNotFound = 44
+
+// keychainKeyStore stores the key in the user's login keychain
+type keychainKeyStore struct {
+	account string
+}
+
+func osKeyStore(path string) (keyStore, error) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil, fmt.Errorf("the keychain isn't available: %w", err)
+	}
+	return keychainKeyStore{account: keyStoreAccount(path)}, nil
+}
+
+func (s keychainKeyStore) load() ([]byte, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keyStoreService, "-a", s.account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+			return nil, fmt.Errorf("%w: the keychain has no key", errNoKey)
+		}
+		return nil, fmt.Errorf("reading token cache key from the keychain: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoKey, err)
+	}
+	return key, nil
+}
+
+func (s keychainKeyStore) store(key []byte) error {
+	// send the command on stdin so the key doesn't appear in the process list
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -l %q -w %s\n", keyStoreService, s.account, keyStoreLabel, hex.EncodeToString(key)))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("storing token cache key in the keychain: %w: %s", err, out)
+	}
+	// interactive mode doesn't report a failed command in its exit status
+	stored, err := s.load()
+	if err != nil || !bytes.Equal(stored, key) {
+		return fmt.Errorf("storing token cache key in the keychain failed: %s", out)
+	}
+	return nil
+}
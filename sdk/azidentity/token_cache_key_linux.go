@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// secretServiceKeyStore stores the key with the Secret Service, for example GNOME Keyring or
+// KWallet, through libsecret's secret-tool
+type secretServiceKeyStore struct {
+	account string
+}
+
+func osKeyStore(path string) (keyStore, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil, fmt.Errorf("the Secret Service isn't available: %w", err)
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil, errors.New("the Secret Service isn't available: there's no D-Bus session")
+	}
+	return secretServiceKeyStore{account: keyStoreAccount(path)}, nil
+}
+
+func (s secretServiceKeyStore) load() ([]byte, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keyStoreService, "account", s.account).Output()
+	if err != nil {
+		// secret-tool exits silently with status 1 when there's no matching secret
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) == 0 {
+			return nil, fmt.Errorf("%w: the Secret Service has no key", errNoKey)
+		}
+		return nil, fmt.Errorf("reading token cache key from the Secret Service: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoKey, err)
+	}
+	return key, nil
+}
+
+func (s secretServiceKeyStore) store(key []byte) error {
+	cmd := exec.Command("secret-tool", "store", "--label="+keyStoreLabel, "service", keyStoreService, "account", s.account)
+	// secret-tool reads the secret from stdin, so it doesn't appear in the process list
+	cmd.Stdin = strings.NewReader(hex.EncodeToString(key))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("storing token cache key with the Secret Service: %w: %s", err, out)
+	}
+	return nil
+}
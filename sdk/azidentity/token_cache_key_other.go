@@ -0,0 +1,17 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"errors"
+	"runtime"
+)
+
+// osKeyStore returns an error because this platform has no supported secret storage
+func osKeyStore(string) (keyStore, error) {
+	return nil, errors.New("FileTokenCache has no secure key storage on " + runtime.GOOS)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const cryptprotectUIForbidden = 0x1
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+)
+
+// dataBlob is a DATA_BLOB, the buffer type of the DPAPI functions
+type dataBlob struct {
+	cbData uint32
+	pbData *byte
+}
+
+func newDataBlob(b []byte) *dataBlob {
+	if len(b) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{cbData: uint32(len(b)), pbData: &b[0]}
+}
+
+// bytes copies the blob's content and frees its buffer, which a DPAPI function allocated
+func (d *dataBlob) bytes() []byte {
+	defer syscall.LocalFree(syscall.Handle(unsafe.Pointer(d.pbData)))
+	b := make([]byte, d.cbData)
+	copy(b, (*[1 << 30]byte)(unsafe.Pointer(d.pbData))[:d.cbData:d.cbData])
+	return b
+}
+
+// dpapiKeyStore stores the key in a file, encrypted with DPAPI for the current user
+type dpapiKeyStore struct {
+	path string
+}
+
+func osKeyStore(path string) (keyStore, error) {
+	if err := procCryptProtectData.Find(); err != nil {
+		return nil, err
+	}
+	return dpapiKeyStore{path: path + ".key"}, nil
+}
+
+func (s dpapiKeyStore) load() ([]byte, error) {
+	protected, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: the key file doesn't exist", errNoKey)
+	} else if err != nil {
+		return nil, err
+	}
+	out := dataBlob{}
+	r, _, err := procCryptUnprotectData.Call(uintptr(unsafe.Pointer(newDataBlob(protected))), 0, 0, 0, 0, cryptprotectUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		// the key was protected by another user or machine, or the file is corrupt
+		return nil, fmt.Errorf("%w: %v", errNoKey, err)
+	}
+	return out.bytes(), nil
+}
+
+func (s dpapiKeyStore) store(key []byte) error {
+	out := dataBlob{}
+	r, _, err := procCryptProtectData.Call(uintptr(unsafe.Pointer(newDataBlob(key))), 0, 0, 0, 0, cryptprotectUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return fmt.Errorf("encrypting token cache key: %w", err)
+	}
+	return writeFileAtomic(s.path, out.bytes())
+}
@@ -0,0 +1,238 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+func TestTokenCacheKeys(t *testing.T) {
+	c := newTokenCache(false)
+	acquired := 0
+	acquire := func(context.Context) (*azcore.AccessToken, error) {
+		acquired++
+		return &azcore.AccessToken{Token: tokenValue, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}
+	for _, key := range []string{
+		tokenCacheKey(tenantID, policy.TokenRequestOptions{Scopes: []string{scope}}),
+		tokenCacheKey(tenantID, policy.TokenRequestOptions{Scopes: []string{scope}}),
+		tokenCacheKey("other-tenant", policy.TokenRequestOptions{Scopes: []string{scope}}),
+		tokenCacheKey(tenantID, policy.TokenRequestOptions{Scopes: []string{scope}, Claims: `{"access_token":{}}`}),
+	} {
+		if _, err := c.getToken(context.Background(), key, acquire); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if acquired != 3 {
+		t.Fatalf("expected 3 acquisitions, got %d", acquired)
+	}
+	// a nil cache caches nothing
+	var disabled *tokenCache
+	for i := 0; i < 2; i++ {
+		if _, err := disabled.getToken(context.Background(), "key", acquire); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if acquired != 5 {
+		t.Fatalf("expected 5 acquisitions, got %d", acquired)
+	}
+}
+
+func TestTokenCacheRefresh(t *testing.T) {
+	c := newTokenCache(false)
+	// this token is within the refresh window, so the next call refreshes it
+	stale := &azcore.AccessToken{Token: "stale", ExpiresOn: time.Now().Add(time.Minute)}
+	tk, err := c.getToken(context.Background(), "key", func(context.Context) (*azcore.AccessToken, error) { return stale, nil })
+	if err != nil || tk.Token != "stale" {
+		t.Fatalf("unexpected result %v, %v", tk, err)
+	}
+	// a failed refresh returns the cached token while it's valid
+	tk, err = c.getToken(context.Background(), "key", func(context.Context) (*azcore.AccessToken, error) { return nil, errors.New("refresh failed") })
+	if err != nil || tk.Token != "stale" {
+		t.Fatalf("unexpected result %v, %v", tk, err)
+	}
+	tk, err = c.getToken(context.Background(), "key", func(context.Context) (*azcore.AccessToken, error) {
+		return &azcore.AccessToken{Token: "fresh", ExpiresOn: time.Now().Add(time.Hour)}, nil
+	})
+	if err != nil || tk.Token != "fresh" {
+		t.Fatalf("unexpected result %v, %v", tk, err)
+	}
+	// an expired token isn't returned when refreshing fails
+	c = newTokenCache(false)
+	expired := &azcore.AccessToken{Token: "expired", ExpiresOn: time.Now().Add(-time.Minute)}
+	if _, err = c.getToken(context.Background(), "key", func(context.Context) (*azcore.AccessToken, error) { return expired, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.getToken(context.Background(), "key", func(context.Context) (*azcore.AccessToken, error) { return nil, errors.New("refresh failed") }); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestTokenCacheSingleFlight(t *testing.T) {
+	c := newTokenCache(false)
+	var acquired int32
+	release := make(chan struct{})
+	acquire := func(context.Context) (*azcore.AccessToken, error) {
+		atomic.AddInt32(&acquired, 1)
+		<-release
+		return &azcore.AccessToken{Token: tokenValue, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tk, err := c.getToken(context.Background(), "key", acquire)
+			if err == nil && tk.Token != tokenValue {
+				err = errors.New("unexpected token " + tk.Token)
+			}
+			errs <- err
+		}()
+	}
+	// give the goroutines time to block on the acquisition
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if acquired != 1 {
+		t.Fatalf("expected 1 acquisition, got %d", acquired)
+	}
+}
+
+func TestClientSecretCredential_TokenCache(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		srv, close := mock.NewTLSServer()
+		srv.SetResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+		cred, err := NewClientSecretCredential(tenantID, clientID, secret, &ClientSecretCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: srv, DisableTokenCache: disabled})
+		if err != nil {
+			t.Fatalf("Unable to create credential. Received: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expected := 1
+		if disabled {
+			expected = 2
+		}
+		if r := srv.Requests(); r != expected {
+			t.Fatalf("Expected %d token requests, got %d", expected, r)
+		}
+		close()
+	}
+}
+
+func TestTokenCacheSharedAcquisitionContext(t *testing.T) {
+	c := newTokenCache(false)
+	type ctxKey struct{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	acquire := func(ctx context.Context) (*azcore.AccessToken, error) {
+		if ctx.Value(ctxKey{}) != "value" {
+			return nil, errors.New("expected the caller's context values")
+		}
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return &azcore.AccessToken{Token: tokenValue, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}
+	// the first caller stops waiting, which doesn't cancel the acquisition another caller is waiting for
+	first, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.getToken(first, "key", acquire)
+		errs <- err
+	}()
+	<-started
+	tks := make(chan *azcore.AccessToken, 1)
+	go func() {
+		tk, err := c.getToken(context.Background(), "key", acquire)
+		if err != nil {
+			t.Error(err)
+		}
+		tks <- tk
+	}()
+	// give the second caller time to wait for the acquisition
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	close(release)
+	if tk := <-tks; tk == nil || tk.Token != tokenValue {
+		t.Fatalf("unexpected token %v", tk)
+	}
+}
+
+func TestTokenCacheAbandonedAcquisition(t *testing.T) {
+	c := newTokenCache(false)
+	var acquired int32
+	acquire := func(ctx context.Context) (*azcore.AccessToken, error) {
+		if atomic.AddInt32(&acquired, 1) == 1 {
+			// the first acquisition hangs until it's canceled, and its goroutine returns some time after that
+			<-ctx.Done()
+			time.Sleep(100 * time.Millisecond)
+			return nil, ctx.Err()
+		}
+		return &azcore.AccessToken{Token: tokenValue, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.getToken(ctx, "key", acquire); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// a caller arriving before the abandoned acquisition returns starts a new acquisition
+	tk, err := c.getToken(context.Background(), "key", acquire)
+	if err != nil || tk.Token != tokenValue {
+		t.Fatalf("unexpected result %v, %v", tk, err)
+	}
+	if acquired := atomic.LoadInt32(&acquired); acquired != 2 {
+		t.Fatalf("expected 2 acquisitions, got %d", acquired)
+	}
+	// the abandoned acquisition's result doesn't replace the new one's
+	time.Sleep(150 * time.Millisecond)
+	tk, err = c.getToken(context.Background(), "key", acquire)
+	if err != nil || tk.Token != tokenValue {
+		t.Fatalf("unexpected result %v, %v", tk, err)
+	}
+	if acquired := atomic.LoadInt32(&acquired); acquired != 2 {
+		t.Fatalf("expected 2 acquisitions, got %d", acquired)
+	}
+}
+
+func TestTokenCacheAcquisitionPanics(t *testing.T) {
+	c := newTokenCache(false)
+	_, err := c.getToken(context.Background(), "key", func(context.Context) (*azcore.AccessToken, error) {
+		panic("bad credential")
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	// the failed acquisition doesn't block later ones
+	tk, err := c.getToken(context.Background(), "key", func(context.Context) (*azcore.AccessToken, error) {
+		return &azcore.AccessToken{Token: tokenValue, ExpiresOn: time.Now().Add(time.Hour)}, nil
+	})
+	if err != nil || tk.Token != tokenValue {
+		t.Fatalf("unexpected result %v, %v", tk, err)
+	}
+}
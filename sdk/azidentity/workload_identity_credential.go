@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const azureFederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE"
+
+// WorkloadIdentityCredentialOptions configures the WorkloadIdentityCredential with optional parameters.
+// All zero-value fields will be initialized with their default values.
+type WorkloadIdentityCredentialOptions struct {
+	// ClientID is the client (application) ID of the service principal.
+	// The default is the value of the AZURE_CLIENT_ID environment variable.
+	ClientID string
+	// TenantID is the Azure Active Directory tenant (directory) ID of the service principal.
+	// The default is the value of the AZURE_TENANT_ID environment variable.
+	TenantID string
+	// TokenFilePath is the path of a file containing a federated token, such as a Kubernetes projected service account token.
+	// The default is the value of the AZURE_FEDERATED_TOKEN_FILE environment variable.
+	TokenFilePath string
+	// The host of the Azure Active Directory authority. The default is AzurePublicCloud.
+	// Leave empty to allow overriding the value from the AZURE_AUTHORITY_HOST environment variable.
+	AuthorityHost AuthorityHost
+	// Cloud specifies the cloud to authenticate in. Its authority host is used when AuthorityHost is empty.
+	Cloud cloud.Configuration
+	// HTTPClient sets the transport for making HTTP requests
+	// Leave this as nil to use the default HTTP transport
+	HTTPClient policy.Transporter
+	// Retry configures the built-in retry policy behavior
+	Retry policy.RetryOptions
+	// Telemetry configures the built-in telemetry policy behavior
+	Telemetry policy.TelemetryOptions
+	// Logging configures the built-in logging policy behavior.
+	Logging policy.LogOptions
+	// DisableTokenCache disables the credential's in-memory token cache, so every call to GetToken
+	// requests a new token.
+	DisableTokenCache bool
+}
+
+// WorkloadIdentityCredential enables authentication to Azure Active Directory using a federated token from a file,
+// as provided to workloads by Azure Kubernetes Service workload identity.  The file is read each time the credential
+// requests a token, so rotated tokens are picked up.  Unless specified in options, the credential's configuration is
+// read from the following environment variables:
+// - AZURE_TENANT_ID
+// - AZURE_CLIENT_ID
+// - AZURE_FEDERATED_TOKEN_FILE
+type WorkloadIdentityCredential struct {
+	cred *ClientAssertionCredential
+	file string
+}
+
+// NewWorkloadIdentityCredential creates an instance of WorkloadIdentityCredential.
+// If the required configuration isn't specified in options or the environment, then a CredentialUnavailableError will be returned.
+// options: The options used to configure the credential and the management of the requests sent to Azure Active Directory.
+func NewWorkloadIdentityCredential(options *WorkloadIdentityCredentialOptions) (*WorkloadIdentityCredential, error) {
+	if options == nil {
+		options = &WorkloadIdentityCredentialOptions{}
+	}
+	tenantID, err := optionOrEnv(options.TenantID, "AZURE_TENANT_ID")
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := optionOrEnv(options.ClientID, "AZURE_CLIENT_ID")
+	if err != nil {
+		return nil, err
+	}
+	file, err := optionOrEnv(options.TokenFilePath, azureFederatedTokenFile)
+	if err != nil {
+		return nil, err
+	}
+	w := &WorkloadIdentityCredential{file: file}
+	cred, err := NewClientAssertionCredential(tenantID, clientID, w.getAssertion, &ClientAssertionCredentialOptions{
+		AuthorityHost: options.AuthorityHost,
+		Cloud:         options.Cloud,
+		HTTPClient:    options.HTTPClient,
+		Retry:         options.Retry,
+		Telemetry:     options.Telemetry,
+		Logging:       options.Logging,
+
+		DisableTokenCache: options.DisableTokenCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.cred = cred
+	return w, nil
+}
+
+// optionOrEnv returns the option's value or, when it's empty, the value of the environment variable.
+func optionOrEnv(option, envVar string) (string, error) {
+	if option != "" {
+		return option, nil
+	}
+	if v := os.Getenv(envVar); v != "" {
+		return v, nil
+	}
+	err := &CredentialUnavailableError{credentialType: "Workload Identity Credential", message: "Missing environment variable " + envVar}
+	logCredentialError(err.credentialType, err)
+	return "", err
+}
+
+// GetToken obtains a token from Azure Active Directory, using the federated token in the credential's file to authenticate.
+// ctx: Context used to control the request lifetime.
+// opts: TokenRequestOptions contains the list of scopes for which the token will have access.
+// Returns an AccessToken which can be used to authenticate service client calls.
+func (w *WorkloadIdentityCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	tk, err := w.cred.GetToken(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	logGetTokenSuccess(w, opts)
+	return tk, nil
+}
+
+// getAssertion reads the federated token from the credential's file.  The file is read for every
+// token request because the token is rotated by its issuer.
+func (w *WorkloadIdentityCredential) getAssertion(context.Context) (string, error) {
+	b, err := os.ReadFile(w.file)
+	if err != nil {
+		return "", &CredentialUnavailableError{credentialType: "Workload Identity Credential", message: "Failed to read federated token file: " + err.Error()}
+	}
+	assertion := strings.TrimSpace(string(b))
+	if assertion == "" {
+		return "", &CredentialUnavailableError{credentialType: "Workload Identity Credential", message: "Federated token file " + w.file + " is empty"}
+	}
+	return assertion, nil
+}
+
+var _ azcore.TokenCredential = (*WorkloadIdentityCredential)(nil)
@@ -0,0 +1,112 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package azidentity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
+)
+
+func TestWorkloadIdentityCredential_MissingConfiguration(t *testing.T) {
+	resetEnvironmentVarsForTest()
+	for _, options := range []*WorkloadIdentityCredentialOptions{
+		nil,
+		{ClientID: clientID, TenantID: tenantID},
+		{ClientID: clientID, TokenFilePath: "token"},
+		{TenantID: tenantID, TokenFilePath: "token"},
+	} {
+		_, err := NewWorkloadIdentityCredential(options)
+		var credentialUnavailable *CredentialUnavailableError
+		if !errors.As(err, &credentialUnavailable) {
+			t.Fatalf("Expected a credential unavailable error, instead received: %T", err)
+		}
+	}
+}
+
+// assertionRecorder records the client assertions sent to the token endpoint
+type assertionRecorder struct {
+	srv        *mock.Server
+	assertions []string
+}
+
+func (a *assertionRecorder) Do(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	a.assertions = append(a.assertions, values.Get(qpClientAssertion))
+	return a.srv.Do(req)
+}
+
+func TestWorkloadIdentityCredential_GetTokenRereadsFile(t *testing.T) {
+	resetEnvironmentVarsForTest()
+	defer resetEnvironmentVarsForTest()
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("first-assertion\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"AZURE_TENANT_ID": tenantID, "AZURE_CLIENT_ID": clientID, azureFederatedTokenFile: file} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv, close := mock.NewTLSServer()
+	defer close()
+	srv.SetResponse(mock.WithBody([]byte(accessTokenRespSuccess)))
+	recorder := &assertionRecorder{srv: srv}
+	cred, err := NewWorkloadIdentityCredential(&WorkloadIdentityCredentialOptions{AuthorityHost: AuthorityHost(srv.URL()), HTTPClient: recorder, DisableTokenCache: true})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	// the token is rotated
+	if err := os.WriteFile(file, []byte("second-assertion"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.assertions) != 2 || recorder.assertions[0] != "first-assertion" || recorder.assertions[1] != "second-assertion" {
+		t.Fatalf("Unexpected assertions %v", recorder.assertions)
+	}
+}
+
+func TestWorkloadIdentityCredential_MissingFile(t *testing.T) {
+	cred, err := NewWorkloadIdentityCredential(&WorkloadIdentityCredentialOptions{
+		ClientID:      clientID,
+		TenantID:      tenantID,
+		TokenFilePath: filepath.Join(t.TempDir(), "missing"),
+	})
+	if err != nil {
+		t.Fatalf("Unable to create credential. Received: %v", err)
+	}
+	// a ChainedTokenCredential should try its next source
+	chain, err := NewChainedTokenCredential([]azcore.TokenCredential{cred}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = chain.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{scope}})
+	var credentialUnavailable *CredentialUnavailableError
+	if !errors.As(err, &credentialUnavailable) {
+		t.Fatalf("Expected a credential unavailable error, instead received: %T", err)
+	}
+}
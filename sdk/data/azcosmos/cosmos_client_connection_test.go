@@ -5,29 +5,21 @@ package azcosmos
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	azruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
 )
 
 func TestEnsureErrorIsGeneratedOnResponse(t *testing.T) {
-	someError := &cosmosErrorResponse{
-		Code: "SomeCode",
-	}
-
-	jsonString, err := json.Marshal(someError)
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	srv, close := mock.NewTLSServer()
 	defer close()
 	srv.SetResponse(
-		mock.WithBody(jsonString),
+		mock.WithBody([]byte(`{"code":"SomeCode","message":"Some message"}`)),
 		mock.WithStatusCode(404))
 
 	pl := azruntime.NewPipeline(srv)
@@ -36,18 +28,21 @@ func TestEnsureErrorIsGeneratedOnResponse(t *testing.T) {
 		resourceType:    resourceTypeDatabase,
 		resourceAddress: "",
 	}
-	_, err = connection.sendGetRequest("/", context.Background(), operationContext, &ReadContainerOptions{}, nil)
+	_, err := connection.sendGetRequest("/", context.Background(), operationContext, &ReadContainerOptions{}, nil)
 	if err == nil {
 		t.Fatal("Expected error")
 	}
 
-	asError := err.(*cosmosError)
-	if asError.ErrorCode() != someError.Code {
-		t.Errorf("Expected %v, but got %v", someError.Code, asError.ErrorCode())
+	var asError *azcore.ResponseError
+	if !errors.As(err, &asError) {
+		t.Fatalf("Expected an *azcore.ResponseError, but got %T", err)
+	}
+	if asError.ErrorCode != "SomeCode" {
+		t.Errorf("Expected SomeCode, but got %v", asError.ErrorCode)
 	}
 
-	if err.Error() != asError.Error() {
-		t.Errorf("Expected %v, but got %v", err.Error(), asError.Error())
+	if asError.StatusCode != 404 {
+		t.Errorf("Expected 404 Not Found, but got %v", asError.StatusCode)
 	}
 }
 
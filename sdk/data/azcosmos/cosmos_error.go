@@ -4,59 +4,24 @@
 package azcosmos
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
 	azruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 )
 
-// cosmosError is used as base error for any error response from the Cosmos service.
-type cosmosError struct {
-	body        string
-	code        string
-	rawResponse *http.Response
-}
-
-func (e *cosmosError) StatusCode() int {
-	if e.rawResponse == nil {
-		return 0
-	}
-	return e.rawResponse.StatusCode
-}
-
-func (e *cosmosError) ErrorCode() string {
-	return e.code
-}
-
-func (e *cosmosError) RawResponse() *http.Response {
-	return e.rawResponse
-}
-
+// newCosmosError returns an *azcore.ResponseError for an error response from the Cosmos service.
+// Its ErrorCode and Message come from the response body's code and message.
 func newCosmosError(response *http.Response) error {
-	bytesRead, err := azruntime.Payload(response)
-	if err != nil {
-		return err
-	}
-
-	cError := cosmosError{
-		rawResponse: response,
-		body:        string(bytesRead),
-	}
-
-	// Attempt to extract Code from body
-	var cErrorResponse cosmosErrorResponse
-	err = json.Unmarshal(bytesRead, &cErrorResponse)
-	if err == nil {
-		cError.code = cErrorResponse.Code
-	}
-
-	return &cError
+	return azruntime.NewResponseError(nil, response)
 }
 
+// newCosmosErrorWithStatusCode returns an *azcore.ResponseError for a failure detected by the client,
+// such as a missing resource, with a response containing only the status code and request charge.
 func newCosmosErrorWithStatusCode(statusCode int, requestCharge *float32) error {
 	rawResponse := &http.Response{
 		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
 		Header:     http.Header{},
 	}
 
@@ -64,18 +29,5 @@ func newCosmosErrorWithStatusCode(statusCode int, requestCharge *float32) error
 		rawResponse.Header.Add(cosmosHeaderRequestCharge, fmt.Sprint(*requestCharge))
 	}
 
-	return &cosmosError{
-		rawResponse: rawResponse,
-	}
-}
-
-func (e *cosmosError) Error() string {
-	if e.body == "" {
-		return "response contained no body"
-	}
-	return e.body
-}
-
-type cosmosErrorResponse struct {
-	Code string `json:"Code"`
+	return azruntime.NewResponseError(nil, rawResponse)
 }
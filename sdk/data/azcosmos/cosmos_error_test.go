@@ -5,10 +5,11 @@ package azcosmos
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	azruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/mock"
 )
@@ -27,9 +28,15 @@ func TestCosmosErrorOnEmptyResponse(t *testing.T) {
 	pl := azruntime.NewPipeline(srv)
 	resp, _ := pl.Do(req)
 
-	cError := newCosmosError(resp)
-	if cError.Error() != "response contained no body" {
-		t.Errorf("Expected response contained no body, but got %v", cError)
+	var asError *azcore.ResponseError
+	if !errors.As(newCosmosError(resp), &asError) {
+		t.Fatal("Expected an *azcore.ResponseError")
+	}
+	if asError.StatusCode != 404 {
+		t.Errorf("Expected 404 Not Found, but got %v", asError.StatusCode)
+	}
+	if asError.ErrorCode != "" {
+		t.Errorf("Expected no error code, but got %v", asError.ErrorCode)
 	}
 }
 
@@ -48,26 +55,28 @@ func TestCosmosErrorOnNonJsonBody(t *testing.T) {
 	pl := azruntime.NewPipeline(srv)
 	resp, _ := pl.Do(req)
 
-	cError := newCosmosError(resp)
-	if cError.Error() != "This is not JSON" {
-		t.Errorf("Expected This is not JSON, but got %v", cError)
+	var asError *azcore.ResponseError
+	if !errors.As(newCosmosError(resp), &asError) {
+		t.Fatal("Expected an *azcore.ResponseError")
 	}
-}
-
-func TestCosmosErrorOnJsonBody(t *testing.T) {
-	someError := &cosmosErrorResponse{
-		Code: "SomeCode",
+	if asError.ErrorCode != "" {
+		t.Errorf("Expected no error code, but got %v", asError.ErrorCode)
 	}
-
-	jsonString, err := json.Marshal(someError)
+	body, err := azruntime.Payload(asError.RawResponse())
 	if err != nil {
 		t.Fatal(err)
 	}
+	if string(body) != "This is not JSON" {
+		t.Errorf("Expected This is not JSON, but got %v", string(body))
+	}
+}
 
+func TestCosmosErrorOnJsonBody(t *testing.T) {
 	srv, close := mock.NewTLSServer()
 	defer close()
 	srv.SetResponse(
-		mock.WithBody(jsonString),
+		mock.WithBody([]byte(`{"code":"SomeCode","message":"Some message"}`)),
+		mock.WithHeader("x-ms-request-id", "some-request-id"),
 		mock.WithStatusCode(404))
 
 	req, err := azruntime.NewRequest(context.Background(), http.MethodGet, srv.URL())
@@ -78,17 +87,37 @@ func TestCosmosErrorOnJsonBody(t *testing.T) {
 	pl := azruntime.NewPipeline(srv)
 	resp, _ := pl.Do(req)
 
-	cError := newCosmosError(resp)
-	asError := cError.(*cosmosError)
-	if asError.ErrorCode() != someError.Code {
-		t.Errorf("Expected %v, but got %v", someError.Code, asError.ErrorCode())
+	var asError *azcore.ResponseError
+	if !errors.As(newCosmosError(resp), &asError) {
+		t.Fatal("Expected an *azcore.ResponseError")
+	}
+	if asError.ErrorCode != "SomeCode" {
+		t.Errorf("Expected SomeCode, but got %v", asError.ErrorCode)
+	}
+
+	if asError.Message != "Some message" {
+		t.Errorf("Expected Some message, but got %v", asError.Message)
+	}
+
+	if asError.StatusCode != 404 {
+		t.Errorf("Expected 404 Not Found, but got %v", asError.StatusCode)
 	}
 
-	if asError.StatusCode() != 404 {
-		t.Errorf("Expected 404 Not Found, but got %v", asError.StatusCode())
+	if asError.RequestID != "some-request-id" {
+		t.Errorf("Expected some-request-id, but got %v", asError.RequestID)
 	}
+}
 
-	if asError.Error() != string(jsonString) {
-		t.Errorf("Expected %v, but got %v", string(jsonString), asError.Error())
+func TestCosmosErrorWithStatusCode(t *testing.T) {
+	charge := float32(2.5)
+	var asError *azcore.ResponseError
+	if !errors.As(newCosmosErrorWithStatusCode(http.StatusNotFound, &charge), &asError) {
+		t.Fatal("Expected an *azcore.ResponseError")
+	}
+	if asError.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 Not Found, but got %v", asError.StatusCode)
+	}
+	if h := asError.RawResponse().Header.Get(cosmosHeaderRequestCharge); h != "2.5" {
+		t.Errorf("Expected a request charge of 2.5, but got %v", h)
 	}
 }
@@ -2,9 +2,13 @@ module github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos
 
 go 1.16
 
+replace github.com/Azure/azure-sdk-for-go/sdk/azcore => ../../azcore
+
 require (
 	github.com/Azure/azure-sdk-for-go v57.3.0+incompatible
 	github.com/Azure/azure-sdk-for-go/sdk/azcore v0.19.0
-	github.com/Azure/azure-sdk-for-go/sdk/internal v0.7.0
+	github.com/Azure/azure-sdk-for-go/sdk/internal v0.7.1
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -21,22 +21,58 @@ import (
 type Client struct {
 	client  *generated.TableClient
 	service *ServiceClient
-	cred    azcore.Credential
-	name    string
+	// sharedKey signs SAS tokens. It's nil unless the client authorizes requests with a SharedKeyCredential.
+	sharedKey *SharedKeyCredential
+	name      string
 }
 
-// NewClient creates a Client struct in the context of the table specified in the serviceURL, credential, and options.
+// NewClient creates a Client struct in the context of the table specified in the serviceURL, Azure AD credential, and options.
 // The serviceURL param is expected to have the name of the table in a format similar to: "https://myAccountName.core.windows.net/<myTableName>".
-// If the serviceURL contains a Shared Access Signature, use azcore.NewAnonymousCredential() as the credential.
-func NewClient(serviceURL string, cred azcore.Credential, options *ClientOptions) (*Client, error) {
-	if options == nil {
-		options = &ClientOptions{}
+func NewClient(serviceURL string, cred azcore.TokenCredential, options *ClientOptions) (*Client, error) {
+	rawServiceURL, tableName, err := parseTableURL(serviceURL)
+	if err != nil {
+		return &Client{}, err
 	}
+	s, err := NewServiceClient(rawServiceURL, cred, options)
+	if err != nil {
+		return &Client{}, err
+	}
+	return s.NewClient(tableName), nil
+}
 
-	parsedUrl, err := url.Parse(serviceURL)
+// NewClientWithNoCredential creates a Client struct in the context of the table specified in the serviceURL and options.
+// Use it when the serviceURL contains a Shared Access Signature, or for public access.
+func NewClientWithNoCredential(serviceURL string, options *ClientOptions) (*Client, error) {
+	rawServiceURL, tableName, err := parseTableURL(serviceURL)
+	if err != nil {
+		return &Client{}, err
+	}
+	s, err := NewServiceClientWithNoCredential(rawServiceURL, options)
+	if err != nil {
+		return &Client{}, err
+	}
+	return s.NewClient(tableName), nil
+}
+
+// NewClientWithSharedKey creates a Client struct in the context of the table specified in the serviceURL, SharedKeyCredential, and options.
+func NewClientWithSharedKey(serviceURL string, cred *SharedKeyCredential, options *ClientOptions) (*Client, error) {
+	rawServiceURL, tableName, err := parseTableURL(serviceURL)
+	if err != nil {
+		return &Client{}, err
+	}
+	s, err := NewServiceClientWithSharedKey(rawServiceURL, cred, options)
 	if err != nil {
 		return &Client{}, err
 	}
+	return s.NewClient(tableName), nil
+}
+
+// parseTableURL splits a table URL into the service URL, including any Shared Access Signature, and the table name.
+func parseTableURL(serviceURL string) (string, string, error) {
+	parsedUrl, err := url.Parse(serviceURL)
+	if err != nil {
+		return "", "", err
+	}
 
 	tableName := parsedUrl.Path[1:]
 	rawServiceURL := parsedUrl.Scheme + "://" + parsedUrl.Host
@@ -52,12 +88,7 @@ func NewClient(serviceURL string, cred azcore.Credential, options *ClientOptions
 	if len(sas) > 0 {
 		rawServiceURL += "/?" + sas.Encode()
 	}
-
-	s, err := NewServiceClient(rawServiceURL, cred, options)
-	if err != nil {
-		return &Client{}, err
-	}
-	return s.NewClient(tableName), nil
+	return rawServiceURL, tableName, nil
 }
 
 type CreateTableResponse struct {
@@ -616,10 +647,10 @@ func (t *Client) SetAccessPolicy(ctx context.Context, options *SetAccessPolicyOp
 }
 
 // GetTableSASToken is a convenience method for generating a SAS token for a specific table.
-// It can only be used if the supplied azcore.Credential during creation was a SharedKeyCredential.
+// It can only be used if the client was created with a SharedKeyCredential.
 func (t Client) GetTableSASToken(permissions SASPermissions, start time.Time, expiry time.Time) (string, error) {
-	cred, ok := t.cred.(*SharedKeyCredential)
-	if !ok {
+	cred := t.sharedKey
+	if cred == nil {
 		return "", errors.New("credential is not a SharedKeyCredential. SAS can only be signed with a SharedKeyCredential")
 	}
 	qps, err := SASSignatureValues{
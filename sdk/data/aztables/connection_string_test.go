@@ -22,16 +22,15 @@ func TestConnectionStringParser(t *testing.T) {
 	require.Equal(t, serviceURL, "https://dummyaccount.table.core.windows.net")
 	require.NotNil(t, cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred := cred
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	require.NoError(t, err)
 	require.NotNil(t, client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred = client.sharedKey
+	require.NotNil(t, sharedKeyCred)
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "https://"))
@@ -45,16 +44,15 @@ func TestConnectionStringParserHTTP(t *testing.T) {
 	require.Equal(t, serviceURL, "http://dummyaccount.table.core.windows.net")
 	require.NotNil(t, cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred := cred
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	require.NoError(t, err)
 	require.NotNil(t, client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred = client.sharedKey
+	require.NotNil(t, sharedKeyCred)
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "http://"))
@@ -68,16 +66,15 @@ func TestConnectionStringParserBasic(t *testing.T) {
 	require.Equal(t, serviceURL, "https://dummyaccount.table.core.windows.net")
 	require.NotNil(t, cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred := cred
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	require.NoError(t, err)
 	require.NotNil(t, client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred = client.sharedKey
+	require.NotNil(t, sharedKeyCred)
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "https://"))
@@ -91,16 +88,15 @@ func TestConnectionStringParserCustomDomain(t *testing.T) {
 	require.Equal(t, serviceURL, "www.mydomain.com")
 	require.NotNil(t, cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred := cred
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	require.NoError(t, err)
 	require.NotNil(t, client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKeyCred = client.sharedKey
+	require.NotNil(t, sharedKeyCred)
 	require.Equal(t, sharedKeyCred.accountName, "dummyaccount")
 	require.Equal(t, getAccountKey(sharedKeyCred), "secretkeykey")
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "www."))
@@ -131,11 +127,12 @@ func TestConnectionStringSAS(t *testing.T) {
 	serviceURL, cred, err := parseConnectionString(connStr)
 	require.NoError(t, err)
 	require.Equal(t, serviceURL, "https://dummyaccount.table.core.windows.net/?fakesharedaccesssignature")
-	require.NotNil(t, cred)
+	require.Nil(t, cred)
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	require.NoError(t, err)
 	require.NotNil(t, client)
+	require.Nil(t, client.sharedKey)
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "https://"))
 	require.True(t, strings.Contains(client.client.Con.Endpoint(), "core.windows.net"))
 }
@@ -153,8 +150,8 @@ func TestConnectionStringCosmos(t *testing.T) {
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "https://"))
 	require.True(t, strings.Contains(client.client.Con.Endpoint(), "cosmos.azure.com:443"))
 
-	sharedKey, ok := client.cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKey := client.sharedKey
+	require.NotNil(t, sharedKey)
 	require.Equal(t, sharedKey.accountName, "dummyaccountname")
 	require.Equal(t, getAccountKey(sharedKey), "secretkeykey")
 }
@@ -172,8 +169,8 @@ func TestConnectionStringChinaCloud(t *testing.T) {
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "http://"))
 	require.True(t, strings.Contains(client.client.Con.Endpoint(), "core.chinacloudapi.cn"))
 
-	sharedKey, ok := client.cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKey := client.sharedKey
+	require.NotNil(t, sharedKey)
 	require.Equal(t, sharedKey.accountName, "dummyaccountname")
 	require.Equal(t, getAccountKey(sharedKey), "secretkeykey")
 }
@@ -191,8 +188,8 @@ func TestConnectionStringAzurite(t *testing.T) {
 	require.True(t, strings.HasPrefix(client.client.Con.Endpoint(), "http://"))
 	require.True(t, strings.Contains(client.client.Con.Endpoint(), "http://local-machine:11002/custom/account/path/faketokensignature"))
 
-	sharedKey, ok := client.cred.(*SharedKeyCredential)
-	require.True(t, ok)
+	sharedKey := client.sharedKey
+	require.NotNil(t, sharedKey)
 	require.Equal(t, sharedKey.accountName, "dummyaccountname")
 	require.Equal(t, getAccountKey(sharedKey), "secretkeykey")
 }
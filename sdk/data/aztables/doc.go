@@ -25,7 +25,7 @@ URL and a credential that allows you to access the account.
 
 	cred, err := aztables.NewSharedKeyCredential("myAccountName", "myAccountKey")
 	handle(err)
-	serviceClient, err := aztables.NewServiceClient("https://<my_account_name>.table.core.windows.net/", cred, nil)
+	serviceClient, err := aztables.NewServiceClientWithSharedKey("https://<my_account_name>.table.core.windows.net/", cred, nil)
 	handle(err)
 
 
@@ -44,7 +44,7 @@ Use the key as the credential parameter to authenticate the client:
 
 	cred, err := aztables.NewSharedKeyCredential("myAccountName", "myAccountKey")
 	handle(err)
-	serviceClient, err := aztables.NewServiceClient("https://<my_account_name>.table.core.windows.net/", cred, nil)
+	serviceClient, err := aztables.NewServiceClientWithSharedKey("https://<my_account_name>.table.core.windows.net/", cred, nil)
 	handle(err)
 
 Using a Connection String
@@ -61,7 +61,7 @@ ServiceClient.GetAccountSASToken or Client.GetTableSASToken() functions.
 
 	cred, err := aztables.NewSharedKeyCredential("myAccountName", "myAccountKey")
 	handle(err)
-	service, err := aztables.NewServiceClient("https://<my_account_name>.table.core.windows.net", cred, nil)
+	service, err := aztables.NewServiceClientWithSharedKey("https://<my_account_name>.table.core.windows.net", cred, nil)
 	handle(err)
 
 	resources := aztables.AccountSASResourceTypes{Service: true}
@@ -71,7 +71,7 @@ ServiceClient.GetAccountSASToken or Client.GetTableSASToken() functions.
 	sasUrl, err := service.GetAccountSASToken(resources, permission, start, expiry)
 	handle(err)
 
-	sasService, err := aztables.NewServiceClient(sasUrl, azcore.AnonymousCredential(), nil)
+	sasService, err := aztables.NewServiceClientWithNoCredential(sasUrl, nil)
 	handle(err)
 
 
@@ -137,7 +137,7 @@ Create a table in your account and get a `Client` to perform operations on the n
 
 	cred, err := aztables.NewSharedKeyCredential("myAccountName", "myAccountKey")
 	handle(err)
-	service, err := aztables.NewServiceClient("https://<my_account_name>.table.core.windows.net", cred, nil)
+	service, err := aztables.NewServiceClientWithSharedKey("https://<my_account_name>.table.core.windows.net", cred, nil)
 	handle(err)
 	resp, err := service.CreateTable("myTable")
 
@@ -145,7 +145,7 @@ Creating Entities
 
 	cred, err := aztables.NewSharedKeyCredential("myAccountName", "myAccountKey")
 	handle(err)
-	service, err := aztables.NewServiceClient("https://<my_account_name>.table.core.windows.net", cred, nil)
+	service, err := aztables.NewServiceClientWithSharedKey("https://<my_account_name>.table.core.windows.net", cred, nil)
 	handle(err)
 
 	myEntity := aztables.EDMEntity{
@@ -176,7 +176,7 @@ Querying entities
 
 	cred, err := aztables.NewSharedKeyCredential("myAccountName", "myAccountKey")
 	handle(err)
-	client, err := aztables.NewClient("https://myAccountName.table.core.windows.net/myTableName", cred, nil)
+	client, err := aztables.NewClientWithSharedKey("https://myAccountName.table.core.windows.net/myTableName", cred, nil)
 	handle(err)
 
 	filter := "PartitionKey eq 'markers' or RowKey eq 'id-001'"
@@ -2,12 +2,16 @@ module github.com/Azure/azure-sdk-for-go/sdk/data/aztables
 
 go 1.16
 
+replace github.com/Azure/azure-sdk-for-go/sdk/azcore => ../../azcore
+
+replace github.com/Azure/azure-sdk-for-go/sdk/azidentity => ../../azidentity
+
 replace github.com/Azure/azure-sdk-for-go/sdk/internal => ../../internal
 
 require (
 	github.com/Azure/azure-sdk-for-go/sdk/azcore v0.19.0
 	github.com/Azure/azure-sdk-for-go/sdk/azidentity v0.10.0
-	github.com/Azure/azure-sdk-for-go/sdk/internal v0.7.0
+	github.com/Azure/azure-sdk-for-go/sdk/internal v0.7.1
 	github.com/stretchr/testify v1.7.0
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,19 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package internal
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// NewConnection creates an instance of the connection type with the specified endpoint.
+// plOpts contains the client's policies, such as the one authorizing requests.
+// Pass nil to accept the default options; this is the same as passing a zero-value options.
+func NewConnection(endpoint string, plOpts runtime.PipelineOptions, options *policy.ClientOptions) *connection {
+	return &connection{u: endpoint, p: runtime.NewClientPipeline(module, version, plOpts, options)}
+}
@@ -10,10 +10,10 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/internal/recording"
 	"github.com/stretchr/testify/require"
@@ -61,51 +61,46 @@ func NewFakeCredential(accountName, accountKey string) *FakeCredential {
 	}
 }
 
-type fakeCredPolicy struct {
-	cred *FakeCredential
+// GetToken returns a token derived from the fake account's name and key
+func (f *FakeCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (*azcore.AccessToken, error) {
+	return &azcore.AccessToken{Token: f.accountName + ":" + f.accountKey, ExpiresOn: time.Now().Add(time.Hour)}, nil
 }
 
-func newFakeCredPolicy(cred *FakeCredential, opts runtime.AuthenticationOptions) *fakeCredPolicy {
-	return &fakeCredPolicy{cred: cred}
-}
-
-func (f *fakeCredPolicy) Do(req *policy.Request) (*http.Response, error) {
-	authHeader := strings.Join([]string{"Authorization ", f.cred.accountName, ":", f.cred.accountKey}, "")
-	req.Raw().Header.Set(headerAuthorization, authHeader)
-	return req.Next()
-}
-
-func (f *FakeCredential) NewAuthenticationPolicy(options runtime.AuthenticationOptions) policy.Policy {
-	return newFakeCredPolicy(f, options)
-}
-
-func createClientForRecording(t *testing.T, tableName string, serviceURL string, cred azcore.Credential) (*Client, error) {
+// createClientForRecording creates a Client authorized by cred, or an anonymous Client when cred is nil.
+func createClientForRecording(t *testing.T, tableName string, serviceURL string, cred *SharedKeyCredential) (*Client, error) {
 	p := NewRecordingPolicy(t, &recording.RecordingOptions{UseHTTPS: true})
 	client, err := recording.GetHTTPClient(t)
 	require.NoError(t, err)
 
-	options := &ClientOptions{
+	options := &ClientOptions{ClientOptions: azcore.ClientOptions{
 		PerCallPolicies: []policy.Policy{p},
 		Transport:       client,
-	}
+	}}
 	if !strings.HasSuffix(serviceURL, "/") && tableName != "" {
 		serviceURL += "/"
 	}
 	serviceURL += tableName
 
-	return NewClient(serviceURL, cred, options)
+	if cred == nil {
+		return NewClientWithNoCredential(serviceURL, options)
+	}
+	return NewClientWithSharedKey(serviceURL, cred, options)
 }
 
-func createServiceClientForRecording(t *testing.T, serviceURL string, cred azcore.Credential) (*ServiceClient, error) {
+// createServiceClientForRecording creates a ServiceClient authorized by cred, or an anonymous ServiceClient when cred is nil.
+func createServiceClientForRecording(t *testing.T, serviceURL string, cred *SharedKeyCredential) (*ServiceClient, error) {
 	p := NewRecordingPolicy(t, &recording.RecordingOptions{UseHTTPS: true})
 	client, err := recording.GetHTTPClient(t)
 	require.NoError(t, err)
 
-	options := &ClientOptions{
+	options := &ClientOptions{ClientOptions: azcore.ClientOptions{
 		PerCallPolicies: []policy.Policy{p},
 		Transport:       client,
+	}}
+	if cred == nil {
+		return NewServiceClientWithNoCredential(serviceURL, options)
 	}
-	return NewServiceClient(serviceURL, cred, options)
+	return NewServiceClientWithSharedKey(serviceURL, cred, options)
 }
 
 func initClientTest(t *testing.T, service string, createTable bool) (*Client, func()) {
@@ -155,7 +150,7 @@ func initServiceTest(t *testing.T, service string) (*ServiceClient, func()) {
 	}
 }
 
-func getAADCredential(t *testing.T) (azcore.Credential, error) { //nolint
+func getAADCredential(t *testing.T) (azcore.TokenCredential, error) { //nolint
 	if recording.GetRecordMode() == "playback" {
 		return NewFakeCredential("fakestorageaccount", "fakeAccountKey"), nil
 	}
@@ -163,7 +158,7 @@ func getAADCredential(t *testing.T) (azcore.Credential, error) { //nolint
 	return azidentity.NewDefaultAzureCredential(nil)
 }
 
-func getSharedKeyCredential(t *testing.T) (azcore.Credential, error) {
+func getSharedKeyCredential(t *testing.T) (*SharedKeyCredential, error) {
 	if recording.GetRecordMode() == "playback" {
 		return NewSharedKeyCredential("accountName", "daaaaaaaaaabbbbbbbbbbcccccccccccccccccccdddddddddddddddddddeeeeeeeeeeefffffffffffggggg==")
 	}
@@ -175,7 +170,7 @@ func getSharedKeyCredential(t *testing.T) (azcore.Credential, error) {
 }
 
 func createStorageClient(t *testing.T) (*Client, error) {
-	var cred azcore.Credential
+	var cred *SharedKeyCredential
 	var err error
 	accountName := recording.GetEnvVariable(t, "TABLES_STORAGE_ACCOUNT_NAME", "fakestorageaccount")
 	accountKey := recording.GetEnvVariable(t, "TABLES_PRIMARY_STORAGE_ACCOUNT_KEY", "fakestorageaccountkey")
@@ -197,7 +192,7 @@ func createStorageClient(t *testing.T) (*Client, error) {
 }
 
 func createCosmosClient(t *testing.T) (*Client, error) {
-	var cred azcore.Credential
+	var cred *SharedKeyCredential
 	accountName := recording.GetEnvVariable(t, "TABLES_COSMOS_ACCOUNT_NAME", "fakestorageaccount")
 	if recording.GetRecordMode() == "playback" {
 		accountName = "fakestorageaccount"
@@ -215,7 +210,7 @@ func createCosmosClient(t *testing.T) (*Client, error) {
 }
 
 func createStorageServiceClient(t *testing.T) (*ServiceClient, error) {
-	var cred azcore.Credential
+	var cred *SharedKeyCredential
 	var err error
 	accountName := recording.GetEnvVariable(t, "TABLES_STORAGE_ACCOUNT_NAME", "fakestorageaccount")
 	accountKey := recording.GetEnvVariable(t, "TABLES_PRIMARY_STORAGE_ACCOUNT_KEY", "fakestorageaccountkey")
@@ -234,7 +229,7 @@ func createStorageServiceClient(t *testing.T) (*ServiceClient, error) {
 }
 
 func createCosmosServiceClient(t *testing.T) (*ServiceClient, error) {
-	var cred azcore.Credential
+	var cred *SharedKeyCredential
 	accountName := recording.GetEnvVariable(t, "TABLES_COSMOS_ACCOUNT_NAME", "fakestorageaccount")
 	if recording.GetRecordMode() == "playback" {
 		accountName = "fakestorageaccount"
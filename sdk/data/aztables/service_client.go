@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	generated "github.com/Azure/azure-sdk-for-go/sdk/data/aztables/internal"
 )
 
@@ -18,35 +20,52 @@ import (
 type ServiceClient struct {
 	client  *generated.TableClient
 	service *generated.ServiceClient
-	cred    azcore.Credential
+	// sharedKey signs SAS tokens. It's nil unless the client authorizes requests with a SharedKeyCredential.
+	sharedKey *SharedKeyCredential
 }
 
-// NewServiceClient creates a ServiceClient struct using the specified serviceURL, credential, and options.
-func NewServiceClient(serviceURL string, cred azcore.Credential, options *ClientOptions) (*ServiceClient, error) {
+// NewServiceClient creates a ServiceClient struct using the specified serviceURL, Azure AD credential, and options.
+func NewServiceClient(serviceURL string, cred azcore.TokenCredential, options *ClientOptions) (*ServiceClient, error) {
+	return newServiceClient(serviceURL, newTokenCredPolicy(cred), nil, options)
+}
+
+// NewServiceClientWithNoCredential creates a ServiceClient struct using the specified serviceURL and options.
+// Use it when the serviceURL contains a Shared Access Signature, or for public access.
+func NewServiceClientWithNoCredential(serviceURL string, options *ClientOptions) (*ServiceClient, error) {
+	return newServiceClient(serviceURL, nil, nil, options)
+}
+
+// NewServiceClientWithSharedKey creates a ServiceClient struct using the specified serviceURL, SharedKeyCredential, and options.
+func NewServiceClientWithSharedKey(serviceURL string, cred *SharedKeyCredential, options *ClientOptions) (*ServiceClient, error) {
+	return newServiceClient(serviceURL, newSharedKeyCredPolicy(cred), cred, options)
+}
+
+func newServiceClient(serviceURL string, authPolicy policy.Policy, sharedKey *SharedKeyCredential, options *ClientOptions) (*ServiceClient, error) {
 	if options == nil {
 		options = &ClientOptions{}
 	}
-	conOptions := options.getConnectionOptions()
+	plOpts := runtime.PipelineOptions{PerRetry: []policy.Policy{runtime.NewSecondaryReadPolicy(&options.SecondaryRead)}}
 	if isCosmosEndpoint(serviceURL) {
-		conOptions.PerCallPolicies = append(conOptions.PerCallPolicies, cosmosPatchTransformPolicy{})
+		plOpts.PerCall = append(plOpts.PerCall, cosmosPatchTransformPolicy{})
+	}
+	if authPolicy != nil {
+		plOpts.PerRetry = append(plOpts.PerRetry, authPolicy)
 	}
-	conOptions.PerCallPolicies = append(conOptions.PerCallPolicies, options.PerCallPolicies...)
-	conOptions.PerRetryPolicies = append(conOptions.PerRetryPolicies, options.PerTryPolicies...)
-	con := generated.NewConnection(serviceURL, cred, conOptions)
+	con := generated.NewConnection(serviceURL, plOpts, &options.ClientOptions)
 	return &ServiceClient{
-		client:  generated.NewTableClient(con),
-		service: generated.NewServiceClient(con),
-		cred:    cred,
+		client:    generated.NewTableClient(con),
+		service:   generated.NewServiceClient(con),
+		sharedKey: sharedKey,
 	}, nil
 }
 
 // NewClient returns a pointer to a Client affinitized to the specified table name and initialized with the same serviceURL and credentials as this ServiceClient
 func (t *ServiceClient) NewClient(tableName string) *Client {
 	return &Client{
-		client:  t.client,
-		cred:    t.cred,
-		name:    tableName,
-		service: t,
+		client:    t.client,
+		sharedKey: t.sharedKey,
+		name:      tableName,
+		service:   t,
 	}
 }
 
@@ -359,10 +378,10 @@ func (t *ServiceClient) SetProperties(ctx context.Context, properties ServicePro
 }
 
 // GetAccountSASToken is a convenience method for generating a SAS token for the currently pointed at account. This methods returns the full service URL and an error
-// if there was an error during creation. This method can only be used if the supplied azcore.Credential during creation was a SharedKeyCredential.
+// if there was an error during creation. This method can only be used if the client was created with a SharedKeyCredential.
 func (t ServiceClient) GetAccountSASToken(resources AccountSASResourceTypes, permissions AccountSASPermissions, start time.Time, expiry time.Time) (string, error) {
-	cred, ok := t.cred.(*SharedKeyCredential)
-	if !ok {
+	cred := t.sharedKey
+	if cred == nil {
 		return "", errors.New("credential is not a SharedKeyCredential. SAS can only be signed with a SharedKeyCredential")
 	}
 	qps, err := AccountSASSignatureValues{
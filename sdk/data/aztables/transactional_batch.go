@@ -37,44 +37,6 @@ const (
 	InsertReplace TransactionType = "insertreplace"
 )
 
-type oDataErrorMessage struct {
-	Lang  string `json:"lang"`
-	Value string `json:"value"`
-}
-
-type oDataError struct {
-	Code    string            `json:"code"`
-	Message oDataErrorMessage `json:"message"`
-}
-
-type tableTransactionError struct {
-	ODataError        oDataError `json:"odata.error"`
-	FailedEntityIndex int
-}
-
-type transactionError struct {
-	rawResponse *http.Response
-	statusCode  int
-	errorCode   string
-	odataError  oDataError
-}
-
-func (t *transactionError) StatusCode() int {
-	return t.rawResponse.StatusCode
-}
-
-func (t *transactionError) ErrorCode() string {
-	return t.odataError.Code
-}
-
-func (t *transactionError) RawResponse() *http.Response {
-	return t.rawResponse
-}
-
-func (t *transactionError) Error() string {
-	return fmt.Sprintf("Code: %s, Message: %s", t.odataError.Code, t.odataError.Message.Value)
-}
-
 type TransactionAction struct {
 	ActionType TransactionType
 	Entity     []byte
@@ -220,10 +182,7 @@ func buildTransactionResponse(req *policy.Request, resp *http.Response, itemCoun
 				return &TransactionResponse{}, err
 			} else {
 				innerResponses = []http.Response{*r}
-				retError := newTableTransactionError(errorBody, resp)
-				ret := retError.(*transactionError)
-				ret.statusCode = r.StatusCode
-				return &result, runtime.NewResponseError(retError, resp)
+				return &result, newTableTransactionError(errorBody, r)
 			}
 		}
 		innerResponses[i] = *r
@@ -241,17 +200,11 @@ func getBoundaryName(bytesBody []byte) string {
 	return string(bytesBody[2:end])
 }
 
-// newTableTransactionError handles the SubmitTransaction error response.
+// newTableTransactionError returns an *azcore.ResponseError for the failed SubmitTransaction response resp,
+// whose body errorBody was already read.
 func newTableTransactionError(errorBody []byte, resp *http.Response) error {
-	oe := tableTransactionError{}
-	if err := json.Unmarshal(errorBody, &oe); err == nil {
-		return &transactionError{
-			rawResponse: resp,
-			errorCode:   oe.ODataError.Code,
-			odataError:  oe.ODataError,
-		}
-	}
-	return fmt.Errorf("unknown error: %s", string(errorBody))
+	resp.Body = ioutil.NopCloser(bytes.NewReader(errorBody))
+	return runtime.NewResponseError(nil, resp)
 }
 
 // generateChangesetBody generates the individual changesets for the various operations within the batch request.
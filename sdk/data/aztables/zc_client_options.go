@@ -4,37 +4,27 @@
 package aztables
 
 import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
-	generated "github.com/Azure/azure-sdk-for-go/sdk/data/aztables/internal"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 )
 
-type ClientOptions struct {
-	// Transport sets the transport for making HTTP requests.
-	Transport policy.Transporter
-
-	// Retry configures the built-in retry policy behavior.
-	Retry policy.RetryOptions
+// tokenScope is the scope of Azure AD tokens for the Tables service
+const tokenScope = "https://storage.azure.com/.default"
 
-	// Telemetry configures the built-in telemetry policy behavior.
-	Telemetry policy.TelemetryOptions
+// SecondaryReadOptions configures reads against the secondary endpoint of a read-access geo-redundant (RA-GRS)
+// storage account, e.g. "myaccount-secondary.table.core.windows.net".
+type SecondaryReadOptions = policy.SecondaryReadOptions
 
-	// PerCallPolicies are policies that execute once per operation
-	PerCallPolicies []policy.Policy
+// ClientOptions configures the pipeline of the Tables clients.
+type ClientOptions struct {
+	azcore.ClientOptions
 
-	// PerTryPolicies are policies that execute once per retry of an operation
-	PerTryPolicies []policy.Policy
+	// SecondaryRead configures reads against the account's RA-GRS secondary endpoint.
+	SecondaryRead SecondaryReadOptions
 }
 
-func (o *ClientOptions) getConnectionOptions() *generated.ConnectionOptions {
-	if o == nil {
-		return nil
-	}
-
-	return &generated.ConnectionOptions{
-		HTTPClient:       o.Transport,
-		Retry:            o.Retry,
-		Telemetry:        o.Telemetry,
-		PerCallPolicies:  []policy.Policy{},
-		PerRetryPolicies: []policy.Policy{},
-	}
+// newTokenCredPolicy returns a policy authorizing requests with tokens from cred
+func newTokenCredPolicy(cred azcore.TokenCredential) policy.Policy {
+	return runtime.NewBearerTokenPolicy(cred, runtime.AuthenticationOptions{TokenRequest: policy.TokenRequestOptions{Scopes: []string{tokenScope}}})
 }
@@ -13,7 +13,6 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/keyvault/azsecrets/internal"
 )
 
@@ -25,40 +24,7 @@ type Client struct {
 
 // ClientOptions are the configurable options on a Client.
 type ClientOptions struct {
-	// Transport sets the transport for making HTTP requests.
-	Transport policy.Transporter
-
-	// Retry configures the built-in retry policy behavior.
-	Retry policy.RetryOptions
-
-	// Telemetry configures the built-in telemetry policy behavior.
-	Telemetry policy.TelemetryOptions
-
-	// Logging configures the built-in logging policy behavior.
-	Logging policy.LogOptions
-
-	// PerCallPolicies contains custom policies to inject into the pipeline.
-	// Each policy is executed once per request.
-	PerCallPolicies []policy.Policy
-
-	// PerRetryPolicies contains custom policies to inject into the pipeline.
-	// Each policy is executed once per request, and for each retry request.
-	PerTryPolicies []policy.Policy
-}
-
-func (c *ClientOptions) toConnectionOptions() *internal.ConnectionOptions {
-	if c == nil {
-		return nil
-	}
-
-	return &internal.ConnectionOptions{
-		HTTPClient:       c.Transport,
-		Retry:            c.Retry,
-		Telemetry:        c.Telemetry,
-		Logging:          c.Logging,
-		PerCallPolicies:  c.PerCallPolicies,
-		PerRetryPolicies: c.PerTryPolicies,
-	}
+	azcore.ClientOptions
 }
 
 // NewClient returns a pointer to a Client object affinitized to a vaultUrl.
@@ -67,7 +33,7 @@ func NewClient(vaultUrl string, credential azcore.TokenCredential, options *Clie
 		options = &ClientOptions{}
 	}
 
-	conn := internal.NewConnection(credential, options.toConnectionOptions())
+	conn := internal.NewConnection(credential, &options.ClientOptions)
 
 	return &Client{
 		kvClient: &internal.KeyVaultClient{
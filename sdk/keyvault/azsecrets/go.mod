@@ -2,6 +2,10 @@ module github.com/Azure/azure-sdk-for-go/sdk/keyvault/azsecrets
 
 go 1.16
 
+replace github.com/Azure/azure-sdk-for-go/sdk/azcore => ../../azcore
+
+replace github.com/Azure/azure-sdk-for-go/sdk/azidentity => ../../azidentity
+
 replace github.com/Azure/azure-sdk-for-go/sdk/internal => ../../internal
 
 require (
@@ -0,0 +1,23 @@
+//go:build go1.16
+// +build go1.16
+
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License.
+
+package internal
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// NewConnection creates an instance of the connection type authorizing requests with tokens from cred.
+// Pass nil to accept the default options; this is the same as passing a zero-value options.
+func NewConnection(cred azcore.TokenCredential, options *policy.ClientOptions) *connection {
+	authPolicy := runtime.NewBearerTokenPolicy(cred, runtime.AuthenticationOptions{TokenRequest: policy.TokenRequestOptions{Scopes: scopes}})
+	client := &connection{
+		p: runtime.NewClientPipeline(module, version, runtime.PipelineOptions{PerRetry: []policy.Policy{authPolicy}}, options),
+	}
+	return client
+}
@@ -77,7 +77,7 @@ You can generate a SAS token from the Azure Portal under Shared Access Signature
 	credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
 	handle(err)
 
-	serviceClient, err := azblob.NewServiceClient(fmt.Sprintf("https://%s.blob.core.windows.net/", accountName), credential, nil)
+	serviceClient, err := azblob.NewServiceClientWithSharedKey(fmt.Sprintf("https://%s.blob.core.windows.net/", accountName), credential, nil)
 	handle(err)
 
     // Provide the convenience function with relevant info
@@ -90,7 +90,7 @@ You can generate a SAS token from the Azure Portal under Shared Access Signature
 	// ******************************************
 
 	// When someone receives the URL, they can access the resource using it in code like this, or a tool of some variety.
-	serviceClient, err = azblob.NewServiceClient(urlToSend, azcore.NewAnonymousCredential(), nil)
+	serviceClient, err = azblob.NewServiceClientWithNoCredential(urlToSend, nil)
 	handle(err)
 
 	// You can also break a blob URL up into its constituent parts
@@ -134,7 +134,7 @@ Examples
 
 	// Open up a service client.
 	// You'll need to specify a service URL, which for blob endpoints usually makes up the syntax http(s)://<account>.blob.core.windows.net/
-	service, err := NewServiceClient(fmt.Sprintf("https://%s.blob.core.windows.net/", accountName), cred, nil)
+	service, err := NewServiceClientWithSharedKey(fmt.Sprintf("https://%s.blob.core.windows.net/", accountName), cred, nil)
 	handle(err)
 
 	// All operations in the Azure Storage Blob SDK for Go operate on a context.Context, allowing you to control cancellation/timeout.
@@ -6,6 +6,7 @@ package azblob
 import (
 	"context"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"io"
 )
 
@@ -14,12 +15,28 @@ type AppendBlobClient struct {
 	client *appendBlobClient
 }
 
-func NewAppendBlobClient(blobURL string, cred azcore.Credential, options *ClientOptions) (AppendBlobClient, error) {
-	con := newConnection(blobURL, cred, options.getConnectionOptions())
+// NewAppendBlobClient creates an AppendBlobClient object using the specified URL, Azure AD credential, and options.
+func NewAppendBlobClient(blobURL string, cred azcore.TokenCredential, options *ClientOptions) (AppendBlobClient, error) {
+	return newAppendBlobClient(blobURL, newTokenCredPolicy(cred), nil, options), nil
+}
+
+// NewAppendBlobClientWithNoCredential creates an AppendBlobClient object using the specified URL and options.
+// Use it for public access or when the blobURL contains a shared access signature.
+func NewAppendBlobClientWithNoCredential(blobURL string, options *ClientOptions) (AppendBlobClient, error) {
+	return newAppendBlobClient(blobURL, nil, nil, options), nil
+}
+
+// NewAppendBlobClientWithSharedKey creates an AppendBlobClient object using the specified URL, shared key, and options.
+func NewAppendBlobClientWithSharedKey(blobURL string, cred *SharedKeyCredential, options *ClientOptions) (AppendBlobClient, error) {
+	return newAppendBlobClient(blobURL, newSharedKeyCredPolicy(cred), cred, options), nil
+}
+
+func newAppendBlobClient(blobURL string, authPolicy policy.Policy, sharedKey *SharedKeyCredential, options *ClientOptions) AppendBlobClient {
+	con := newConnection(blobURL, authPolicy, options)
 	return AppendBlobClient{
 		client:     &appendBlobClient{con: con},
-		BlobClient: BlobClient{client: &blobClient{con: con}},
-	}, nil
+		BlobClient: BlobClient{client: &blobClient{con: con}, sharedKey: sharedKey},
+	}
 }
 
 // WithSnapshot creates a new AppendBlobURL object identical to the source but with the specified snapshot timestamp.
@@ -6,6 +6,7 @@ package azblob
 import (
 	"context"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"io"
 )
@@ -27,14 +28,28 @@ type BlockBlobClient struct {
 	client *blockBlobClient
 }
 
-// NewBlockBlobClient creates a BlockBlobClient object using the specified URL and request policy pipeline.
-func NewBlockBlobClient(blobURL string, cred azcore.Credential, options *ClientOptions) (BlockBlobClient, error) {
-	con := newConnection(blobURL, cred, options.getConnectionOptions())
+// NewBlockBlobClient creates a BlockBlobClient object using the specified URL, Azure AD credential, and options.
+func NewBlockBlobClient(blobURL string, cred azcore.TokenCredential, options *ClientOptions) (BlockBlobClient, error) {
+	return newBlockBlobClient(blobURL, newTokenCredPolicy(cred), nil, options), nil
+}
+
+// NewBlockBlobClientWithNoCredential creates a BlockBlobClient object using the specified URL and options.
+// Use it for public access or when the blobURL contains a shared access signature.
+func NewBlockBlobClientWithNoCredential(blobURL string, options *ClientOptions) (BlockBlobClient, error) {
+	return newBlockBlobClient(blobURL, nil, nil, options), nil
+}
+
+// NewBlockBlobClientWithSharedKey creates a BlockBlobClient object using the specified URL, shared key, and options.
+func NewBlockBlobClientWithSharedKey(blobURL string, cred *SharedKeyCredential, options *ClientOptions) (BlockBlobClient, error) {
+	return newBlockBlobClient(blobURL, newSharedKeyCredPolicy(cred), cred, options), nil
+}
+
+func newBlockBlobClient(blobURL string, authPolicy policy.Policy, sharedKey *SharedKeyCredential, options *ClientOptions) BlockBlobClient {
+	con := newConnection(blobURL, authPolicy, options)
 	return BlockBlobClient{
 		client:     &blockBlobClient{con: con},
-		BlobClient: BlobClient{client: &blobClient{con: con}},
-	}, nil
-	//return bbc, nil
+		BlobClient: BlobClient{client: &blobClient{con: con}, sharedKey: sharedKey},
+	}
 }
 
 // WithSnapshot creates a new BlockBlobClient object identical to the source but with the specified snapshot timestamp.
@@ -5,6 +5,7 @@ package azblob
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -12,8 +13,8 @@ import (
 
 // A ContainerClient represents a URL to the Azure Storage container allowing you to manipulate its blobs.
 type ContainerClient struct {
-	client *containerClient
-	cred   azcore.Credential
+	client    *containerClient
+	sharedKey *SharedKeyCredential
 }
 
 // URL returns the URL endpoint used by the ContainerClient object.
@@ -21,11 +22,26 @@ func (c ContainerClient) URL() string {
 	return c.client.con.u
 }
 
-// NewContainerClient creates a ContainerClient object using the specified URL and request policy pipeline.
-func NewContainerClient(containerURL string, cred azcore.Credential, options *ClientOptions) (ContainerClient, error) {
+// NewContainerClient creates a ContainerClient object using the specified URL, Azure AD credential, and options.
+func NewContainerClient(containerURL string, cred azcore.TokenCredential, options *ClientOptions) (ContainerClient, error) {
 	return ContainerClient{client: &containerClient{
-		con: newConnection(containerURL, cred, options.getConnectionOptions()),
-	}, cred: cred}, nil
+		con: newConnection(containerURL, newTokenCredPolicy(cred), options),
+	}}, nil
+}
+
+// NewContainerClientWithNoCredential creates a ContainerClient object using the specified URL and options.
+// Use it for public access or when the containerURL contains a shared access signature.
+func NewContainerClientWithNoCredential(containerURL string, options *ClientOptions) (ContainerClient, error) {
+	return ContainerClient{client: &containerClient{
+		con: newConnection(containerURL, nil, options),
+	}}, nil
+}
+
+// NewContainerClientWithSharedKey creates a ContainerClient object using the specified URL, shared key, and options.
+func NewContainerClientWithSharedKey(containerURL string, cred *SharedKeyCredential, options *ClientOptions) (ContainerClient, error) {
+	return ContainerClient{client: &containerClient{
+		con: newConnection(containerURL, newSharedKeyCredPolicy(cred), options),
+	}, sharedKey: cred}, nil
 }
 
 // NewContainerClientFromConnectionString creates a ContainerClient object using connection string of an account
@@ -199,8 +215,11 @@ func (c ContainerClient) ListBlobsHierarchy(delimiter string, listOptions *Conta
 }
 
 // GetSASToken is a convenience method for generating a SAS token for the currently pointed at container.
-// It can only be used if the supplied azcore.Credential during creation was a SharedKeyCredential.
+// It can only be used if the client was created with a SharedKeyCredential.
 func (c ContainerClient) GetSASToken(permissions BlobSASPermissions, start time.Time, expiry time.Time) (SASQueryParameters, error) {
+	if c.sharedKey == nil {
+		return SASQueryParameters{}, errors.New("credential is not a SharedKeyCredential. SAS can only be signed with a SharedKeyCredential")
+	}
 	urlParts := NewBlobURLParts(c.URL())
 
 	// Containers do not have snapshots, nor versions.
@@ -212,5 +231,5 @@ func (c ContainerClient) GetSASToken(permissions BlobSASPermissions, start time.
 
 		StartTime:  start.UTC(),
 		ExpiryTime: expiry.UTC(),
-	}.NewSASQueryParameters(c.cred.(*SharedKeyCredential))
+	}.NewSASQueryParameters(c.sharedKey)
 }
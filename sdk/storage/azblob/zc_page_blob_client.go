@@ -6,6 +6,7 @@ package azblob
 import (
 	"context"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"io"
 	"net/url"
 )
@@ -20,12 +21,28 @@ type PageBlobClient struct {
 	client *pageBlobClient
 }
 
-func NewPageBlobClient(blobURL string, cred azcore.Credential, options *ClientOptions) (PageBlobClient, error) {
-	con := newConnection(blobURL, cred, options.getConnectionOptions())
+// NewPageBlobClient creates a PageBlobClient object using the specified URL, Azure AD credential, and options.
+func NewPageBlobClient(blobURL string, cred azcore.TokenCredential, options *ClientOptions) (PageBlobClient, error) {
+	return newPageBlobClient(blobURL, newTokenCredPolicy(cred), nil, options), nil
+}
+
+// NewPageBlobClientWithNoCredential creates a PageBlobClient object using the specified URL and options.
+// Use it for public access or when the blobURL contains a shared access signature.
+func NewPageBlobClientWithNoCredential(blobURL string, options *ClientOptions) (PageBlobClient, error) {
+	return newPageBlobClient(blobURL, nil, nil, options), nil
+}
+
+// NewPageBlobClientWithSharedKey creates a PageBlobClient object using the specified URL, shared key, and options.
+func NewPageBlobClientWithSharedKey(blobURL string, cred *SharedKeyCredential, options *ClientOptions) (PageBlobClient, error) {
+	return newPageBlobClient(blobURL, newSharedKeyCredPolicy(cred), cred, options), nil
+}
+
+func newPageBlobClient(blobURL string, authPolicy policy.Policy, sharedKey *SharedKeyCredential, options *ClientOptions) PageBlobClient {
+	con := newConnection(blobURL, authPolicy, options)
 	return PageBlobClient{
 		client:     &pageBlobClient{con: con},
-		BlobClient: BlobClient{client: &blobClient{con: con}},
-	}, nil
+		BlobClient: BlobClient{client: &blobClient{con: con}, sharedKey: sharedKey},
+	}
 }
 
 // WithSnapshot creates a new PageBlobURL object identical to the source but with the specified snapshot timestamp.
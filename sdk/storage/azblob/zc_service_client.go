@@ -6,12 +6,12 @@ package azblob
 import (
 	"context"
 	"errors"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 )
 
 //nolint
@@ -25,9 +25,9 @@ const (
 
 // A ServiceClient represents a URL to the Azure Storage Blob service allowing you to manipulate blob containers.
 type ServiceClient struct {
-	client *serviceClient
-	u      url.URL
-	cred   azcore.Credential
+	client    *serviceClient
+	u         url.URL
+	sharedKey *SharedKeyCredential
 }
 
 // URL returns the URL endpoint used by the ServiceClient object.
@@ -35,17 +35,34 @@ func (s ServiceClient) URL() string {
 	return s.client.con.u
 }
 
-// NewServiceClient creates a ServiceClient object using the specified URL, credential, and options.
+// NewServiceClient creates a ServiceClient object using the specified URL, Azure AD credential, and options.
+// Example of serviceURL: https://<your_storage_account>.blob.core.windows.net
+func NewServiceClient(serviceURL string, cred azcore.TokenCredential, options *ClientOptions) (ServiceClient, error) {
+	return newServiceClient(serviceURL, newTokenCredPolicy(cred), nil, options)
+}
+
+// NewServiceClientWithNoCredential creates a ServiceClient object using the specified URL and options.
+// Use it for public access or when the serviceURL contains a shared access signature.
+// Example of serviceURL: https://<your_storage_account>.blob.core.windows.net?<SAS token>
+func NewServiceClientWithNoCredential(serviceURL string, options *ClientOptions) (ServiceClient, error) {
+	return newServiceClient(serviceURL, nil, nil, options)
+}
+
+// NewServiceClientWithSharedKey creates a ServiceClient object using the specified URL, shared key, and options.
 // Example of serviceURL: https://<your_storage_account>.blob.core.windows.net
-func NewServiceClient(serviceURL string, cred azcore.Credential, options *ClientOptions) (ServiceClient, error) {
+func NewServiceClientWithSharedKey(serviceURL string, cred *SharedKeyCredential, options *ClientOptions) (ServiceClient, error) {
+	return newServiceClient(serviceURL, newSharedKeyCredPolicy(cred), cred, options)
+}
+
+func newServiceClient(serviceURL string, authPolicy policy.Policy, sharedKey *SharedKeyCredential, options *ClientOptions) (ServiceClient, error) {
 	u, err := url.Parse(serviceURL)
 	if err != nil {
 		return ServiceClient{}, err
 	}
 
 	return ServiceClient{client: &serviceClient{
-		con: newConnection(serviceURL, cred, options.getConnectionOptions()),
-	}, u: *u, cred: cred}, nil
+		con: newConnection(serviceURL, authPolicy, options),
+	}, u: *u, sharedKey: sharedKey}, nil
 }
 
 // NewServiceClientFromConnectionString creates a service client from the given connection string.
@@ -55,7 +72,10 @@ func NewServiceClientFromConnectionString(connectionString string, options *Clie
 	if err != nil {
 		return ServiceClient{}, err
 	}
-	return NewServiceClient(endpoint, credential, options)
+	if credential == nil {
+		return NewServiceClientWithNoCredential(endpoint, options)
+	}
+	return NewServiceClientWithSharedKey(endpoint, credential, options)
 }
 
 // NewContainerClient creates a new ContainerClient object by concatenating containerName to the end of
@@ -70,7 +90,7 @@ func (s ServiceClient) NewContainerClient(containerName string) ContainerClient
 		client: &containerClient{
 			con: containerConnection,
 		},
-		cred: s.cred,
+		sharedKey: s.sharedKey,
 	}
 }
 
@@ -187,15 +207,15 @@ func (s ServiceClient) GetStatistics(ctx context.Context) (ServiceGetStatisticsR
 }
 
 func (s ServiceClient) CanGetAccountSASToken() bool {
-	return s.cred != nil
+	return s.sharedKey != nil
 }
 
 // GetSASToken is a convenience method for generating a SAS token for the currently pointed at account.
-// It can only be used if the supplied azcore.Credential during creation was a SharedKeyCredential.
+// It can only be used if the client was created with a SharedKeyCredential.
 // This validity can be checked with CanGetAccountSASToken().
 func (s ServiceClient) GetSASToken(resources AccountSASResourceTypes, permissions AccountSASPermissions, services AccountSASServices, start time.Time, expiry time.Time) (string, error) {
-	cred, ok := s.cred.(*SharedKeyCredential)
-	if !ok {
+	cred := s.sharedKey
+	if cred == nil {
 		return "", errors.New("credential is not a SharedKeyCredential. SAS can only be signed with a SharedKeyCredential")
 	}
 
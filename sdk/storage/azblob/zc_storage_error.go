@@ -8,7 +8,9 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"net/http"
 	"sort"
 	"strings"
@@ -48,6 +50,8 @@ func (e *InternalError) As(target interface{}) bool {
 // StorageError is the internal struct that replaces the generated StorageError.
 // TL;DR: This implements xml.Unmarshaler, and when the original StorageError is substituted, this unmarshaler kicks in.
 // This handles the description and details. defunkifyStorageError handles the response, cause, and service code.
+// StorageError unwraps to an *azcore.ResponseError describing the same response, so callers can handle errors
+// from all Azure SDK clients with errors.As.
 type StorageError struct {
 	raw         string
 	response    *http.Response
@@ -58,8 +62,9 @@ type StorageError struct {
 }
 
 func handleError(err error) error {
-	if err, ok := err.(ResponseError); ok {
-		return &InternalError{defunkifyStorageError(err)}
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return &InternalError{defunkifyStorageError(respErr)}
 	}
 
 	if err != nil {
@@ -70,7 +75,7 @@ func handleError(err error) error {
 }
 
 // defunkifyStorageError is a function that takes the "funky" ResponseError and reduces it to a storageError.
-func defunkifyStorageError(responseError ResponseError) error {
+func defunkifyStorageError(responseError *azcore.ResponseError) error {
 	if err, ok := responseError.Unwrap().(*StorageError); ok {
 		// errors.Unwrap(responseError.Unwrap())
 
@@ -138,6 +143,15 @@ func (e StorageError) Response() *http.Response {
 	return e.response
 }
 
+// Unwrap returns an *azcore.ResponseError describing the error response, or nil when there's no response.
+func (e *StorageError) Unwrap() error {
+	if e.response == nil {
+		return nil
+	}
+	// a new error, because the ResponseError returned by the generated code wraps this StorageError
+	return runtime.NewResponseError(nil, e.response)
+}
+
 //nolint
 func writeRequestWithResponse(b *bytes.Buffer, request *policy.Request, response *http.Response) {
 	// Write the request into the buffer.
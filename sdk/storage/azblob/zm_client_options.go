@@ -4,29 +4,42 @@
 package azblob
 
 import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 )
 
+// tokenScope is the scope of Azure AD tokens for Azure Storage
+const tokenScope = "https://storage.azure.com/.default"
+
+// SecondaryReadOptions configures reads against the secondary endpoint of a read-access geo-redundant (RA-GRS)
+// storage account, e.g. "myaccount-secondary.blob.core.windows.net".
+type SecondaryReadOptions = policy.SecondaryReadOptions
+
+// ClientOptions configures the pipeline of the storage clients.
 type ClientOptions struct {
-	// Transporter sets the transport for making HTTP requests.
-	Transporter policy.Transporter
-	// Retry configures the built-in retry policy behavior.
-	Retry policy.RetryOptions
-	// Telemetry configures the built-in telemetry policy behavior.
-	Telemetry policy.TelemetryOptions
-	// PerCallOptions are options to run on every request
-	PerCallOptions []policy.Policy
+	azcore.ClientOptions
+
+	// SecondaryRead configures reads against the account's RA-GRS secondary endpoint.
+	SecondaryRead SecondaryReadOptions
 }
 
-func (o *ClientOptions) getConnectionOptions() *connectionOptions {
-	if o == nil {
-		return nil
+// newConnection creates an instance of the connection type with the specified endpoint.
+// authPolicy authorizes requests; pass nil for requests which don't need authorization, such as SAS URLs.
+// Pass nil to accept the default options; this is the same as passing a zero-value options.
+func newConnection(endpoint string, authPolicy policy.Policy, options *ClientOptions) *connection {
+	if options == nil {
+		options = &ClientOptions{}
 	}
-
-	return &connectionOptions{
-		HTTPClient:      o.Transporter,
-		Retry:           o.Retry,
-		Telemetry:       o.Telemetry,
-		PerCallPolicies: []policy.Policy{},
+	perRetry := []policy.Policy{runtime.NewSecondaryReadPolicy(&options.SecondaryRead)}
+	if authPolicy != nil {
+		perRetry = append(perRetry, authPolicy)
 	}
+	p := runtime.NewClientPipeline(module, version, runtime.PipelineOptions{PerRetry: perRetry}, &options.ClientOptions)
+	return &connection{u: endpoint, p: p}
+}
+
+// newTokenCredPolicy returns a policy authorizing requests with tokens from cred
+func newTokenCredPolicy(cred azcore.TokenCredential) policy.Policy {
+	return runtime.NewBearerTokenPolicy(cred, runtime.AuthenticationOptions{TokenRequest: policy.TokenRequestOptions{Scopes: []string{tokenScope}}})
 }
@@ -22,16 +22,15 @@ func (s *azblobTestSuite) TestConnectionStringParser() {
 	_assert.Equal(serviceURL, "https://dummyaccount.blob.core.windows.net")
 	_assert.NotNil(cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred := cred
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	_assert.Nil(err)
 	_assert.NotNil(client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred = client.sharedKey
+	_assert.NotNil(sharedKeyCred)
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 	_assert.True(strings.HasPrefix(client.client.con.Endpoint(), "https://"))
@@ -47,16 +46,15 @@ func (s *azblobTestSuite) TestConnectionStringParserHTTP() {
 	_assert.Equal(serviceURL, "http://dummyaccount.blob.core.windows.net")
 	_assert.NotNil(cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred := cred
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	_assert.Nil(err)
 	_assert.NotNil(client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred = client.sharedKey
+	_assert.NotNil(sharedKeyCred)
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 	_assert.True(strings.HasPrefix(client.client.con.Endpoint(), "http://"))
@@ -71,16 +69,15 @@ func (s *azblobTestSuite) TestConnectionStringParserBasic() {
 	_assert.Equal(serviceURL, "https://dummyaccount.blob.core.windows.net")
 	_assert.NotNil(cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred := cred
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	_assert.Nil(err)
 	_assert.NotNil(client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred = client.sharedKey
+	_assert.NotNil(sharedKeyCred)
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 	_assert.True(strings.HasPrefix(client.client.con.Endpoint(), "https://"))
@@ -95,16 +92,15 @@ func (s *azblobTestSuite) TestConnectionStringParserCustomDomain() {
 	_assert.Equal(serviceURL, "www.mydomain.com")
 	_assert.NotNil(cred)
 
-	sharedKeyCred, ok := cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred := cred
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	_assert.Nil(err)
 	_assert.NotNil(client)
-	sharedKeyCred, ok = client.cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKeyCred = client.sharedKey
+	_assert.NotNil(sharedKeyCred)
 	_assert.Equal(sharedKeyCred.accountName, "dummyaccount")
 	_assert.Equal(getAccountKey(sharedKeyCred), "secretkeykey")
 	_assert.True(strings.HasPrefix(client.client.con.Endpoint(), "www."))
@@ -137,11 +133,12 @@ func (s *azblobTestSuite) TestConnectionStringSAS() {
 	serviceURL, cred, err := parseConnectionString(connStr)
 	_assert.Nil(err)
 	_assert.Equal(serviceURL, "https://dummyaccount.blob.core.windows.net/?fakesharedaccesssignature")
-	_assert.NotNil(cred)
+	_assert.Nil(cred)
 
 	client, err := NewServiceClientFromConnectionString(connStr, nil)
 	_assert.Nil(err)
 	_assert.NotNil(client)
+	_assert.False(client.CanGetAccountSASToken())
 	_assert.True(strings.HasPrefix(client.client.con.Endpoint(), "https://"))
 	_assert.True(strings.Contains(client.client.con.Endpoint(), "core.windows.net"))
 }
@@ -160,8 +157,8 @@ func (s *azblobTestSuite) TestConnectionStringChinaCloud() {
 	_assert.True(strings.HasPrefix(client.client.con.Endpoint(), "http://"))
 	_assert.True(strings.Contains(client.client.con.Endpoint(), "core.chinacloudapi.cn"))
 
-	sharedKey, ok := client.cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKey := client.sharedKey
+	_assert.NotNil(sharedKey)
 	_assert.Equal(sharedKey.accountName, "dummyaccountname")
 	_assert.Equal(getAccountKey(sharedKey), "secretkeykey")
 }
@@ -180,8 +177,8 @@ func (s *azblobTestSuite) TestConnectionStringAzurite() {
 	_assert.True(strings.HasPrefix(client.client.con.Endpoint(), "http://"))
 	_assert.True(strings.Contains(client.client.con.Endpoint(), "http://local-machine:11002/custom/account/path/faketokensignature"))
 
-	sharedKey, ok := client.cred.(*SharedKeyCredential)
-	_assert.True(ok)
+	sharedKey := client.sharedKey
+	_assert.NotNil(sharedKey)
 	_assert.Equal(sharedKey.accountName, "dummyaccountname")
 	_assert.Equal(getAccountKey(sharedKey), "secretkeykey")
 }
@@ -10,6 +10,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	testframework "github.com/Azure/azure-sdk-for-go/sdk/internal/recording"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/internal"
@@ -410,9 +411,9 @@ func getConnectionString(recording *testframework.Recording, accountType testAcc
 func getServiceClientFromConnectionString(recording *testframework.Recording, accountType testAccountType, options *ClientOptions) (ServiceClient, error) {
 	if recording != nil {
 		if options == nil {
-			options = &ClientOptions{
-				Transporter: recording,
-				Retry:       policy.RetryOptions{MaxRetries: -1}}
+			options = &ClientOptions{ClientOptions: azcore.ClientOptions{
+				Transport: recording,
+				Retry:     policy.RetryOptions{MaxRetries: -1}}}
 		}
 	}
 
@@ -422,7 +423,7 @@ func getServiceClientFromConnectionString(recording *testframework.Recording, ac
 		return ServiceClient{}, nil
 	}
 
-	svcClient, err := NewServiceClient(primaryURL, cred, options)
+	svcClient, err := NewServiceClientWithSharedKey(primaryURL, cred, options)
 	return svcClient, err
 }
 
@@ -438,9 +439,9 @@ const (
 func getServiceClient(recording *testframework.Recording, accountType testAccountType, options *ClientOptions) (ServiceClient, error) {
 	if recording != nil {
 		if options == nil {
-			options = &ClientOptions{
-				Transporter: recording,
-				Retry:       policy.RetryOptions{MaxRetries: -1}}
+			options = &ClientOptions{ClientOptions: azcore.ClientOptions{
+				Transport: recording,
+				Retry:     policy.RetryOptions{MaxRetries: -1}}}
 		}
 	}
 
@@ -450,7 +451,7 @@ func getServiceClient(recording *testframework.Recording, accountType testAccoun
 	}
 
 	serviceURL, _ := url.Parse("https://" + cred.AccountName() + ".blob.core.windows.net/")
-	serviceClient, err := NewServiceClient(serviceURL.String(), cred, options)
+	serviceClient, err := NewServiceClientWithSharedKey(serviceURL.String(), cred, options)
 
 	return serviceClient, err
 }
@@ -536,6 +537,10 @@ func validateStorageError(_assert *assert.Assertions, err error, code StorageErr
 	_assert.Equal(errors.As(err, &storageError), true)
 
 	_assert.Equal(storageError.ErrorCode, code)
+
+	var respErr *azcore.ResponseError
+	_assert.Equal(errors.As(err, &respErr), true)
+	_assert.Equal(respErr.ErrorCode, string(code))
 }
 
 func blobListToMap(list []string) map[string]bool {